@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"nav-panel-backend/internal/config"
+	"nav-panel-backend/internal/database"
+	"os"
+	"strconv"
+)
+
+const usage = `Usage: migrate [-config file] [-data-dir dir] <command>
+
+Commands:
+  status         列出所有迁移及其执行状态
+  pending        列出尚未执行的迁移，存在未执行迁移时以状态码 1 退出
+  up             执行所有未执行的迁移
+  down <version> 回滚到指定版本（回滚所有版本号大于 version 的迁移）
+`
+
+func main() {
+	cfg, args, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	db, err := database.InitDatabase(cfg.DBPath())
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer db.Close()
+
+	database.SetSeed(cfg.Seed)
+
+	switch args[0] {
+	case "status":
+		statuses, err := database.Status(db)
+		if err != nil {
+			log.Fatal("Failed to read migration status:", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied at " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-40s %s\n", s.Version, s.Name, state)
+		}
+
+	case "pending":
+		pending, err := database.Pending(db)
+		if err != nil {
+			log.Fatal("Failed to read pending migrations:", err)
+		}
+		if len(pending) == 0 {
+			fmt.Println("Database is up to date")
+			return
+		}
+		for _, m := range pending {
+			fmt.Printf("%4d  %s\n", m.Version, m.Name)
+		}
+		os.Exit(1)
+
+	case "up":
+		if err := database.Migrate(db); err != nil {
+			log.Fatal("Failed to run migrations:", err)
+		}
+		fmt.Println("Migrations applied")
+
+	case "down":
+		if len(args) < 2 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		target, err := strconv.Atoi(args[1])
+		if err != nil {
+			log.Fatal("Invalid version:", args[1])
+		}
+		if err := database.Rollback(db, target); err != nil {
+			log.Fatal("Failed to roll back migrations:", err)
+		}
+		fmt.Printf("Rolled back to version %d\n", target)
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
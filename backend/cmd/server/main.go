@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"nav-panel-backend/internal/config"
 	"nav-panel-backend/internal/database"
 	"nav-panel-backend/internal/handler"
+	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/repository"
 	"nav-panel-backend/internal/service"
+	"os"
+	"slices"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -14,14 +19,22 @@ import (
 )
 
 func main() {
+	// 加载配置
+	cfg, _, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	gin.SetMode(cfg.GinMode)
+
 	// 初始化数据库
-	db, err := database.InitDatabase()
+	db, err := database.InitDatabase(cfg.DBPath())
 	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer db.Close()
 
 	// 运行数据库迁移
+	database.SetSeed(cfg.Seed)
 	if err := database.Migrate(db); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
@@ -29,33 +42,109 @@ func main() {
 	// 初始化仓库层
 	websiteRepo := repository.NewWebsiteRepository(db)
 	searchEngineRepo := repository.NewSearchEngineRepository(db)
+	searchEngineCategoryRepo := repository.NewSearchEngineCategoryRepository(db)
 	settingsRepo := repository.NewSettingsRepository(db)
 	groupRepo := repository.NewGroupRepository(db)
+	importRepo := repository.NewImportRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	apiTokenRepo := repository.NewAPITokenRepository(db)
+	healthRepo := repository.NewHealthRepository(db)
+	iconRepo := repository.NewIconRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	visitRepo := repository.NewVisitRepository(db)
+	searchHistoryRepo := repository.NewSearchHistoryRepository(db)
+	trashRepo := repository.NewTrashRepository(db)
 
 	// 初始化服务层
-	websiteService := service.NewWebsiteService(websiteRepo)
-	searchEngineService := service.NewSearchEngineService(searchEngineRepo)
-	settingsService := service.NewSettingsService(settingsRepo)
-	groupService := service.NewGroupService(groupRepo)
+	fetchClient := service.NewFetchClient(service.FetchConfig{AllowPrivateNetworks: cfg.Fetch.AllowPrivateNetworks})
+	iconService := service.NewIconService(iconRepo, service.IconConfig{
+		Discovery:       cfg.Icons.Discovery,
+		RefreshInterval: time.Duration(cfg.Icons.RefreshInterval),
+		Timeout:         time.Duration(cfg.Icons.Timeout),
+		MaxSize:         int64(cfg.Upload.MaxIconSize),
+	}, fetchClient)
+	websiteService := service.NewWebsiteService(websiteRepo, groupRepo, tagRepo, iconService)
+	searchEngineService := service.NewSearchEngineService(searchEngineRepo, searchEngineCategoryRepo, iconService)
+	searchEngineCategoryService := service.NewSearchEngineCategoryService(searchEngineCategoryRepo)
+	previewService := service.NewPreviewService(websiteRepo, groupRepo, iconService, fetchClient)
+	settingsService := service.NewSettingsService(settingsRepo, iconService, previewService)
+	groupService := service.NewGroupService(groupRepo, iconService)
+	tagService := service.NewTagService(tagRepo)
+	visitService := service.NewVisitService(visitRepo, websiteRepo)
+	searchHistoryService := service.NewSearchHistoryService(searchHistoryRepo, searchEngineRepo)
+	openSearchService := service.NewOpenSearchService(searchEngineRepo, websiteRepo, groupRepo, searchHistoryService)
+	authService := service.NewAuthService(userRepo, cfg.Auth)
+	userService := service.NewUserService(userRepo)
+	apiTokenService := service.NewAPITokenService(apiTokenRepo, userRepo)
+	healthService := service.NewHealthService(healthRepo, service.HealthConfig{
+		Interval:    time.Duration(cfg.HealthCheck.Interval),
+		Concurrency: cfg.HealthCheck.Concurrency,
+		Timeout:     time.Duration(cfg.HealthCheck.Timeout),
+	}, fetchClient)
+	backupService := service.NewBackupService(db, service.BackupConfig{
+		Dir:        cfg.BackupDir(),
+		Interval:   time.Duration(cfg.Backup.Interval),
+		KeepDaily:  cfg.Backup.KeepDaily,
+		KeepWeekly: cfg.Backup.KeepWeekly,
+	})
+	trashService := service.NewTrashService(trashRepo, service.TrashConfig{
+		Retention: time.Duration(cfg.Trash.Retention),
+	})
 	
 	// 为设置服务设置其他仓库依赖（用于导入导出功能）
-	settingsService.SetRepositories(websiteRepo, searchEngineRepo)
+	settingsService.SetRepositories(websiteRepo, searchEngineRepo, searchEngineCategoryRepo, groupRepo, tagRepo, importRepo)
+
+	// 首次启动时创建管理员账号
+	if admin, password, err := authService.EnsureAdmin(); err != nil {
+		log.Fatal("Failed to create admin account:", err)
+	} else if admin != nil && password != "" {
+		log.Printf("Created admin account %q with generated password: %s (change it after logging in)", admin.Username, password)
+	} else if admin != nil {
+		log.Printf("Created admin account %q", admin.Username)
+	}
+	if !cfg.Auth.Enabled {
+		log.Println("Warning: authentication is disabled, anyone who can reach the server can modify data")
+	}
 
 	// 初始化处理器层
-	websiteHandler := handler.NewWebsiteHandler(websiteService)
-	searchEngineHandler := handler.NewSearchEngineHandler(searchEngineService)
+	websiteHandler := handler.NewWebsiteHandler(websiteService, previewService)
+	searchEngineHandler := handler.NewSearchEngineHandler(searchEngineService, iconService, cfg.Upload)
+	searchEngineCategoryHandler := handler.NewSearchEngineCategoryHandler(searchEngineCategoryService)
 	settingsHandler := handler.NewSettingsHandler(settingsService)
 	groupHandler := handler.NewGroupHandler(groupService)
+	tagHandler := handler.NewTagHandler(tagService)
+	visitHandler := handler.NewVisitHandler(visitService)
+	searchHistoryHandler := handler.NewSearchHistoryHandler(searchHistoryService)
+	searchHandler := handler.NewSearchHandler(searchEngineService, searchHistoryService)
+	openSearchHandler := handler.NewOpenSearchHandler(openSearchService, cfg.PublicURL)
+	backupHandler := handler.NewBackupHandler(backupService)
+	authHandler := handler.NewAuthHandler(authService, apiTokenService)
+	userHandler := handler.NewUserHandler(userService)
+	apiTokenHandler := handler.NewAPITokenHandler(apiTokenService)
+	healthHandler := handler.NewHealthHandler(healthService)
+	iconHandler := handler.NewIconHandler(iconService, cfg.Upload)
+
+	// 启动定时备份、链接健康检查、图标刷新和回收站清理
+	backupService.Start(context.Background())
+	healthService.Start(context.Background())
+	iconService.Start(context.Background())
+	trashService.Start(context.Background())
 
 	// 初始化 Gin 路由
 	r := gin.Default()
+	r.MaxMultipartMemory = int64(cfg.Upload.MaxRequestSize)
 
 	// 配置 CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000", "http://localhost:5173"} // 前端地址
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	r.Use(cors.New(config))
+	corsConfig := cors.DefaultConfig()
+	if slices.Contains(cfg.AllowedOrigins, "*") {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = cfg.AllowedOrigins // 前端地址
+		corsConfig.AllowCredentials = true           // 允许携带会话 Cookie
+	}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	r.Use(cors.New(corsConfig))
 
 	// 注册路由
 	api := r.Group("/api")
@@ -69,54 +158,168 @@ func main() {
 			})
 		})
 
+		// 图标按内容哈希访问，无需登录，供 <img> 直接引用
+		api.GET("/icons/:hash", iconHandler.Get)
+
+		// 认证路由
+		api.Use(authHandler.Authenticate())
+		auth := api.Group("/auth")
+		{
+			auth.POST("/login", authHandler.Login)
+			auth.POST("/logout", authHandler.Logout)
+			auth.GET("/status", authHandler.Status)
+			auth.PUT("/password", authHandler.RequireSession(), authHandler.ChangePassword)
+		}
+
+		// API 令牌路由，只能通过登录会话管理
+		tokens := api.Group("/tokens", authHandler.RequireSession())
+		{
+			tokens.GET("", apiTokenHandler.GetAll)
+			tokens.POST("", apiTokenHandler.Create)
+			tokens.DELETE("/:id", apiTokenHandler.Revoke)
+		}
+
+		// 账号管理路由，仅管理员可用
+		users := api.Group("/users", authHandler.RequireAdmin())
+		{
+			users.GET("", userHandler.GetAll)
+			users.POST("", userHandler.Create)
+			users.PUT("/:id", userHandler.Update)
+			users.DELETE("/:id", userHandler.Delete)
+		}
+
+		// 以下路由的数据按账号隔离，写操作需要登录
+		// 使用 API 令牌访问时，每个路由组按请求方法检查对应的 read 或 write 权限范围，关闭公开只读模式时读操作也需要登录
+		protected := api.Group("", authHandler.RequireAuth())
+
 		// 网站路由
-		websites := api.Group("/websites")
+		websites := protected.Group("/websites", authHandler.RequireScope(model.ScopeWebsitesRead, model.ScopeWebsitesWrite))
 		{
 			websites.GET("", websiteHandler.GetAll)
+			websites.GET("/health", healthHandler.GetAll)
+			websites.GET("/search", websiteHandler.Search)
+			websites.GET("/stats/most-visited", visitHandler.MostVisited)
+			websites.GET("/stats/least-visited", visitHandler.LeastVisited)
+			websites.GET("/stats/never-visited", visitHandler.NeverVisited)
+			websites.GET("/stats/visits", visitHandler.Visits)
 			websites.POST("", websiteHandler.Create)
+			websites.POST("/preview", websiteHandler.Preview)
 			websites.PUT("/reorder", websiteHandler.Reorder)
+			websites.GET("/trash", websiteHandler.GetTrash)
+			websites.DELETE("/trash", websiteHandler.EmptyTrash)
+			websites.POST("/trash/:id/restore", websiteHandler.Restore)
+			websites.DELETE("/trash/:id", websiteHandler.Purge)
 			websites.PUT("/:id", websiteHandler.Update)
 			websites.DELETE("/:id", websiteHandler.Delete)
+			websites.POST("/:id/check", healthHandler.Check)
+			websites.POST("/:id/icon", websiteHandler.RefreshIcon)
+		}
+
+		// 标签路由
+		tags := protected.Group("/tags", authHandler.RequireScope(model.ScopeWebsitesRead, model.ScopeWebsitesWrite))
+		{
+			tags.GET("", tagHandler.GetAll)
+			tags.POST("", tagHandler.Create)
+			tags.POST("/merge", tagHandler.Merge)
+			tags.PUT("/:id", tagHandler.Update)
+			tags.DELETE("/:id", tagHandler.Delete)
+		}
+
+		// 图标上传路由，上传后返回的地址可用于网站、分组和搜索引擎
+		icons := protected.Group("/icons", authHandler.RequireScope(model.ScopeWebsitesRead, model.ScopeWebsitesWrite))
+		{
+			icons.POST("", iconHandler.Upload)
 		}
 
 		// 搜索引擎路由
-		searchEngines := api.Group("/search-engines")
+		searchEngines := protected.Group("/search-engines", authHandler.RequireScope(model.ScopeSearchEnginesRead, model.ScopeSearchEnginesWrite))
 		{
 			searchEngines.GET("", searchEngineHandler.GetAll)
 			searchEngines.POST("", searchEngineHandler.Create)
 			searchEngines.POST("/with-icon", searchEngineHandler.CreateWithIcon)
+			searchEngines.PUT("/reorder", searchEngineHandler.Reorder)
+			searchEngines.GET("/categories", searchEngineCategoryHandler.GetAll)
+			searchEngines.POST("/categories", searchEngineCategoryHandler.Create)
+			searchEngines.PUT("/categories/reorder", searchEngineCategoryHandler.Reorder)
+			searchEngines.PUT("/categories/:id", searchEngineCategoryHandler.Update)
+			searchEngines.DELETE("/categories/:id", searchEngineCategoryHandler.Delete)
+			searchEngines.GET("/trash", searchEngineHandler.GetTrash)
+			searchEngines.DELETE("/trash", searchEngineHandler.EmptyTrash)
+			searchEngines.POST("/trash/:id/restore", searchEngineHandler.Restore)
+			searchEngines.DELETE("/trash/:id", searchEngineHandler.Purge)
 			searchEngines.PUT("/:id", searchEngineHandler.Update)
 			searchEngines.PUT("/:id/with-icon", searchEngineHandler.UpdateWithIcon)
 			searchEngines.DELETE("/:id", searchEngineHandler.Delete)
+			searchEngines.POST("/:id/resolve", searchEngineHandler.Resolve)
+		}
+
+		// 搜索历史路由，历史属于个人隐私，公开只读模式下也需要登录
+		search := api.Group("/search", authHandler.RequireLogin(), authHandler.RequireScope(model.ScopeSearchEnginesRead, model.ScopeSearchEnginesWrite))
+		{
+			search.GET("/suggest", searchHistoryHandler.Suggest)
+			search.GET("/history", searchHistoryHandler.GetRecent)
+			search.POST("/history", searchHistoryHandler.Record)
+			search.DELETE("/history", searchHistoryHandler.Clear)
+			search.GET("/history/settings", searchHistoryHandler.GetSettings)
+			search.PUT("/history/settings", searchHistoryHandler.UpdateSettings)
+			search.DELETE("/history/:id", searchHistoryHandler.Delete)
 		}
 
 		// 设置路由
-		settings := api.Group("/settings")
+		settings := protected.Group("/settings", authHandler.RequireScope(model.ScopeSettingsRead, model.ScopeSettingsWrite))
 		{
 			settings.GET("", settingsHandler.Get)
 			settings.PUT("", settingsHandler.Update)
 		}
 
 		// 分组路由
-		groups := api.Group("/groups")
+		groups := protected.Group("/groups", authHandler.RequireScope(model.ScopeWebsitesRead, model.ScopeWebsitesWrite))
 		{
 			groups.GET("", groupHandler.GetGroups)
 			groups.GET("/with-websites", groupHandler.GetGroupsWithWebsites)
+			groups.GET("/tree", groupHandler.GetGroupTree)
 			groups.POST("", groupHandler.CreateGroup)
 			groups.PUT("/reorder", groupHandler.ReorderGroups)
+			groups.GET("/trash", groupHandler.GetTrash)
+			groups.DELETE("/trash", groupHandler.EmptyTrash)
+			groups.POST("/trash/:id/restore", groupHandler.RestoreGroup)
+			groups.DELETE("/trash/:id", groupHandler.PurgeGroup)
 			groups.PUT("/:id", groupHandler.UpdateGroup)
+			groups.PUT("/:id/move", groupHandler.MoveGroup)
 			groups.DELETE("/:id", groupHandler.DeleteGroup)
 			groups.POST("/move-website", groupHandler.MoveWebsiteToGroup)
 		}
 
-		// 数据导入导出
-		api.GET("/export", settingsHandler.Export)
-		api.POST("/import", settingsHandler.Import)
+		// 数据导入导出，只涉及当前账号的数据，始终需要登录
+		transfer := api.Group("", authHandler.RequireLogin(), authHandler.RequireScope(model.ScopeDataRead, model.ScopeDataWrite))
+		{
+			transfer.GET("/export", settingsHandler.Export)
+			transfer.POST("/import", settingsHandler.Import)
+			transfer.GET("/export/bookmarks", settingsHandler.ExportBookmarks)
+			transfer.POST("/import/bookmarks", settingsHandler.ImportBookmarks)
+		}
+
+		// 数据库备份路由，备份包含所有账号的数据，仅管理员可用
+		backups := api.Group("/backups", authHandler.RequireAdmin())
+		{
+			backups.GET("", backupHandler.List)
+			backups.POST("", backupHandler.Create)
+			backups.GET("/:name", backupHandler.Download)
+			backups.POST("/:name/restore", backupHandler.Restore)
+		}
 	}
 
+	// 网站跳转链接，记录访问后 302 跳转，面板中的链接直接指向这里
+	r.GET("/go/:websiteId", authHandler.Authenticate(), authHandler.RequireScope(model.ScopeWebsitesRead, model.ScopeWebsitesWrite), visitHandler.Go)
+	// 关键词搜索跳转，"!gh foo" 或 "gh foo" 使用对应引擎搜索，浏览器默认搜索引擎可设为 /search?q=%s
+	r.GET("/search", authHandler.Authenticate(), authHandler.RequireScope(model.ScopeSearchEnginesRead, model.ScopeSearchEnginesWrite), searchHandler.Search)
+	// OpenSearch 描述文档和地址栏搜索建议，浏览器可将面板添加为搜索引擎
+	r.GET("/opensearch.xml", authHandler.Authenticate(), openSearchHandler.Description)
+	r.GET("/search/suggest", authHandler.Authenticate(), openSearchHandler.Suggest)
+
 	// 启动服务器
-	log.Println("Server starting on :8080")
-	if err := r.Run(":8080"); err != nil {
+	log.Println("Server starting on", cfg.Listen)
+	if err := r.Run(cfg.Listen); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,480 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config 应用配置
+// 加载顺序：默认值 < 配置文件（YAML 或 TOML）< 环境变量 < 命令行参数
+type Config struct {
+	Listen         string      `yaml:"listen" toml:"listen"`
+	DataDir        string      `yaml:"dataDir" toml:"dataDir"`
+	AllowedOrigins []string    `yaml:"allowedOrigins" toml:"allowedOrigins"`
+	GinMode        string      `yaml:"ginMode" toml:"ginMode"`
+	PublicURL      string      `yaml:"publicURL" toml:"publicURL"` // 对外访问的地址，用于生成 OpenSearch 描述等绝对链接，为空时按请求推断
+	Upload         Upload      `yaml:"upload" toml:"upload"`
+	Backup         Backup      `yaml:"backup" toml:"backup"`
+	HealthCheck    HealthCheck `yaml:"healthCheck" toml:"healthCheck"`
+	Icons          Icons       `yaml:"icons" toml:"icons"`
+	Fetch          Fetch       `yaml:"fetch" toml:"fetch"`
+	Trash          Trash       `yaml:"trash" toml:"trash"`
+	Auth           Auth        `yaml:"auth" toml:"auth"`
+	Seed           Seed        `yaml:"seed" toml:"seed"`
+}
+
+// Upload 上传限制
+type Upload struct {
+	MaxRequestSize ByteSize `yaml:"maxRequestSize" toml:"maxRequestSize"` // multipart 请求体上限
+	MaxIconSize    ByteSize `yaml:"maxIconSize" toml:"maxIconSize"`       // 单个图标文件上限
+}
+
+// Backup 自动备份配置
+type Backup struct {
+	Interval   Duration `yaml:"interval" toml:"interval"` // 为0时不启用定时备份
+	KeepDaily  int      `yaml:"keepDaily" toml:"keepDaily"`
+	KeepWeekly int      `yaml:"keepWeekly" toml:"keepWeekly"`
+}
+
+// HealthCheck 网站链接健康检查配置
+type HealthCheck struct {
+	Interval    Duration `yaml:"interval" toml:"interval"`       // 为0时不启用定时检查
+	Concurrency int      `yaml:"concurrency" toml:"concurrency"` // 同时检查的网站数量
+	Timeout     Duration `yaml:"timeout" toml:"timeout"`         // 单个网站的检查超时，包括重定向
+}
+
+// Icons 网站图标自动发现配置
+type Icons struct {
+	Discovery       bool     `yaml:"discovery" toml:"discovery"`             // 创建网站或修改 URL 时自动发现图标
+	RefreshInterval Duration `yaml:"refreshInterval" toml:"refreshInterval"` // 自动发现的图标多久重新获取一次，为0时不定时刷新
+	Timeout         Duration `yaml:"timeout" toml:"timeout"`                 // 单个网站的图标发现超时
+}
+
+// Fetch 服务端请求外部网址（网页预览、图标发现、健康检查）的访问限制
+type Fetch struct {
+	AllowPrivateNetworks bool `yaml:"allowPrivateNetworks" toml:"allowPrivateNetworks"` // 允许访问回环和内网地址，链路本地地址始终禁止
+}
+
+// Trash 回收站配置
+type Trash struct {
+	Retention Duration `yaml:"retention" toml:"retention"` // 删除的网站、分组和搜索引擎在回收站中保留多久，为0时不自动清理
+}
+
+// Auth 管理员认证配置
+type Auth struct {
+	Enabled       bool     `yaml:"enabled" toml:"enabled"`             // 为 false 时所有接口都不需要登录，仅适用于受信任的内网
+	PublicRead    bool     `yaml:"publicRead" toml:"publicRead"`       // 未登录时允许只读访问
+	PublicUser    string   `yaml:"publicUser" toml:"publicUser"`       // 未登录或关闭认证时展示哪个账号的面板，为空时使用最早创建的管理员
+	AdminUsername string   `yaml:"adminUsername" toml:"adminUsername"` // 首次启动时创建的管理员用户名
+	AdminPassword string   `yaml:"adminPassword" toml:"adminPassword"` // 首次启动时的管理员密码，为空时随机生成并打印到日志
+	SessionTTL    Duration `yaml:"sessionTTL" toml:"sessionTTL"`       // 登录会话有效期
+	CookieSecure  bool     `yaml:"cookieSecure" toml:"cookieSecure"`   // 仅通过 HTTPS 发送会话 Cookie
+}
+
+// Seed 新建数据库时写入的默认数据
+type Seed struct {
+	Theme   string       `yaml:"theme" toml:"theme"`
+	Engines []SeedEngine `yaml:"engines" toml:"engines"`
+}
+
+// SeedEngine 默认搜索引擎
+type SeedEngine struct {
+	ID          string `yaml:"id" toml:"id"`
+	Name        string `yaml:"name" toml:"name"`
+	URL         string `yaml:"url" toml:"url"`
+	Placeholder string `yaml:"placeholder" toml:"placeholder"`
+	IsDefault   bool   `yaml:"isDefault" toml:"isDefault"`
+	Keyword     string `yaml:"keyword" toml:"keyword"` // 快捷关键词，/search?q=!g foo 使用该引擎搜索
+}
+
+// Default 默认配置
+func Default() *Config {
+	ginMode := os.Getenv(gin.EnvGinMode)
+	if ginMode == "" {
+		ginMode = gin.DebugMode
+	}
+
+	return &Config{
+		Listen:         ":8080",
+		DataDir:        "data",
+		AllowedOrigins: []string{"http://localhost:3000", "http://localhost:5173"},
+		GinMode:        ginMode,
+		Upload: Upload{
+			MaxRequestSize: 10 << 20,
+			MaxIconSize:    2 << 20,
+		},
+		Backup: Backup{
+			Interval:   Duration(24 * time.Hour),
+			KeepDaily:  7,
+			KeepWeekly: 4,
+		},
+		HealthCheck: HealthCheck{
+			Interval:    Duration(6 * time.Hour),
+			Concurrency: 8,
+			Timeout:     Duration(10 * time.Second),
+		},
+		Icons: Icons{
+			Discovery:       true,
+			RefreshInterval: Duration(7 * 24 * time.Hour),
+			Timeout:         Duration(15 * time.Second),
+		},
+		Trash: Trash{
+			Retention: Duration(30 * 24 * time.Hour),
+		},
+		Auth: Auth{
+			Enabled:       true,
+			PublicRead:    true,
+			AdminUsername: "admin",
+			SessionTTL:    Duration(30 * 24 * time.Hour),
+		},
+		Seed: Seed{
+			Theme: "light",
+			Engines: []SeedEngine{
+				{"google", "Google", "https://www.google.com/search?q={}", "使用 Google 搜索...", true, "g"},
+				{"baidu", "百度", "https://www.baidu.com/s?wd={}", "使用百度搜索...", false, "bd"},
+				{"bing", "Bing", "https://www.bing.com/search?q={}", "使用 Bing 搜索...", false, "b"},
+				{"github", "GitHub", "https://github.com/search?q={}", "在 GitHub 中搜索...", false, "gh"},
+			},
+		},
+	}
+}
+
+// option 可以通过环境变量和命令行参数设置的配置项
+type option struct {
+	flag  string
+	env   string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var options = []option{
+	{"listen", "NAV_LISTEN", "监听地址，如 :8080 或 127.0.0.1:8080", func(c *Config, v string) error {
+		c.Listen = v
+		return nil
+	}},
+	{"data-dir", "NAV_DATA_DIR", "数据目录，存放数据库和备份", func(c *Config, v string) error {
+		c.DataDir = v
+		return nil
+	}},
+	{"allowed-origins", "NAV_ALLOWED_ORIGINS", "允许跨域访问的来源，逗号分隔，* 表示全部", func(c *Config, v string) error {
+		c.AllowedOrigins = splitList(v)
+		return nil
+	}},
+	{"gin-mode", "NAV_GIN_MODE", "Gin 运行模式：debug、release 或 test", func(c *Config, v string) error {
+		c.GinMode = v
+		return nil
+	}},
+	{"public-url", "NAV_PUBLIC_URL", "对外访问的地址，如 https://nav.example.com，为空时按请求推断", func(c *Config, v string) error {
+		c.PublicURL = v
+		return nil
+	}},
+	{"max-request-size", "NAV_MAX_REQUEST_SIZE", "multipart 请求体上限，如 10MB", func(c *Config, v string) error {
+		return c.Upload.MaxRequestSize.UnmarshalText([]byte(v))
+	}},
+	{"max-icon-size", "NAV_MAX_ICON_SIZE", "单个图标文件上限，如 2MB", func(c *Config, v string) error {
+		return c.Upload.MaxIconSize.UnmarshalText([]byte(v))
+	}},
+	{"backup-interval", "NAV_BACKUP_INTERVAL", "自动备份间隔，如 24h，0 表示关闭", func(c *Config, v string) error {
+		return c.Backup.Interval.UnmarshalText([]byte(v))
+	}},
+	{"backup-keep-daily", "NAV_BACKUP_KEEP_DAILY", "保留最近多少天的每日备份", func(c *Config, v string) error {
+		return parseInt(&c.Backup.KeepDaily, v)
+	}},
+	{"backup-keep-weekly", "NAV_BACKUP_KEEP_WEEKLY", "保留最近多少周的每周备份", func(c *Config, v string) error {
+		return parseInt(&c.Backup.KeepWeekly, v)
+	}},
+	{"health-check-interval", "NAV_HEALTH_CHECK_INTERVAL", "网站链接健康检查间隔，如 6h，0 表示关闭", func(c *Config, v string) error {
+		return c.HealthCheck.Interval.UnmarshalText([]byte(v))
+	}},
+	{"health-check-concurrency", "NAV_HEALTH_CHECK_CONCURRENCY", "同时检查的网站数量", func(c *Config, v string) error {
+		return parseInt(&c.HealthCheck.Concurrency, v)
+	}},
+	{"health-check-timeout", "NAV_HEALTH_CHECK_TIMEOUT", "单个网站的检查超时，如 10s", func(c *Config, v string) error {
+		return c.HealthCheck.Timeout.UnmarshalText([]byte(v))
+	}},
+	{"icon-discovery", "NAV_ICON_DISCOVERY", "创建网站或修改 URL 时自动发现图标", func(c *Config, v string) error {
+		return parseBool(&c.Icons.Discovery, v)
+	}},
+	{"icon-refresh-interval", "NAV_ICON_REFRESH_INTERVAL", "自动发现的图标刷新间隔，如 168h，0 表示关闭", func(c *Config, v string) error {
+		return c.Icons.RefreshInterval.UnmarshalText([]byte(v))
+	}},
+	{"icon-timeout", "NAV_ICON_TIMEOUT", "单个网站的图标发现超时，如 15s", func(c *Config, v string) error {
+		return c.Icons.Timeout.UnmarshalText([]byte(v))
+	}},
+	{"fetch-allow-private-networks", "NAV_FETCH_ALLOW_PRIVATE_NETWORKS", "允许网页预览、图标发现和健康检查访问回环和内网地址", func(c *Config, v string) error {
+		return parseBool(&c.Fetch.AllowPrivateNetworks, v)
+	}},
+	{"trash-retention", "NAV_TRASH_RETENTION", "回收站条目保留多久后永久删除，如 720h，0 表示不自动清理", func(c *Config, v string) error {
+		return c.Trash.Retention.UnmarshalText([]byte(v))
+	}},
+	{"auth-enabled", "NAV_AUTH_ENABLED", "是否启用管理员认证", func(c *Config, v string) error {
+		return parseBool(&c.Auth.Enabled, v)
+	}},
+	{"public-read", "NAV_PUBLIC_READ", "未登录时允许只读访问", func(c *Config, v string) error {
+		return parseBool(&c.Auth.PublicRead, v)
+	}},
+	{"public-user", "NAV_PUBLIC_USER", "未登录时展示哪个账号的面板，默认最早创建的管理员", func(c *Config, v string) error {
+		c.Auth.PublicUser = v
+		return nil
+	}},
+	{"admin-username", "NAV_ADMIN_USERNAME", "首次启动时创建的管理员用户名", func(c *Config, v string) error {
+		c.Auth.AdminUsername = v
+		return nil
+	}},
+	{"admin-password", "NAV_ADMIN_PASSWORD", "首次启动时的管理员密码，为空时随机生成", func(c *Config, v string) error {
+		c.Auth.AdminPassword = v
+		return nil
+	}},
+	{"session-ttl", "NAV_SESSION_TTL", "登录会话有效期，如 720h", func(c *Config, v string) error {
+		return c.Auth.SessionTTL.UnmarshalText([]byte(v))
+	}},
+	{"cookie-secure", "NAV_COOKIE_SECURE", "仅通过 HTTPS 发送会话 Cookie", func(c *Config, v string) error {
+		return parseBool(&c.Auth.CookieSecure, v)
+	}},
+}
+
+// Load 依次加载默认值、配置文件、环境变量和命令行参数并校验
+// 返回未被解析的剩余命令行参数，供子命令使用
+func Load(name string, args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("NAV_CONFIG"), "配置文件路径（.yaml、.yml 或 .toml），也可通过 NAV_CONFIG 指定")
+
+	flagValues := make(map[string]*string, len(options))
+	for _, opt := range options {
+		flagValues[opt.flag] = fs.String(opt.flag, "", fmt.Sprintf("%s（环境变量 %s）", opt.usage, opt.env))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := Default()
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, opt := range options {
+		if value, ok := os.LookupEnv(opt.env); ok && value != "" {
+			if err := opt.set(cfg, value); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s: %w", opt.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, opt := range options {
+			if opt.flag == f.Name && flagErr == nil {
+				if err := opt.set(cfg, *flagValues[opt.flag]); err != nil {
+					flagErr = fmt.Errorf("invalid -%s: %w", opt.flag, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate 校验配置，返回所有不合法的配置项
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Listen); err != nil {
+		invalid("listen: %q is not a valid address, expected host:port such as :8080", c.Listen)
+	} else if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		invalid("listen: port %q must be between 1 and 65535", port)
+	}
+
+	if strings.TrimSpace(c.DataDir) == "" {
+		invalid("dataDir: must not be empty")
+	}
+
+	if len(c.AllowedOrigins) == 0 {
+		invalid("allowedOrigins: at least one origin is required, use * to allow all")
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			invalid("allowedOrigins: %q must be a scheme and host such as https://nav.example.com", origin)
+		}
+	}
+
+	if c.PublicURL != "" {
+		u, err := url.Parse(c.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			invalid("publicURL: %q must be an absolute http(s) URL such as https://nav.example.com", c.PublicURL)
+		}
+	}
+
+	switch c.GinMode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		invalid("ginMode: %q must be one of debug, release or test", c.GinMode)
+	}
+
+	if c.Upload.MaxRequestSize <= 0 {
+		invalid("upload.maxRequestSize: must be greater than 0")
+	}
+	if c.Upload.MaxIconSize <= 0 {
+		invalid("upload.maxIconSize: must be greater than 0")
+	} else if c.Upload.MaxIconSize > c.Upload.MaxRequestSize {
+		invalid("upload.maxIconSize: %s exceeds upload.maxRequestSize %s", c.Upload.MaxIconSize, c.Upload.MaxRequestSize)
+	}
+
+	if c.Backup.Interval < 0 {
+		invalid("backup.interval: must not be negative")
+	}
+	if c.Backup.KeepDaily < 0 || c.Backup.KeepWeekly < 0 {
+		invalid("backup.keepDaily and backup.keepWeekly: must not be negative")
+	}
+	if c.Backup.Interval > 0 && c.Backup.KeepDaily == 0 && c.Backup.KeepWeekly == 0 {
+		invalid("backup: keepDaily and keepWeekly are both 0, every scheduled backup would be deleted immediately")
+	}
+
+	if c.HealthCheck.Interval < 0 {
+		invalid("healthCheck.interval: must not be negative")
+	}
+	if c.HealthCheck.Concurrency < 1 || c.HealthCheck.Concurrency > 64 {
+		invalid("healthCheck.concurrency: %d must be between 1 and 64", c.HealthCheck.Concurrency)
+	}
+	if c.HealthCheck.Timeout < Duration(time.Second) {
+		invalid("healthCheck.timeout: must be at least 1s")
+	}
+	if c.Icons.RefreshInterval < 0 {
+		invalid("icons.refreshInterval: must not be negative")
+	}
+	if c.Icons.Timeout < Duration(time.Second) {
+		invalid("icons.timeout: must be at least 1s")
+	}
+	if c.Trash.Retention < 0 {
+		invalid("trash.retention: must not be negative")
+	}
+
+	if c.Auth.Enabled {
+		if strings.TrimSpace(c.Auth.AdminUsername) == "" {
+			invalid("auth.adminUsername: must not be empty")
+		}
+		if c.Auth.AdminPassword != "" && len(c.Auth.AdminPassword) < 8 {
+			invalid("auth.adminPassword: must be at least 8 characters")
+		}
+		if c.Auth.SessionTTL < Duration(time.Minute) {
+			invalid("auth.sessionTTL: must be at least 1m")
+		}
+	}
+
+	if strings.TrimSpace(c.Seed.Theme) == "" {
+		invalid("seed.theme: must not be empty")
+	}
+	ids := make(map[string]bool)
+	defaults := 0
+	for i, e := range c.Seed.Engines {
+		if e.ID == "" || e.Name == "" {
+			invalid("seed.engines[%d]: id and name are required", i)
+		}
+		if ids[e.ID] {
+			invalid("seed.engines[%d]: duplicate id %q", i, e.ID)
+		}
+		ids[e.ID] = true
+		if u, err := url.Parse(e.URL); err != nil || u.Host == "" || (!strings.Contains(e.URL, "{}") && !strings.Contains(e.URL, "{query")) {
+			invalid("seed.engines[%d]: url %q must be an absolute URL containing the {query} placeholder", i, e.URL)
+		}
+		if e.IsDefault {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		invalid("seed.engines: only one engine can be the default")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// DBPath 数据库文件路径
+func (c *Config) DBPath() string {
+	return filepath.Join(c.DataDir, "nav-panel.db")
+}
+
+// BackupDir 备份目录
+func (c *Config) BackupDir() string {
+	return filepath.Join(c.DataDir, "backups")
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseInt(target *int, value string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", value)
+	}
+	*target = n
+	return nil
+}
+
+func parseBool(target *bool, value string) error {
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean, expected true or false", value)
+	}
+	*target = b
+	return nil
+}
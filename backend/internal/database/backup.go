@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Snapshot 使用 VACUUM INTO 生成数据库的一致性在线快照
+// 先写入临时文件再重命名，避免留下不完整的备份文件
+func Snapshot(db *sql.DB, destPath string) error {
+	tmpPath := destPath + ".tmp"
+	os.Remove(tmpPath)
+
+	if _, err := db.Exec("VACUUM INTO ?", tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// Verify 检查备份文件是否为完整可用的数据库
+func Verify(path string) error {
+	src, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var result string
+	if err := src.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var count int
+	if err := src.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'app_settings'").Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("not a nav-panel database")
+	}
+
+	return nil
+}
+
+// Restore 使用 SQLite 在线备份 API 将备份文件整体写回正在使用的数据库
+// 备份 API 在单个步骤内完成复制，其他连接只会看到恢复前或恢复后的完整数据
+func Restore(db *sql.DB, srcPath string) error {
+	ctx := context.Background()
+
+	src, err := sql.Open("sqlite3", "file:"+srcPath+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	destConn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(destRaw interface{}) error {
+		return srcConn.Raw(func(srcRaw interface{}) error {
+			dest, ok := destRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", destRaw)
+			}
+			source, ok := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", srcRaw)
+			}
+
+			backup, err := dest.Backup("main", source, "main")
+			if err != nil {
+				return err
+			}
+
+			if _, err := backup.Step(-1); err != nil {
+				backup.Close()
+				return err
+			}
+
+			return backup.Finish()
+		})
+	})
+}
@@ -0,0 +1,278 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration 一次有序的数据库结构变更
+// Up 和 Down 都在独立事务中执行，Down 为 nil 表示该迁移不可回滚
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+	Down    func(tx *sql.Tx) error
+}
+
+// MigrationStatus 迁移的执行状态
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrate 按版本顺序执行所有未执行的迁移
+func Migrate(db *sql.DB) error {
+	// 启用外键约束
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return err
+	}
+
+	if err := checkFTS5(db); err != nil {
+		return err
+	}
+
+	if err := ensureMigrationsTable(db); err != nil {
+		return err
+	}
+
+	pending, err := Pending(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if err := runMigration(db, m, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rollback 依次回滚版本号大于 target 的已执行迁移
+func Rollback(db *sql.DB, target int) error {
+	if err := checkFTS5(db); err != nil {
+		return err
+	}
+
+	if err := ensureMigrationsTable(db); err != nil {
+		return err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+
+	list := sortedMigrations()
+	for i := len(list) - 1; i >= 0; i-- {
+		m := list[i]
+		if m.Version <= target {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return fmt.Errorf("migration %d (%s) is irreversible", m.Version, m.Name)
+		}
+		if err := runMigration(db, m, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Pending 返回尚未执行的迁移
+func Pending(db *sql.DB) ([]Migration, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range sortedMigrations() {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending, nil
+}
+
+// Status 返回所有迁移的执行状态
+func Status(db *sql.DB) ([]MigrationStatus, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range sortedMigrations() {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if appliedAt, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// checkFTS5 检查 SQLite 是否启用了 FTS5，网站全文索引的迁移和搜索都依赖它
+// 在执行任何迁移之前检查，避免数据库停留在部分迁移的版本
+func checkFTS5(db *sql.DB) error {
+	var enabled bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		return errors.New("SQLite FTS5 is not available, build and run with -tags sqlite_fts5")
+	}
+	return nil
+}
+
+func ensureMigrationsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`)
+	return err
+}
+
+func appliedVersions(db *sql.DB) (map[int]time.Time, error) {
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func runMigration(db *sql.DB, m Migration, up bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if up {
+		if err := m.Up(tx); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now()); err != nil {
+			return err
+		}
+	} else {
+		if err := m.Down(tx); err != nil {
+			return fmt.Errorf("rollback of migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func sortedMigrations() []Migration {
+	list := make([]Migration, len(migrations))
+	copy(list, migrations)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list
+}
+
+// execQuerier 抽象 *sql.DB 与 *sql.Tx，迁移辅助函数可在事务中使用
+type execQuerier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// addColumnIfNotExists 检查列是否存在，如果不存在则添加
+func addColumnIfNotExists(db execQuerier, tableName, columnName, columnType string) error {
+	// 检查列是否存在
+	exists, err := columnExists(db, tableName, columnName)
+	if err != nil {
+		return err
+	}
+
+	// 如果列不存在，则添加
+	if !exists {
+		_, err := db.Exec("ALTER TABLE " + tableName + " ADD COLUMN " + columnName + " " + columnType)
+		return err
+	}
+
+	return nil
+}
+
+// dropColumnIfExists 检查列是否存在，如果存在则删除
+func dropColumnIfExists(db execQuerier, tableName, columnName string) error {
+	exists, err := columnExists(db, tableName, columnName)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		_, err := db.Exec("ALTER TABLE " + tableName + " DROP COLUMN " + columnName)
+		return err
+	}
+
+	return nil
+}
+
+// columnExists 检查指定表中的列是否存在
+func columnExists(db execQuerier, tableName, columnName string) (bool, error) {
+	query := "PRAGMA table_info(" + tableName + ")"
+	rows, err := db.Query(query)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid int
+		var name, dataType string
+		var notNull, dfltValue, pk interface{}
+
+		err := rows.Scan(&cid, &name, &dataType, &notNull, &dfltValue, &pk)
+		if err != nil {
+			return false, err
+		}
+
+		if name == columnName {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
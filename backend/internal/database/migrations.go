@@ -0,0 +1,1146 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"nav-panel-backend/internal/config"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/svg"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// migrations 所有数据库迁移，版本号一经发布不可修改，新的变更追加新版本
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up:      migrateInitialSchema,
+	},
+	{
+		Version: 2,
+		Name:    "merge_search_engine_icon_columns",
+		Up:      migrateMergeSearchEngineIconColumns,
+	},
+	{
+		Version: 3,
+		Name:    "create_users_and_sessions",
+		Up:      migrateCreateUsersAndSessions,
+		Down:    rollbackCreateUsersAndSessions,
+	},
+	{
+		Version: 4,
+		Name:    "scope_data_by_user",
+		Up:      migrateScopeDataByUser,
+	},
+	{
+		Version: 5,
+		Name:    "create_api_tokens",
+		Up:      migrateCreateAPITokens,
+		Down:    rollbackCreateAPITokens,
+	},
+	{
+		Version: 6,
+		Name:    "create_website_health",
+		Up:      migrateCreateWebsiteHealth,
+		Down:    rollbackCreateWebsiteHealth,
+	},
+	{
+		Version: 7,
+		Name:    "create_icons",
+		Up:      migrateCreateIcons,
+	},
+	{
+		Version: 8,
+		Name:    "move_icon_data_urls_to_store",
+		Up:      migrateMoveIconDataURLsToStore,
+	},
+	{
+		Version: 9,
+		Name:    "create_icon_variants",
+		Up:      migrateCreateIconVariants,
+		Down:    rollbackCreateIconVariants,
+	},
+	{
+		Version: 10,
+		Name:    "sanitize_svg_icons",
+		Up:      migrateSanitizeSVGIcons,
+	},
+	{
+		Version: 11,
+		Name:    "create_websites_fts",
+		Up:      migrateCreateWebsitesFTS,
+		Down:    rollbackCreateWebsitesFTS,
+	},
+	{
+		Version: 12,
+		Name:    "replace_category_with_tags",
+		Up:      migrateReplaceCategoryWithTags,
+	},
+	{
+		Version: 13,
+		Name:    "add_group_parent",
+		Up:      migrateAddGroupParent,
+		Down:    rollbackAddGroupParent,
+	},
+	{
+		Version: 14,
+		Name:    "create_website_visits",
+		Up:      migrateCreateWebsiteVisits,
+		Down:    rollbackCreateWebsiteVisits,
+	},
+	{
+		Version: 15,
+		Name:    "create_search_history",
+		Up:      migrateCreateSearchHistory,
+		Down:    rollbackCreateSearchHistory,
+	},
+	{
+		Version: 16,
+		Name:    "add_search_engine_keyword",
+		Up:      migrateAddSearchEngineKeyword,
+		Down:    rollbackAddSearchEngineKeyword,
+	},
+	{
+		Version: 17,
+		Name:    "add_search_engine_method_and_body",
+		Up:      migrateAddSearchEngineMethodAndBody,
+		Down:    rollbackAddSearchEngineMethodAndBody,
+	},
+	{
+		Version: 18,
+		Name:    "add_search_engine_order_and_categories",
+		Up:      migrateAddSearchEngineOrderAndCategories,
+		Down:    rollbackAddSearchEngineOrderAndCategories,
+	},
+	{
+		Version: 19,
+		Name:    "add_soft_delete",
+		Up:      migrateAddSoftDelete,
+		Down:    rollbackAddSoftDelete,
+	},
+}
+
+// migrateInitialSchema 基线结构
+// 使用 IF NOT EXISTS 和列检查，使引入迁移表之前创建的数据库也能平滑登记为该版本
+func migrateInitialSchema(tx *sql.Tx) error {
+	// 创建分组表
+	groupsSQL := `
+	CREATE TABLE IF NOT EXISTS groups (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		color TEXT,
+		icon TEXT,
+		sort_order INTEGER DEFAULT 0,
+		is_collapsed BOOLEAN DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if _, err := tx.Exec(groupsSQL); err != nil {
+		return err
+	}
+
+	// 创建网站表
+	websitesSQL := `
+	CREATE TABLE IF NOT EXISTS websites (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		icon TEXT,
+		description TEXT,
+		category TEXT,
+		group_id TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE SET NULL
+	);`
+
+	if _, err := tx.Exec(websitesSQL); err != nil {
+		return err
+	}
+
+	// 创建搜索引擎表
+	searchEnginesSQL := `
+	CREATE TABLE IF NOT EXISTS search_engines (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		icon TEXT,
+		placeholder TEXT,
+		is_default BOOLEAN DEFAULT FALSE
+	);`
+
+	if _, err := tx.Exec(searchEnginesSQL); err != nil {
+		return err
+	}
+
+	// 检查并添加新字段（向后兼容）
+	if err := addColumnIfNotExists(tx, "search_engines", "icon", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(tx, "websites", "sort_order", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(tx, "websites", "group_id", "TEXT"); err != nil {
+		return err
+	}
+
+	// 创建应用设置表
+	settingsSQL := `
+	CREATE TABLE IF NOT EXISTS app_settings (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		theme TEXT DEFAULT 'light',
+		layout_config TEXT,
+		background_config TEXT,
+		card_style_config TEXT,
+		search_config TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if _, err := tx.Exec(settingsSQL); err != nil {
+		return err
+	}
+
+	return seedDefaults(tx)
+}
+
+// migrateMergeSearchEngineIconColumns 将旧版的 icon_data + icon_type 合并为 icon 字段中的 DataURL，并删除旧字段
+func migrateMergeSearchEngineIconColumns(tx *sql.Tx) error {
+	hasData, err := columnExists(tx, "search_engines", "icon_data")
+	if err != nil {
+		return err
+	}
+	if !hasData {
+		return nil
+	}
+
+	hasType, err := columnExists(tx, "search_engines", "icon_type")
+	if err != nil {
+		return err
+	}
+
+	typeColumn := "NULL"
+	if hasType {
+		typeColumn = "icon_type"
+	}
+
+	rows, err := tx.Query("SELECT id, icon_data, " + typeColumn + " FROM search_engines WHERE icon_data IS NOT NULL AND (icon IS NULL OR icon = '')")
+	if err != nil {
+		return err
+	}
+
+	type legacyIcon struct {
+		id      string
+		dataURL string
+	}
+	var icons []legacyIcon
+	for rows.Next() {
+		var id string
+		var data []byte
+		var iconType sql.NullString
+		if err := rows.Scan(&id, &data, &iconType); err != nil {
+			rows.Close()
+			return err
+		}
+		if len(data) == 0 {
+			continue
+		}
+		icons = append(icons, legacyIcon{id: id, dataURL: legacyIconDataURL(data, iconType.String)})
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, icon := range icons {
+		if _, err := tx.Exec("UPDATE search_engines SET icon = ? WHERE id = ?", icon.dataURL, icon.id); err != nil {
+			return err
+		}
+	}
+
+	if err := dropColumnIfExists(tx, "search_engines", "icon_data"); err != nil {
+		return err
+	}
+	return dropColumnIfExists(tx, "search_engines", "icon_type")
+}
+
+// migrateCreateUsersAndSessions 管理员账号和登录会话，会话只保存令牌的 SHA-256 摘要
+func migrateCreateUsersAndSessions(tx *sql.Tx) error {
+	usersSQL := `
+	CREATE TABLE users (
+		id TEXT PRIMARY KEY,
+		username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		password_hash TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if _, err := tx.Exec(usersSQL); err != nil {
+		return err
+	}
+
+	sessionsSQL := `
+	CREATE TABLE sessions (
+		token_hash TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	);`
+
+	if _, err := tx.Exec(sessionsSQL); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec("CREATE INDEX idx_sessions_user_id ON sessions (user_id)")
+	return err
+}
+
+func rollbackCreateUsersAndSessions(tx *sql.Tx) error {
+	if _, err := tx.Exec("DROP TABLE IF EXISTS sessions"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("DROP TABLE IF EXISTS users")
+	return err
+}
+
+// migrateScopeDataByUser 为账号增加角色，网站、分组、搜索引擎和设置按账号隔离
+// 已有数据归属最早创建的账号；还没有账号时 user_id 保持为 NULL，由第一个创建的账号认领
+func migrateScopeDataByUser(tx *sql.Tx) error {
+	if err := addColumnIfNotExists(tx, "users", "role", "TEXT NOT NULL DEFAULT 'user'"); err != nil {
+		return err
+	}
+	// 迁移前只有一个内置管理员
+	if _, err := tx.Exec("UPDATE users SET role = 'admin'"); err != nil {
+		return err
+	}
+
+	var owner sql.NullString
+	err := tx.QueryRow("SELECT id FROM users ORDER BY created_at ASC LIMIT 1").Scan(&owner)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	for _, table := range []string{"groups", "websites"} {
+		if err := addColumnIfNotExists(tx, table, "user_id", "TEXT"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE "+table+" SET user_id = ?", owner); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_" + table + "_user_id ON " + table + " (user_id)"); err != nil {
+			return err
+		}
+	}
+
+	// 搜索引擎ID只需在同一账号内唯一，以便每个账号都能拥有 google 等默认引擎
+	searchEnginesSQL := `
+	CREATE TABLE search_engines_new (
+		user_id TEXT,
+		id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		icon TEXT,
+		placeholder TEXT,
+		is_default BOOLEAN DEFAULT FALSE,
+		UNIQUE (user_id, id)
+	);`
+	if _, err := tx.Exec(searchEnginesSQL); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO search_engines_new (user_id, id, name, url, icon, placeholder, is_default)
+		SELECT ?, id, name, url, icon, placeholder, is_default FROM search_engines`, owner); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DROP TABLE search_engines"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("ALTER TABLE search_engines_new RENAME TO search_engines"); err != nil {
+		return err
+	}
+
+	// 设置由单行改为每个账号一行
+	settingsSQL := `
+	CREATE TABLE app_settings_new (
+		user_id TEXT UNIQUE,
+		theme TEXT DEFAULT 'light',
+		layout_config TEXT,
+		background_config TEXT,
+		card_style_config TEXT,
+		search_config TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+	if _, err := tx.Exec(settingsSQL); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO app_settings_new (user_id, theme, layout_config, background_config, card_style_config, search_config, updated_at)
+		SELECT ?, theme, layout_config, background_config, card_style_config, search_config, updated_at FROM app_settings WHERE id = 1`, owner); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DROP TABLE app_settings"); err != nil {
+		return err
+	}
+	_, err = tx.Exec("ALTER TABLE app_settings_new RENAME TO app_settings")
+	return err
+}
+
+// migrateCreateAPITokens 个人 API 令牌，只保存令牌的 SHA-256 摘要，scopes 为 JSON 数组
+func migrateCreateAPITokens(tx *sql.Tx) error {
+	tokensSQL := `
+	CREATE TABLE api_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT NOT NULL,
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+	);`
+
+	if _, err := tx.Exec(tokensSQL); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec("CREATE INDEX idx_api_tokens_user_id ON api_tokens (user_id)")
+	return err
+}
+
+func rollbackCreateAPITokens(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS api_tokens")
+	return err
+}
+
+// migrateCreateWebsiteHealth 网站链接最近一次健康检查的结果，每个网站一行
+func migrateCreateWebsiteHealth(tx *sql.Tx) error {
+	healthSQL := `
+	CREATE TABLE website_health (
+		website_id TEXT PRIMARY KEY,
+		status_code INTEGER,
+		latency_ms INTEGER,
+		final_url TEXT,
+		error TEXT,
+		checked_at DATETIME NOT NULL,
+		FOREIGN KEY (website_id) REFERENCES websites (id) ON DELETE CASCADE
+	);`
+
+	_, err := tx.Exec(healthSQL)
+	return err
+}
+
+func rollbackCreateWebsiteHealth(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS website_health")
+	return err
+}
+
+// migrateCreateIcons 本地缓存的图标，按内容的 SHA-256 去重
+// websites.icon_source 记录图标来源：auto 为自动发现，fallback 为发现失败时生成的占位图标，NULL 为用户指定
+func migrateCreateIcons(tx *sql.Tx) error {
+	iconsSQL := `
+	CREATE TABLE icons (
+		hash TEXT PRIMARY KEY,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		data BLOB NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if _, err := tx.Exec(iconsSQL); err != nil {
+		return err
+	}
+
+	if err := addColumnIfNotExists(tx, "websites", "icon_source", "TEXT"); err != nil {
+		return err
+	}
+	return addColumnIfNotExists(tx, "websites", "icon_checked_at", "DATETIME")
+}
+
+// migrateMoveIconDataURLsToStore 把网站、分组和搜索引擎 icon 字段中的 DataURL 移入图标库，字段改为 /api/icons/<hash> 引用
+// 无法解析的 DataURL 保持不变
+func migrateMoveIconDataURLsToStore(tx *sql.Tx) error {
+	for _, table := range []string{"websites", "groups", "search_engines"} {
+		rows, err := tx.Query("SELECT rowid, icon FROM " + table + " WHERE icon LIKE 'data:%'")
+		if err != nil {
+			return err
+		}
+
+		refs := make(map[int64]string)
+		var icons []*model.Icon
+		for rows.Next() {
+			var rowID int64
+			var dataURL string
+			if err := rows.Scan(&rowID, &dataURL); err != nil {
+				rows.Close()
+				return err
+			}
+
+			contentType, data, err := model.ParseDataURL(dataURL)
+			if err != nil || len(data) == 0 {
+				continue
+			}
+			if !strings.HasPrefix(contentType, "image/") {
+				contentType = http.DetectContentType(data)
+			}
+
+			icon := model.NewIcon(data, contentType)
+			icons = append(icons, icon)
+			refs[rowID] = icon.URL()
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+
+		for _, icon := range icons {
+			if _, err := tx.Exec("INSERT OR IGNORE INTO icons (hash, content_type, size, data, created_at) VALUES (?, ?, ?, ?, ?)",
+				icon.Hash, icon.ContentType, icon.Size, icon.Data, icon.CreatedAt); err != nil {
+				return err
+			}
+		}
+		for rowID, ref := range refs {
+			if _, err := tx.Exec("UPDATE "+table+" SET icon = ? WHERE rowid = ?", ref, rowID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateCreateIconVariants 上传图标标准化时生成的缩略图
+func migrateCreateIconVariants(tx *sql.Tx) error {
+	variantsSQL := `
+	CREATE TABLE icon_variants (
+		hash TEXT NOT NULL REFERENCES icons(hash) ON DELETE CASCADE,
+		size INTEGER NOT NULL,
+		content_type TEXT NOT NULL,
+		data BLOB NOT NULL,
+		PRIMARY KEY (hash, size)
+	);`
+
+	_, err := tx.Exec(variantsSQL)
+	return err
+}
+
+func rollbackCreateIconVariants(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE IF EXISTS icon_variants")
+	return err
+}
+
+// migrateSanitizeSVGIcons 清理图标库中已有的 SVG，内容变化后哈希随之变化，同时更新所有引用
+// 无法解析的 SVG 直接删除，引用它的网站会在下一轮定时刷新时重新发现图标
+func migrateSanitizeSVGIcons(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT hash, data, created_at FROM icons WHERE content_type = 'image/svg+xml'")
+	if err != nil {
+		return err
+	}
+
+	type replacement struct {
+		oldHash string
+		icon    *model.Icon // 为 nil 时删除图标及其引用
+	}
+	var replacements []replacement
+	for rows.Next() {
+		var hash string
+		var data []byte
+		var createdAt sql.NullTime
+		if err := rows.Scan(&hash, &data, &createdAt); err != nil {
+			rows.Close()
+			return err
+		}
+
+		cleaned, err := svg.Sanitize(data)
+		if err != nil {
+			replacements = append(replacements, replacement{oldHash: hash})
+			continue
+		}
+		if bytes.Equal(cleaned, data) {
+			continue
+		}
+
+		icon := model.NewIcon(cleaned, "image/svg+xml")
+		if createdAt.Valid {
+			icon.CreatedAt = createdAt.Time
+		}
+		replacements = append(replacements, replacement{oldHash: hash, icon: icon})
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, r := range replacements {
+		var ref interface{}
+		if r.icon != nil {
+			if _, err := tx.Exec("INSERT OR IGNORE INTO icons (hash, content_type, size, data, created_at) VALUES (?, ?, ?, ?, ?)",
+				r.icon.Hash, r.icon.ContentType, r.icon.Size, r.icon.Data, r.icon.CreatedAt); err != nil {
+				return err
+			}
+			ref = r.icon.URL()
+		}
+
+		oldRef := model.IconURLPrefix + r.oldHash
+		for _, table := range []string{"websites", "groups", "search_engines"} {
+			if _, err := tx.Exec("UPDATE "+table+" SET icon = ? WHERE icon = ?", ref, oldRef); err != nil {
+				return err
+			}
+		}
+		if r.icon == nil {
+			if _, err := tx.Exec("UPDATE websites SET icon_checked_at = NULL WHERE icon IS NULL AND icon_checked_at IS NOT NULL"); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM icon_variants WHERE hash = ?", r.oldHash); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM icons WHERE hash = ?", r.oldHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// legacyIconDataURL 旧版 icon_data 可能是原始二进制、base64 文本或完整的 DataURL
+func legacyIconDataURL(data []byte, iconType string) string {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "data:") {
+		return text
+	}
+
+	if iconType == "" {
+		iconType = "image/png"
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(text); err == nil {
+		return fmt.Sprintf("data:%s;base64,%s", iconType, text)
+	}
+	return fmt.Sprintf("data:%s;base64,%s", iconType, base64.StdEncoding.EncodeToString(data))
+}
+
+// seed 新建数据库时写入的默认数据，启动时由配置覆盖
+var seed = config.Default().Seed
+
+// SetSeed 设置新建数据库时写入的默认数据，需在 Migrate 之前调用
+func SetSeed(s config.Seed) {
+	seed = s
+}
+
+// SeedUser 为新账号写入默认设置和默认搜索引擎（已存在时忽略）
+func SeedUser(tx *sql.Tx, userID string) error {
+	searchConfig, err := seedSearchConfig()
+	if err != nil {
+		return err
+	}
+
+	settingsSQL := `
+	INSERT OR IGNORE INTO app_settings (user_id, theme, layout_config, background_config, card_style_config, search_config)
+	VALUES (?, ?, ?, ?, ?, ?);`
+
+	if _, err := tx.Exec(settingsSQL, userID, seed.Theme, defaultLayoutConfig, defaultBackgroundConfig, defaultCardStyleConfig, searchConfig); err != nil {
+		return err
+	}
+
+	for i, engine := range seed.Engines {
+		engineSQL := `
+		INSERT OR IGNORE INTO search_engines (user_id, id, name, url, placeholder, is_default, keyword, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?, NULLIF(LOWER(?), ''), ?);`
+
+		if _, err := tx.Exec(engineSQL, userID, engine.ID, engine.Name, engine.URL, engine.Placeholder, engine.IsDefault, engine.Keyword, i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const (
+	defaultLayoutConfig     = `{"columns":6,"cardSize":"medium","showLabels":true,"gap":20}`
+	defaultBackgroundConfig = `{"type":"gradient","value":"linear-gradient(135deg, #667eea 0%, #764ba2 100%)"}`
+	defaultCardStyleConfig  = `{"borderRadius":12,"opacity":0.9,"shadow":true}`
+)
+
+// seedSearchConfig 默认搜索配置，默认引擎取自 seed
+func seedSearchConfig() (string, error) {
+	defaultEngineID := ""
+	for _, engine := range seed.Engines {
+		if engine.IsDefault {
+			defaultEngineID = engine.ID
+		}
+	}
+
+	searchConfig, err := json.Marshal(map[string]interface{}{
+		"enabled":         true,
+		"defaultEngineId": defaultEngineID,
+		"openInNewTab":    true,
+	})
+	return string(searchConfig), err
+}
+
+// seedDefaults 写入默认设置和默认搜索引擎（已存在时忽略）
+// 仅供基线迁移使用，此时设置表还是单行结构
+func seedDefaults(tx *sql.Tx) error {
+	searchConfig, err := seedSearchConfig()
+	if err != nil {
+		return err
+	}
+
+	// 插入默认设置（如果不存在）
+	defaultSettingsSQL := `
+	INSERT OR IGNORE INTO app_settings (id, theme, layout_config, background_config, card_style_config, search_config)
+	VALUES (1, ?, ?, ?, ?, ?);`
+
+	if _, err := tx.Exec(defaultSettingsSQL, seed.Theme, defaultLayoutConfig, defaultBackgroundConfig, defaultCardStyleConfig, searchConfig); err != nil {
+		return err
+	}
+
+	// 插入默认搜索引擎（如果不存在）
+	for _, engine := range seed.Engines {
+		engineSQL := `
+		INSERT OR IGNORE INTO search_engines (id, name, url, placeholder, is_default)
+		VALUES (?, ?, ?, ?, ?);`
+
+		if _, err := tx.Exec(engineSQL, engine.ID, engine.Name, engine.URL, engine.Placeholder, engine.IsDefault); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateCreateWebsitesFTS 创建网站全文索引，由触发器与 websites 表保持同步
+// 索引自行保存一份文本而不引用 websites 的 rowid，VACUUM 改变 rowid 后索引仍然有效
+func migrateCreateWebsitesFTS(tx *sql.Tx) error {
+	ftsSQL := `
+	CREATE VIRTUAL TABLE websites_fts USING fts5(
+		name, url, description, category,
+		website_id UNINDEXED,
+		user_id UNINDEXED,
+		tokenize = 'unicode61 remove_diacritics 2',
+		prefix = '2 3'
+	);`
+	if _, err := tx.Exec(ftsSQL); err != nil {
+		return err
+	}
+
+	triggersSQL := `
+	INSERT INTO websites_fts (name, url, description, category, website_id, user_id)
+	SELECT name, url, COALESCE(description, ''), COALESCE(category, ''), id, user_id FROM websites;
+
+	CREATE TRIGGER websites_fts_insert AFTER INSERT ON websites BEGIN
+		INSERT INTO websites_fts (name, url, description, category, website_id, user_id)
+		VALUES (new.name, new.url, COALESCE(new.description, ''), COALESCE(new.category, ''), new.id, new.user_id);
+	END;
+
+	CREATE TRIGGER websites_fts_update AFTER UPDATE OF id, name, url, description, category, user_id ON websites BEGIN
+		DELETE FROM websites_fts WHERE website_id = old.id;
+		INSERT INTO websites_fts (name, url, description, category, website_id, user_id)
+		VALUES (new.name, new.url, COALESCE(new.description, ''), COALESCE(new.category, ''), new.id, new.user_id);
+	END;
+
+	CREATE TRIGGER websites_fts_delete AFTER DELETE ON websites BEGIN
+		DELETE FROM websites_fts WHERE website_id = old.id;
+	END;`
+
+	_, err := tx.Exec(triggersSQL)
+	return err
+}
+
+func rollbackCreateWebsitesFTS(tx *sql.Tx) error {
+	rollbackSQL := `
+	DROP TRIGGER IF EXISTS websites_fts_insert;
+	DROP TRIGGER IF EXISTS websites_fts_update;
+	DROP TRIGGER IF EXISTS websites_fts_delete;
+	DROP TABLE IF EXISTS websites_fts;`
+
+	_, err := tx.Exec(rollbackSQL)
+	return err
+}
+
+// migrateReplaceCategoryWithTags 用多对多的标签取代网站的自由文本分类
+// 每个账号中忽略大小写后相同的分类合并为一个标签，全文索引改为索引标签名称，最后删除 category 列
+func migrateReplaceCategoryWithTags(tx *sql.Tx) error {
+	tagsSQL := `
+	CREATE TABLE tags (
+		id TEXT PRIMARY KEY,
+		user_id TEXT,
+		name TEXT NOT NULL,
+		color TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE UNIQUE INDEX idx_tags_user_name ON tags (user_id, name COLLATE NOCASE);
+
+	CREATE TABLE website_tags (
+		website_id TEXT NOT NULL REFERENCES websites(id) ON DELETE CASCADE,
+		tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+		PRIMARY KEY (website_id, tag_id)
+	);
+	CREATE INDEX idx_website_tags_tag_id ON website_tags (tag_id);`
+	if _, err := tx.Exec(tagsSQL); err != nil {
+		return err
+	}
+
+	// 标签归属引用它的网站所属的账号，还没有账号时 user_id 保持为 NULL，由第一个创建的账号认领
+	rows, err := tx.Query("SELECT id, user_id, category FROM websites WHERE TRIM(COALESCE(category, '')) != '' ORDER BY created_at")
+	if err != nil {
+		return err
+	}
+	type assignment struct {
+		websiteID, name string
+		userID          sql.NullString
+	}
+	var assignments []assignment
+	for rows.Next() {
+		var a assignment
+		if err := rows.Scan(&a.websiteID, &a.userID, &a.name); err != nil {
+			rows.Close()
+			return err
+		}
+		a.name = strings.Join(strings.Fields(a.name), " ")
+		assignments = append(assignments, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// 最早出现的写法作为标签名称
+	tagIDs := make(map[string]string)
+	for _, a := range assignments {
+		key := a.userID.String + "\x00" + strings.ToLower(a.name)
+		tagID, ok := tagIDs[key]
+		if !ok {
+			tagID = uuid.New().String()
+			if _, err := tx.Exec("INSERT INTO tags (id, user_id, name) VALUES (?, ?, ?)", tagID, a.userID, a.name); err != nil {
+				return err
+			}
+			tagIDs[key] = tagID
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO website_tags (website_id, tag_id) VALUES (?, ?)", a.websiteID, tagID); err != nil {
+			return err
+		}
+	}
+
+	// 触发器引用了 category 列，需要先删除才能删除该列
+	if err := rollbackCreateWebsitesFTS(tx); err != nil {
+		return err
+	}
+	if err := dropColumnIfExists(tx, "websites", "category"); err != nil {
+		return err
+	}
+
+	ftsSQL := `
+	CREATE VIRTUAL TABLE websites_fts USING fts5(
+		name, url, description, tags,
+		website_id UNINDEXED,
+		user_id UNINDEXED,
+		tokenize = 'unicode61 remove_diacritics 2',
+		prefix = '2 3'
+	);
+
+	INSERT INTO websites_fts (name, url, description, tags, website_id, user_id)
+	SELECT name, url, COALESCE(description, ''),
+		COALESCE((SELECT GROUP_CONCAT(t.name, ' ') FROM website_tags wt JOIN tags t ON t.id = wt.tag_id WHERE wt.website_id = websites.id), ''),
+		id, user_id
+	FROM websites;
+
+	CREATE TRIGGER websites_fts_insert AFTER INSERT ON websites BEGIN
+		` + reindexWebsiteFTS("new.id") + `
+	END;
+
+	CREATE TRIGGER websites_fts_update AFTER UPDATE OF id, name, url, description, user_id ON websites BEGIN
+		DELETE FROM websites_fts WHERE website_id = old.id;
+		` + reindexWebsiteFTS("new.id") + `
+	END;
+
+	CREATE TRIGGER websites_fts_delete AFTER DELETE ON websites BEGIN
+		DELETE FROM website_tags WHERE website_id = old.id;
+		DELETE FROM websites_fts WHERE website_id = old.id;
+	END;
+
+	CREATE TRIGGER website_tags_fts_insert AFTER INSERT ON website_tags BEGIN
+		` + reindexWebsiteFTS("new.website_id") + `
+	END;
+
+	CREATE TRIGGER website_tags_fts_delete AFTER DELETE ON website_tags BEGIN
+		` + reindexWebsiteFTS("old.website_id") + `
+	END;
+
+	CREATE TRIGGER tags_fts_update AFTER UPDATE OF name ON tags BEGIN
+		DELETE FROM websites_fts WHERE website_id IN (SELECT website_id FROM website_tags WHERE tag_id = new.id);
+		INSERT INTO websites_fts (name, url, description, tags, website_id, user_id)
+		SELECT w.name, w.url, COALESCE(w.description, ''),
+			COALESCE((SELECT GROUP_CONCAT(t.name, ' ') FROM website_tags wt JOIN tags t ON t.id = wt.tag_id WHERE wt.website_id = w.id), ''),
+			w.id, w.user_id
+		FROM websites w WHERE w.id IN (SELECT website_id FROM website_tags WHERE tag_id = new.id);
+	END;
+
+	CREATE TRIGGER tags_delete AFTER DELETE ON tags BEGIN
+		DELETE FROM website_tags WHERE tag_id = old.id;
+	END;`
+
+	_, err = tx.Exec(ftsSQL)
+	return err
+}
+
+// reindexWebsiteFTS 重建单个网站全文索引行的 SQL，标签名称以空格连接后索引
+func reindexWebsiteFTS(websiteID string) string {
+	return `DELETE FROM websites_fts WHERE website_id = ` + websiteID + `;
+		INSERT INTO websites_fts (name, url, description, tags, website_id, user_id)
+		SELECT w.name, w.url, COALESCE(w.description, ''),
+			COALESCE((SELECT GROUP_CONCAT(t.name, ' ') FROM website_tags wt JOIN tags t ON t.id = wt.tag_id WHERE wt.website_id = w.id), ''),
+			w.id, w.user_id
+		FROM websites w WHERE w.id = ` + websiteID + `;`
+}
+
+// migrateAddGroupParent 分组增加 parent_id 以支持子分组，已有分组都是顶层分组
+// sort_order 改为在同一父分组内排序，已有的全局排序在顶层内依然有效
+func migrateAddGroupParent(tx *sql.Tx) error {
+	if err := addColumnIfNotExists(tx, "groups", "parent_id", "TEXT"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_groups_parent_id ON groups (user_id, parent_id, sort_order)")
+	return err
+}
+
+// rollbackAddGroupParent 删除 parent_id，子分组全部变为顶层分组
+func rollbackAddGroupParent(tx *sql.Tx) error {
+	if _, err := tx.Exec("DROP INDEX IF EXISTS idx_groups_parent_id"); err != nil {
+		return err
+	}
+	return dropColumnIfExists(tx, "groups", "parent_id")
+}
+
+// migrateCreateWebsiteVisits 网站访问统计，按网站、日期、访问者和来源页面每天聚合为一行
+// 未登录访问时 visitor_id 为空字符串，没有来源页面时 referrer 为空字符串，以便参与主键去重
+// last_visited_at 保存 Unix 时间戳（秒），聚合查询时可以直接取 MAX
+func migrateCreateWebsiteVisits(tx *sql.Tx) error {
+	visitsSQL := `
+	CREATE TABLE website_visits (
+		website_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		visitor_id TEXT NOT NULL DEFAULT '',
+		referrer TEXT NOT NULL DEFAULT '',
+		count INTEGER NOT NULL DEFAULT 0,
+		last_visited_at INTEGER NOT NULL,
+		PRIMARY KEY (website_id, day, visitor_id, referrer)
+	);
+
+	CREATE INDEX idx_website_visits_user_day ON website_visits (user_id, day);
+
+	CREATE TRIGGER website_visits_delete AFTER DELETE ON websites BEGIN
+		DELETE FROM website_visits WHERE website_id = old.id;
+	END;`
+
+	_, err := tx.Exec(visitsSQL)
+	return err
+}
+
+func rollbackCreateWebsiteVisits(tx *sql.Tx) error {
+	if _, err := tx.Exec("DROP TRIGGER IF EXISTS website_visits_delete"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("DROP TABLE IF EXISTS website_visits")
+	return err
+}
+
+// migrateCreateSearchHistory 搜索历史及每个账号的搜索历史隐私设置
+// searched_at 保存 Unix 时间戳（秒），便于按时间衰减计算建议的排序
+// normalized 为去掉多余空白并转为小写的查询，相同查询的不同写法合并统计
+func migrateCreateSearchHistory(tx *sql.Tx) error {
+	historySQL := `
+	CREATE TABLE search_history (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		query TEXT NOT NULL,
+		normalized TEXT NOT NULL,
+		engine_id TEXT,
+		searched_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX idx_search_history_user ON search_history (user_id, searched_at);
+
+	CREATE TABLE search_history_settings (
+		user_id TEXT PRIMARY KEY,
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		retention_days INTEGER NOT NULL DEFAULT 0,
+		exclude_patterns TEXT NOT NULL DEFAULT '[]',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	_, err := tx.Exec(historySQL)
+	return err
+}
+
+func rollbackCreateSearchHistory(tx *sql.Tx) error {
+	if _, err := tx.Exec("DROP TABLE IF EXISTS search_history_settings"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("DROP TABLE IF EXISTS search_history")
+	return err
+}
+
+// migrateAddSearchEngineKeyword 搜索引擎快捷关键词，同一账号内唯一（统一保存为小写）
+// 已有的默认搜索引擎按 seed 配置补上关键词，关键词已被占用时跳过
+func migrateAddSearchEngineKeyword(tx *sql.Tx) error {
+	if err := addColumnIfNotExists(tx, "search_engines", "keyword", "TEXT"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_search_engines_keyword ON search_engines (user_id, keyword)"); err != nil {
+		return err
+	}
+
+	for _, engine := range seed.Engines {
+		keyword := strings.ToLower(strings.TrimSpace(engine.Keyword))
+		if keyword == "" {
+			continue
+		}
+		backfillSQL := `
+		UPDATE search_engines SET keyword = ?1
+		WHERE id = ?2 AND keyword IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM search_engines other WHERE other.user_id = search_engines.user_id AND other.keyword = ?1)`
+
+		if _, err := tx.Exec(backfillSQL, keyword, engine.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func rollbackAddSearchEngineKeyword(tx *sql.Tx) error {
+	if _, err := tx.Exec("DROP INDEX IF EXISTS idx_search_engines_keyword"); err != nil {
+		return err
+	}
+	return dropColumnIfExists(tx, "search_engines", "keyword")
+}
+
+// migrateAddSearchEngineMethodAndBody 搜索引擎的请求方法和 POST 表单模板，已有的搜索引擎均为 GET
+func migrateAddSearchEngineMethodAndBody(tx *sql.Tx) error {
+	if err := addColumnIfNotExists(tx, "search_engines", "method", "TEXT NOT NULL DEFAULT 'GET'"); err != nil {
+		return err
+	}
+	return addColumnIfNotExists(tx, "search_engines", "body", "TEXT")
+}
+
+func rollbackAddSearchEngineMethodAndBody(tx *sql.Tx) error {
+	if err := dropColumnIfExists(tx, "search_engines", "body"); err != nil {
+		return err
+	}
+	return dropColumnIfExists(tx, "search_engines", "method")
+}
+
+// migrateAddSearchEngineOrderAndCategories 搜索引擎的显式排序和分类
+// 已有搜索引擎按原来的显示顺序（默认引擎在前，其余按名称）编号，保持升级前后顺序不变
+func migrateAddSearchEngineOrderAndCategories(tx *sql.Tx) error {
+	categoriesSQL := `
+	CREATE TABLE search_engine_categories (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		sort_order INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE UNIQUE INDEX idx_search_engine_categories_user_name ON search_engine_categories (user_id, name COLLATE NOCASE);`
+	if _, err := tx.Exec(categoriesSQL); err != nil {
+		return err
+	}
+
+	if err := addColumnIfNotExists(tx, "search_engines", "sort_order", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfNotExists(tx, "search_engines", "category_id", "TEXT"); err != nil {
+		return err
+	}
+
+	backfillSQL := `
+	UPDATE search_engines SET sort_order = (
+		SELECT COUNT(*) FROM search_engines other
+		WHERE other.user_id IS search_engines.user_id
+		  AND (other.is_default > search_engines.is_default
+		    OR (other.is_default = search_engines.is_default AND other.name < search_engines.name)
+		    OR (other.is_default = search_engines.is_default AND other.name = search_engines.name AND other.id < search_engines.id))
+	)`
+	if _, err := tx.Exec(backfillSQL); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_search_engines_sort_order ON search_engines (user_id, sort_order)")
+	return err
+}
+
+func rollbackAddSearchEngineOrderAndCategories(tx *sql.Tx) error {
+	if _, err := tx.Exec("DROP INDEX IF EXISTS idx_search_engines_sort_order"); err != nil {
+		return err
+	}
+	if err := dropColumnIfExists(tx, "search_engines", "category_id"); err != nil {
+		return err
+	}
+	if err := dropColumnIfExists(tx, "search_engines", "sort_order"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("DROP TABLE IF EXISTS search_engine_categories")
+	return err
+}
+
+// migrateAddSoftDelete 网站、分组和搜索引擎改为软删除，删除时只记录 deleted_at（Unix 时间戳，秒）并移入回收站
+// 网站和分组的 deleted_with 为随之一起删除的分组ID，恢复该分组时一起恢复；单独删除时为空
+// 搜索引擎的关键词只需在未删除的搜索引擎中唯一，唯一索引改为部分索引
+func migrateAddSoftDelete(tx *sql.Tx) error {
+	for _, table := range []string{"websites", "groups", "search_engines"} {
+		if err := addColumnIfNotExists(tx, table, "deleted_at", "INTEGER"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_" + table + "_deleted_at ON " + table + " (user_id, deleted_at)"); err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"websites", "groups"} {
+		if err := addColumnIfNotExists(tx, table, "deleted_with", "TEXT"); err != nil {
+			return err
+		}
+	}
+
+	keywordSQL := `
+	DROP INDEX IF EXISTS idx_search_engines_keyword;
+	CREATE UNIQUE INDEX idx_search_engines_keyword ON search_engines (user_id, keyword) WHERE deleted_at IS NULL;`
+	_, err := tx.Exec(keywordSQL)
+	return err
+}
+
+// rollbackAddSoftDelete 回收站中的条目被永久删除，之后删除软删除相关的列
+func rollbackAddSoftDelete(tx *sql.Tx) error {
+	purgeSQL := `
+	UPDATE websites SET group_id = NULL WHERE group_id IN (SELECT id FROM groups WHERE deleted_at IS NOT NULL);
+	UPDATE groups SET parent_id = NULL WHERE parent_id IN (SELECT id FROM groups WHERE deleted_at IS NOT NULL);
+	DELETE FROM websites WHERE deleted_at IS NOT NULL;
+	DELETE FROM groups WHERE deleted_at IS NOT NULL;
+	DELETE FROM search_engines WHERE deleted_at IS NOT NULL;
+	DROP INDEX IF EXISTS idx_search_engines_keyword;
+	CREATE UNIQUE INDEX idx_search_engines_keyword ON search_engines (user_id, keyword);`
+	if _, err := tx.Exec(purgeSQL); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"websites", "groups"} {
+		if err := dropColumnIfExists(tx, table, "deleted_with"); err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"websites", "groups", "search_engines"} {
+		if _, err := tx.Exec("DROP INDEX IF EXISTS idx_" + table + "_deleted_at"); err != nil {
+			return err
+		}
+		if err := dropColumnIfExists(tx, table, "deleted_at"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenHandler struct {
+	service *service.APITokenService
+}
+
+func NewAPITokenHandler(service *service.APITokenService) *APITokenHandler {
+	return &APITokenHandler{service: service}
+}
+
+// GetAll 获取当前账号的所有 API 令牌
+func (h *APITokenHandler) GetAll(c *gin.Context) {
+	tokens, err := h.service.GetAll(ownerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tokens})
+}
+
+// Create 创建 API 令牌
+func (h *APITokenHandler) Create(c *gin.Context) {
+	user := currentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req model.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Create(user, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+// Revoke 吊销 API 令牌
+func (h *APITokenHandler) Revoke(c *gin.Context) {
+	if err := h.service.Revoke(ownerID(c), c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrAPITokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+}
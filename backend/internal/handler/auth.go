@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionCookie 会话 Cookie 名称
+const sessionCookie = "nav_session"
+
+// userContextKey 已登录账号在 gin.Context 中的键
+const userContextKey = "user"
+
+// ownerContextKey 未登录访问时所展示面板的账号在 gin.Context 中的键
+const ownerContextKey = "owner"
+
+// apiTokenContextKey 使用 API 令牌认证时令牌信息在 gin.Context 中的键
+const apiTokenContextKey = "apiToken"
+
+type AuthHandler struct {
+	service      *service.AuthService
+	tokenService *service.APITokenService
+}
+
+func NewAuthHandler(service *service.AuthService, tokenService *service.APITokenService) *AuthHandler {
+	return &AuthHandler{service: service, tokenService: tokenService}
+}
+
+// Authenticate 解析请求中的会话令牌或 API 令牌，有效时把账号放入上下文，本身不拒绝请求
+// 会话令牌可以放在 Authorization: Bearer 头中，也可以通过会话 Cookie 携带；API 令牌只能放在 Authorization 头中
+// 未登录且允许公开只读（或关闭认证）时，使用默认账号的面板
+func (h *AuthHandler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.service.Enabled() {
+			h.setDefaultOwner(c)
+			c.Next()
+			return
+		}
+
+		if token := requestToken(c); strings.HasPrefix(token, model.APITokenPrefix) {
+			user, apiToken, err := h.tokenService.Authenticate(token)
+			if err != nil && !errors.Is(err, service.ErrUnauthenticated) {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if user != nil {
+				c.Set(userContextKey, user)
+				c.Set(apiTokenContextKey, apiToken)
+			}
+		} else if token != "" {
+			user, err := h.service.Authenticate(token)
+			if err != nil && !errors.Is(err, service.ErrUnauthenticated) {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if user != nil {
+				c.Set(userContextKey, user)
+			}
+		}
+
+		if currentUser(c) == nil && h.service.PublicRead() {
+			h.setDefaultOwner(c)
+		}
+
+		c.Next()
+	}
+}
+
+func (h *AuthHandler) setDefaultOwner(c *gin.Context) {
+	owner, err := h.service.DefaultUser()
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			c.Error(err)
+		}
+		return
+	}
+	c.Set(ownerContextKey, owner)
+}
+
+// RequireAuth 要求登录，开启公开只读模式时放行 GET 和 HEAD 请求
+func (h *AuthHandler) RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.service.PublicRead() && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+			c.Next()
+			return
+		}
+		h.requireUser(c)
+	}
+}
+
+// RequireLogin 无论是否开启公开只读模式都要求登录，用于导出、备份等敏感接口
+func (h *AuthHandler) RequireLogin() gin.HandlerFunc {
+	return h.requireUser
+}
+
+// RequireAdmin 要求管理员登录，用于账号管理和备份等影响整个实例的接口
+func (h *AuthHandler) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.service.Enabled() {
+			c.Next()
+			return
+		}
+
+		user := currentUser(c)
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		if !user.IsAdmin() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin privileges required"})
+			return
+		}
+		if token := currentAPIToken(c); token != nil && !service.TokenAllows(token, model.ScopeAdmin) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API token is missing scope " + string(model.ScopeAdmin)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope 使用 API 令牌访问时检查权限范围，GET 和 HEAD 请求需要 read，其余需要 write
+// 通过登录会话访问或未登录访问时不做限制，是否需要登录由 RequireAuth 决定
+func (h *AuthHandler) RequireScope(read, write model.TokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := currentAPIToken(c)
+		if token == nil {
+			c.Next()
+			return
+		}
+
+		required := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			required = read
+		}
+		if !service.TokenAllows(token, required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API token is missing scope " + string(required)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession 要求通过登录会话访问，用于修改密码、管理 API 令牌等不应交给脚本的操作
+func (h *AuthHandler) RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentAPIToken(c) != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint cannot be used with an API token"})
+			return
+		}
+		h.requireUser(c)
+	}
+}
+
+func (h *AuthHandler) requireUser(c *gin.Context) {
+	if !h.service.Enabled() || currentUser(c) != nil {
+		c.Next()
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+}
+
+// Login 登录
+func (h *AuthHandler) Login(c *gin.Context) {
+	if !h.service.Enabled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Authentication is disabled"})
+		return
+	}
+
+	var req model.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Login(req, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		case errors.Is(err, service.ErrTooManyAttempts):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, please try again later"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	h.setSessionCookie(c, result.Token, int(h.service.SessionTTL().Seconds()))
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// Logout 注销当前会话
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.service.Logout(requestToken(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setSessionCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// Status 获取当前认证状态
+func (h *AuthHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.service.Status(currentUser(c))})
+}
+
+// ChangePassword 修改当前账号的密码
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	user := currentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ChangePassword(user, req); err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setSessionCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully, please log in again"})
+}
+
+func (h *AuthHandler) setSessionCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, token, maxAge, "/", "", h.service.CookieSecure(), true)
+}
+
+// requestToken 从 Authorization 头或会话 Cookie 中读取令牌
+func requestToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if token, err := c.Cookie(sessionCookie); err == nil {
+		return token
+	}
+	return ""
+}
+
+// ownerID 获取当前请求所操作的面板所属账号：已登录时为登录账号，否则为默认账号
+func ownerID(c *gin.Context) string {
+	if user := currentUser(c); user != nil {
+		return user.ID
+	}
+	if value, ok := c.Get(ownerContextKey); ok {
+		if owner, ok := value.(*model.User); ok {
+			return owner.ID
+		}
+	}
+	return ""
+}
+
+// currentAPIToken 获取当前请求使用的 API 令牌，未使用时返回 nil
+func currentAPIToken(c *gin.Context) *model.APIToken {
+	if value, ok := c.Get(apiTokenContextKey); ok {
+		if token, ok := value.(*model.APIToken); ok {
+			return token
+		}
+	}
+	return nil
+}
+
+// currentUser 获取当前登录的账号，未登录时返回 nil
+func currentUser(c *gin.Context) *model.User {
+	if value, ok := c.Get(userContextKey); ok {
+		if user, ok := value.(*model.User); ok {
+			return user
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BackupHandler struct {
+	service *service.BackupService
+}
+
+func NewBackupHandler(service *service.BackupService) *BackupHandler {
+	return &BackupHandler{service: service}
+}
+
+// List 获取所有备份
+func (h *BackupHandler) List(c *gin.Context) {
+	backups, err := h.service.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": backups})
+}
+
+// Create 立即创建手动备份
+func (h *BackupHandler) Create(c *gin.Context) {
+	backup, err := h.service.Create(model.BackupKindManual)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": backup})
+}
+
+// Download 下载备份文件
+func (h *BackupHandler) Download(c *gin.Context) {
+	name := c.Param("name")
+	path, err := h.service.Path(name)
+	if err != nil {
+		if errors.Is(err, service.ErrBackupNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Backup not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.FileAttachment(path, name)
+}
+
+// Restore 从备份恢复数据
+func (h *BackupHandler) Restore(c *gin.Context) {
+	safety, err := h.service.Restore(c.Param("name"))
+	if err != nil {
+		if errors.Is(err, service.ErrBackupNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Backup not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": gin.H{"safetyBackup": safety}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Backup restored successfully",
+		"data":    gin.H{"safetyBackup": safety},
+	})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/service"
@@ -20,7 +21,7 @@ func NewGroupHandler(groupService *service.GroupService) *GroupHandler {
 
 // GetGroups 获取所有分组
 func (h *GroupHandler) GetGroups(c *gin.Context) {
-	groups, err := h.groupService.GetAllGroups()
+	groups, err := h.groupService.GetAllGroups(ownerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -31,7 +32,7 @@ func (h *GroupHandler) GetGroups(c *gin.Context) {
 
 // GetGroupsWithWebsites 获取所有分组及其网站
 func (h *GroupHandler) GetGroupsWithWebsites(c *gin.Context) {
-	groups, err := h.groupService.GetAllGroupsWithWebsites()
+	groups, err := h.groupService.GetAllGroupsWithWebsites(ownerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -40,6 +41,17 @@ func (h *GroupHandler) GetGroupsWithWebsites(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": groups})
 }
 
+// GetGroupTree 获取嵌套的分组树及其网站
+func (h *GroupHandler) GetGroupTree(c *gin.Context) {
+	tree, err := h.groupService.GetGroupTree(ownerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tree})
+}
+
 // CreateGroup 创建分组
 func (h *GroupHandler) CreateGroup(c *gin.Context) {
 	var req model.CreateGroupRequest
@@ -48,9 +60,9 @@ func (h *GroupHandler) CreateGroup(c *gin.Context) {
 		return
 	}
 
-	group, err := h.groupService.CreateGroup(req)
+	group, err := h.groupService.CreateGroup(ownerID(c), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondGroupError(c, err)
 		return
 	}
 
@@ -71,8 +83,12 @@ func (h *GroupHandler) UpdateGroup(c *gin.Context) {
 		return
 	}
 
-	updatedGroup, err := h.groupService.UpdateGroup(id, req)
+	updatedGroup, err := h.groupService.UpdateGroup(ownerID(c), id, req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidIcon) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -88,13 +104,58 @@ func (h *GroupHandler) DeleteGroup(c *gin.Context) {
 		return
 	}
 
-	err := h.groupService.DeleteGroup(id)
+	// ?children=promote（默认）把子分组提升一级，?children=cascade 一起删除子分组
+	mode := model.GroupDeleteMode(c.DefaultQuery("children", string(model.GroupDeletePromote)))
+	err := h.groupService.DeleteGroup(ownerID(c), id, mode)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group moved to trash"})
+}
+
+// GetTrash 获取回收站中的分组
+func (h *GroupHandler) GetTrash(c *gin.Context) {
+	groups, err := h.groupService.GetTrash(ownerID(c))
+	if err != nil {
+		respondGroupError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// RestoreGroup 从回收站恢复分组及随之一起删除的网站和子分组
+func (h *GroupHandler) RestoreGroup(c *gin.Context) {
+	group, err := h.groupService.RestoreGroup(ownerID(c), c.Param("id"))
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": group})
+}
+
+// PurgeGroup 永久删除回收站中的分组及随之一起删除的网站和子分组
+func (h *GroupHandler) PurgeGroup(c *gin.Context) {
+	if err := h.groupService.PurgeGroup(ownerID(c), c.Param("id")); err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group deleted permanently"})
+}
+
+// EmptyTrash 清空回收站中的分组
+func (h *GroupHandler) EmptyTrash(c *gin.Context) {
+	deleted, err := h.groupService.EmptyTrash(ownerID(c))
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"deleted": deleted}, "message": "Group trash emptied successfully"})
 }
 
 // ReorderGroups 重新排序分组
@@ -105,15 +166,32 @@ func (h *GroupHandler) ReorderGroups(c *gin.Context) {
 		return
 	}
 
-	err := h.groupService.ReorderGroups(req.GroupIds)
+	err := h.groupService.ReorderGroups(ownerID(c), req.GroupIds)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondGroupError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Groups reordered successfully"})
 }
 
+// MoveGroup 修改分组的父分组或在同一层级中的位置
+func (h *GroupHandler) MoveGroup(c *gin.Context) {
+	var req model.MoveGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.groupService.MoveGroup(ownerID(c), c.Param("id"), req)
+	if err != nil {
+		respondGroupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": group})
+}
+
 // MoveWebsiteToGroup 移动网站到分组
 func (h *GroupHandler) MoveWebsiteToGroup(c *gin.Context) {
 	var req model.MoveWebsiteToGroupRequest
@@ -122,11 +200,26 @@ func (h *GroupHandler) MoveWebsiteToGroup(c *gin.Context) {
 		return
 	}
 
-	err := h.groupService.MoveWebsiteToGroup(req.WebsiteId, req.GroupId, req.Position)
+	err := h.groupService.MoveWebsiteToGroup(ownerID(c), req.WebsiteId, req.GroupId, req.Position)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Website moved successfully"})
-}
\ No newline at end of file
+}
+
+func respondGroupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrGroupNotFound), errors.Is(err, service.ErrNotInTrash):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidIcon),
+		errors.Is(err, service.ErrGroupCycle),
+		errors.Is(err, service.ErrGroupTooDeep),
+		errors.Is(err, service.ErrInvalidGroupReorder),
+		errors.Is(err, service.ErrInvalidGroupDeleteMode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
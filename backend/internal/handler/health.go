@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"nav-panel-backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	service *service.HealthService
+}
+
+func NewHealthHandler(service *service.HealthService) *HealthHandler {
+	return &HealthHandler{service: service}
+}
+
+// GetAll 获取当前账号下所有网站的链接健康状态
+func (h *HealthHandler) GetAll(c *gin.Context) {
+	result, err := h.service.GetAll(ownerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// Check 立即检查单个网站的链接
+func (h *HealthHandler) Check(c *gin.Context) {
+	result, err := h.service.Check(c.Request.Context(), ownerID(c), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrWebsiteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Website not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
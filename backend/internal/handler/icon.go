@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"nav-panel-backend/internal/config"
+	"nav-panel-backend/internal/service"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// iconHashPattern 图标哈希为 SHA-256 的十六进制表示
+var iconHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type IconHandler struct {
+	service *service.IconService
+	upload  config.Upload
+}
+
+func NewIconHandler(service *service.IconService, upload config.Upload) *IconHandler {
+	return &IconHandler{service: service, upload: upload}
+}
+
+// Get 获取本地缓存的图标，内容由哈希决定，可以长期缓存
+// 可通过 ?size= 获取不小于该尺寸的缩略图
+func (h *IconHandler) Get(c *gin.Context) {
+	hash := c.Param("hash")
+	if !iconHashPattern.MatchString(hash) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Icon not found"})
+		return
+	}
+
+	size := 0
+	if value := c.Query("size"); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil || size <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size must be a positive integer"})
+			return
+		}
+	}
+
+	icon, err := h.service.GetVariant(hash, size)
+	if err != nil {
+		if errors.Is(err, service.ErrIconNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Icon not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	etag := icon.ETag()
+	if size > 0 {
+		etag = fmt.Sprintf(`"%s-%d"`, icon.Hash, size)
+	}
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	// 直接打开 SVG 图标时禁止执行脚本
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, icon.ContentType, icon.Data)
+}
+
+// Upload 上传图标，标准化后存入图标库，返回的 url 可以作为网站、分组或搜索引擎的图标
+func (h *IconHandler) Upload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.upload.MaxRequestSize))
+	if err := c.Request.ParseMultipartForm(int64(h.upload.MaxRequestSize)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form"})
+		return
+	}
+
+	data, err := readIconUpload(c, h.upload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if data == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "icon file is required"})
+		return
+	}
+
+	icon, err := h.service.StoreUpload(data)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidIcon) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": service.UploadResponse(icon)})
+}
+
+// readIconUpload 读取已解析的 multipart 表单中的 icon 文件，没有上传文件时返回 nil
+// 不信任客户端声明的 Content-Type，格式由服务层按内容识别
+func readIconUpload(c *gin.Context, upload config.Upload) ([]byte, error) {
+	file, header, err := c.Request.FormFile("icon")
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read image file: %w", err)
+	}
+	defer file.Close()
+
+	if header.Size > int64(upload.MaxIconSize) {
+		return nil, fmt.Errorf("image too large. Maximum size is %s", upload.MaxIconSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(upload.MaxIconSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file: %w", err)
+	}
+	if int64(len(data)) > int64(upload.MaxIconSize) {
+		return nil, fmt.Errorf("image too large. Maximum size is %s", upload.MaxIconSize)
+	}
+	return data, nil
+}
+
+// etagMatches 判断 If-None-Match 是否包含指定的 ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
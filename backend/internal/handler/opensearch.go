@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/xml"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OpenSearchHandler struct {
+	service   *service.OpenSearchService
+	publicURL string
+}
+
+// NewOpenSearchHandler publicURL 为配置的对外地址，为空时按请求推断
+func NewOpenSearchHandler(service *service.OpenSearchService, publicURL string) *OpenSearchHandler {
+	return &OpenSearchHandler{service: service, publicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// Description 返回 OpenSearch 描述文档，页面中通过 <link rel="search"> 引用后浏览器会提供“添加为搜索引擎”
+func (h *OpenSearchHandler) Description(c *gin.Context) {
+	owner := ownerID(c)
+	if owner == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	doc, err := h.service.Description(owner, h.baseURL(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/opensearchdescription+xml; charset=utf-8", append([]byte(xml.Header), data...))
+}
+
+// Suggest 返回 OpenSearch 建议格式 [q, [建议], [说明], [地址]]，支持 ?q=&limit=
+// 只有登录时才包含搜索历史；使用 API 令牌时搜索引擎关键词和搜索历史需要 search-engines:read 权限，
+// 网站和分组需要 websites:read 权限；没有可展示的账号时返回空建议，不打断浏览器输入
+func (h *OpenSearchHandler) Suggest(c *gin.Context) {
+	q := c.Query("q")
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
+
+	completions := []model.SearchCompletion{}
+	if owner := ownerID(c); owner != "" {
+		token := currentAPIToken(c)
+		enginesAllowed := token == nil || service.TokenAllows(token, model.ScopeSearchEnginesRead)
+		sources := service.SuggestSources{
+			Engines:  enginesAllowed,
+			History:  currentUser(c) != nil && enginesAllowed,
+			Websites: token == nil || service.TokenAllows(token, model.ScopeWebsitesRead),
+		}
+
+		var err error
+		completions, err = h.service.Suggest(owner, q, h.baseURL(c), sources, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	texts := make([]string, 0, len(completions))
+	descriptions := make([]string, 0, len(completions))
+	urls := make([]string, 0, len(completions))
+	for _, completion := range completions {
+		texts = append(texts, completion.Text)
+		descriptions = append(descriptions, completion.Description)
+		urls = append(urls, completion.URL)
+	}
+
+	c.Header("Content-Type", "application/x-suggestions+json; charset=utf-8")
+	c.JSON(http.StatusOK, []interface{}{q, texts, descriptions, urls})
+}
+
+// baseURL 面板对外的地址，未配置时按请求的 Host 和 X-Forwarded-Proto 推断
+func (h *OpenSearchHandler) baseURL(c *gin.Context) string {
+	if h.publicURL != "" {
+		return h.publicURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
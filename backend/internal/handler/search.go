@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchFormPage POST 搜索引擎无法直接跳转，返回自动提交表单的页面，表单按搜索引擎的字符编码提交
+var searchFormPage = template.Must(template.New("search-form").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="referrer" content="no-referrer"><title>Redirecting...</title></head>
+<body onload="document.forms[0].submit()">
+<form method="post" action="{{.URL}}" accept-charset="{{.Encoding}}">
+{{- range .Fields}}
+<input type="hidden" name="{{.Name}}" value="{{.Value}}">
+{{- end}}
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body>
+</html>
+`))
+
+type SearchHandler struct {
+	engines *service.SearchEngineService
+	history *service.SearchHistoryService
+}
+
+func NewSearchHandler(engines *service.SearchEngineService, history *service.SearchHistoryService) *SearchHandler {
+	return &SearchHandler{engines: engines, history: history}
+}
+
+// Search 按 ?q= 中的关键词选择搜索引擎并 302 跳转到搜索结果页，可设为浏览器的默认搜索引擎
+// POST 搜索引擎返回自动提交表单的页面，{lang} 取自 Accept-Language
+// 使用当前账号（未登录时为公开展示的账号）的搜索引擎，登录时记录搜索历史（API 令牌需要 search-engines:write 权限）
+func (h *SearchHandler) Search(c *gin.Context) {
+	owner := ownerID(c)
+	if owner == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	route, err := h.engines.Route(owner, c.Query("q"), requestLanguage(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSearchQuery):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNoSearchEngine):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidSearchEngine):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if currentUser(c) != nil && route.Query != "" && canRecordSearchHistory(c) {
+		h.history.Track(owner, route.Query, route.Engine.ID)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if route.Request.Method != http.MethodPost {
+		c.Redirect(http.StatusFound, route.Request.URL)
+		return
+	}
+
+	var page bytes.Buffer
+	if err := searchFormPage.Execute(&page, route.Request); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// canRecordSearchHistory 记录搜索历史是写操作，与 POST /api/search/history 一样，
+// 使用 API 令牌时需要 search-engines:write 权限，登录会话不受限制
+func canRecordSearchHistory(c *gin.Context) bool {
+	token := currentAPIToken(c)
+	return token == nil || service.TokenAllows(token, model.ScopeSearchEnginesWrite)
+}
+
+// requestLanguage 请求的首选语言，如 zh-CN，取自 Accept-Language 的第一项
+func requestLanguage(c *gin.Context) string {
+	first, _, _ := strings.Cut(c.GetHeader("Accept-Language"), ",")
+	lang, _, _ := strings.Cut(first, ";")
+	lang = strings.TrimSpace(lang)
+	if lang == "*" {
+		return ""
+	}
+	return lang
+}
@@ -2,28 +2,28 @@ package handler
 
 import (
 	"database/sql"
-	"encoding/base64"
-	"fmt"
-	"io"
+	"errors"
+	"nav-panel-backend/internal/config"
 	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/service"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type SearchEngineHandler struct {
 	service *service.SearchEngineService
+	icons   *service.IconService
+	upload  config.Upload
 }
 
-func NewSearchEngineHandler(service *service.SearchEngineService) *SearchEngineHandler {
-	return &SearchEngineHandler{service: service}
+func NewSearchEngineHandler(service *service.SearchEngineService, icons *service.IconService, upload config.Upload) *SearchEngineHandler {
+	return &SearchEngineHandler{service: service, icons: icons, upload: upload}
 }
 
 // GetAll 获取所有搜索引擎
 func (h *SearchEngineHandler) GetAll(c *gin.Context) {
-	engines, err := h.service.GetAll()
+	engines, err := h.service.GetAll(ownerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -40,9 +40,9 @@ func (h *SearchEngineHandler) Create(c *gin.Context) {
 		return
 	}
 
-	engine, err := h.service.Create(&req)
+	engine, err := h.service.Create(ownerID(c), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSearchEngineError(c, err)
 		return
 	}
 
@@ -52,7 +52,8 @@ func (h *SearchEngineHandler) Create(c *gin.Context) {
 // CreateWithIcon 创建带图片上传的搜索引擎
 func (h *SearchEngineHandler) CreateWithIcon(c *gin.Context) {
 	// 解析multipart form
-	err := c.Request.ParseMultipartForm(10 << 20) // 限制10MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.upload.MaxRequestSize))
+	err := c.Request.ParseMultipartForm(int64(h.upload.MaxRequestSize))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form"})
 		return
@@ -64,6 +65,10 @@ func (h *SearchEngineHandler) CreateWithIcon(c *gin.Context) {
 		Name:        c.PostForm("name"),
 		URL:         c.PostForm("url"),
 		Placeholder: stringPtr(c.PostForm("placeholder")),
+		Keyword:     stringPtr(c.PostForm("keyword")),
+		Method:      stringPtr(c.PostForm("method")),
+		Body:        stringPtr(c.PostForm("body")),
+		CategoryId:  stringPtr(c.PostForm("categoryId")),
 	}
 
 	// 验证必填字段
@@ -72,35 +77,24 @@ func (h *SearchEngineHandler) CreateWithIcon(c *gin.Context) {
 		return
 	}
 
-	// 处理图标上传
-	file, header, err := c.Request.FormFile("icon")
-	if err == nil {
-		defer file.Close()
-
-		// 检查文件类型
-		contentType := header.Header.Get("Content-Type")
-		if !isValidImageType(contentType) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image type. Only PNG, JPG, GIF, WebP and SVG are allowed"})
-			return
-		}
-
-		// 检查文件大小（限制2MB）
-		if header.Size > 2<<20 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Image too large. Maximum size is 2MB"})
-			return
-		}
-
-		// 读取文件内容
-		data, err := io.ReadAll(file)
+	// 处理图标上传，按内容识别格式并标准化后存入图标库
+	data, err := readIconUpload(c, h.upload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if data != nil {
+		icon, err := h.icons.StoreUpload(data)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image file"})
+			if errors.Is(err, service.ErrInvalidIcon) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		// 转换为base64
-		base64Data := base64.StdEncoding.EncodeToString(data)
-		dataURL := fmt.Sprintf("data:%s;base64,%s", contentType, base64Data)
-		req.Icon = &dataURL
+		iconRef := icon.URL()
+		req.Icon = &iconRef
 	}
 
 	// 处理isDefault字段
@@ -109,9 +103,9 @@ func (h *SearchEngineHandler) CreateWithIcon(c *gin.Context) {
 		req.IsDefault = &isDefault
 	}
 
-	engine, err := h.service.Create(&req)
+	engine, err := h.service.Create(ownerID(c), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSearchEngineError(c, err)
 		return
 	}
 
@@ -132,13 +126,9 @@ func (h *SearchEngineHandler) Update(c *gin.Context) {
 		return
 	}
 
-	engine, err := h.service.Update(id, &req)
+	engine, err := h.service.Update(ownerID(c), id, &req)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Search engine not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSearchEngineError(c, err)
 		return
 	}
 
@@ -154,7 +144,8 @@ func (h *SearchEngineHandler) UpdateWithIcon(c *gin.Context) {
 	}
 
 	// 解析multipart form
-	err := c.Request.ParseMultipartForm(10 << 20) // 限制10MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(h.upload.MaxRequestSize))
+	err := c.Request.ParseMultipartForm(int64(h.upload.MaxRequestSize))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form"})
 		return
@@ -172,36 +163,38 @@ func (h *SearchEngineHandler) UpdateWithIcon(c *gin.Context) {
 	if placeholder := c.PostForm("placeholder"); placeholder != "" {
 		req.Placeholder = &placeholder
 	}
+	// keyword、body 和 categoryId 字段存在时更新，空字符串表示清除
+	if keyword, ok := c.GetPostForm("keyword"); ok {
+		req.Keyword = &keyword
+	}
+	if method := c.PostForm("method"); method != "" {
+		req.Method = &method
+	}
+	if body, ok := c.GetPostForm("body"); ok {
+		req.Body = &body
+	}
+	if categoryID, ok := c.GetPostForm("categoryId"); ok {
+		req.CategoryId = &categoryID
+	}
 
-	// 处理图标上传
-	file, header, err := c.Request.FormFile("icon")
-	if err == nil {
-		defer file.Close()
-
-		// 检查文件类型
-		contentType := header.Header.Get("Content-Type")
-		if !isValidImageType(contentType) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image type. Only PNG, JPG, GIF, WebP and SVG are allowed"})
-			return
-		}
-
-		// 检查文件大小（限制2MB）
-		if header.Size > 2<<20 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Image too large. Maximum size is 2MB"})
-			return
-		}
-
-		// 读取文件内容
-		data, err := io.ReadAll(file)
+	// 处理图标上传，按内容识别格式并标准化后存入图标库
+	data, err := readIconUpload(c, h.upload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if data != nil {
+		icon, err := h.icons.StoreUpload(data)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image file"})
+			if errors.Is(err, service.ErrInvalidIcon) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		// 转换为base64
-		base64Data := base64.StdEncoding.EncodeToString(data)
-		dataURL := fmt.Sprintf("data:%s;base64,%s", contentType, base64Data)
-		req.Icon = &dataURL
+		iconRef := icon.URL()
+		req.Icon = &iconRef
 	}
 
 	// 处理isDefault字段
@@ -210,13 +203,9 @@ func (h *SearchEngineHandler) UpdateWithIcon(c *gin.Context) {
 		req.IsDefault = &isDefault
 	}
 
-	engine, err := h.service.Update(id, &req)
+	engine, err := h.service.Update(ownerID(c), id, &req)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Search engine not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSearchEngineError(c, err)
 		return
 	}
 
@@ -231,17 +220,105 @@ func (h *SearchEngineHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Delete(id)
+	err := h.service.Delete(ownerID(c), id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Search engine not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSearchEngineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Search engine moved to trash"})
+}
+
+// GetTrash 获取回收站中的搜索引擎
+func (h *SearchEngineHandler) GetTrash(c *gin.Context) {
+	engines, err := h.service.GetTrash(ownerID(c))
+	if err != nil {
+		respondSearchEngineError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Search engine deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"data": engines})
+}
+
+// Restore 从回收站恢复搜索引擎
+func (h *SearchEngineHandler) Restore(c *gin.Context) {
+	engine, err := h.service.Restore(ownerID(c), c.Param("id"))
+	if err != nil {
+		respondSearchEngineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": engine})
+}
+
+// Purge 永久删除回收站中的搜索引擎
+func (h *SearchEngineHandler) Purge(c *gin.Context) {
+	if err := h.service.Purge(ownerID(c), c.Param("id")); err != nil {
+		respondSearchEngineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Search engine deleted permanently"})
+}
+
+// EmptyTrash 清空回收站中的搜索引擎
+func (h *SearchEngineHandler) EmptyTrash(c *gin.Context) {
+	deleted, err := h.service.EmptyTrash(ownerID(c))
+	if err != nil {
+		respondSearchEngineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"deleted": deleted}, "message": "Search engine trash emptied successfully"})
+}
+
+// Reorder 重新排序搜索引擎
+func (h *SearchEngineHandler) Reorder(c *gin.Context) {
+	var req model.ReorderSearchEnginesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	engines, err := h.service.Reorder(ownerID(c), &req)
+	if err != nil {
+		respondSearchEngineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": engines})
+}
+
+// Resolve 按搜索引擎的地址模板生成最终的搜索请求（方法、地址、编码后的表单）
+func (h *SearchEngineHandler) Resolve(c *gin.Context) {
+	var req model.ResolveSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resolved, err := h.service.Resolve(ownerID(c), c.Param("id"), req)
+	if err != nil {
+		respondSearchEngineError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": resolved})
+}
+
+func respondSearchEngineError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Search engine not found"})
+	case errors.Is(err, service.ErrNotInTrash):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSearchEngineKeywordExists), errors.Is(err, service.ErrSearchEngineInTrash):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidIcon), errors.Is(err, service.ErrInvalidSearchEngine), errors.Is(err, service.ErrInvalidSearchQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 // 辅助函数
@@ -251,21 +328,3 @@ func stringPtr(s string) *string {
 	}
 	return &s
 }
-
-func isValidImageType(contentType string) bool {
-	validTypes := []string{
-		"image/png",
-		"image/jpeg",
-		"image/jpg", 
-		"image/gif",
-		"image/webp",
-		"image/svg+xml",
-	}
-	
-	for _, validType := range validTypes {
-		if strings.HasPrefix(contentType, validType) {
-			return true
-		}
-	}
-	return false
-} 
\ No newline at end of file
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchEngineCategoryHandler struct {
+	service *service.SearchEngineCategoryService
+}
+
+func NewSearchEngineCategoryHandler(service *service.SearchEngineCategoryService) *SearchEngineCategoryHandler {
+	return &SearchEngineCategoryHandler{service: service}
+}
+
+// GetAll 按排序获取所有搜索引擎分类
+func (h *SearchEngineCategoryHandler) GetAll(c *gin.Context) {
+	categories, err := h.service.GetAll(ownerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": categories})
+}
+
+// Create 创建搜索引擎分类
+func (h *SearchEngineCategoryHandler) Create(c *gin.Context) {
+	var req model.CreateSearchEngineCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Create(ownerID(c), req)
+	if err != nil {
+		respondSearchEngineCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": category})
+}
+
+// Update 重命名搜索引擎分类
+func (h *SearchEngineCategoryHandler) Update(c *gin.Context) {
+	var req model.UpdateSearchEngineCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Update(ownerID(c), c.Param("id"), req)
+	if err != nil {
+		respondSearchEngineCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
+// Delete 删除搜索引擎分类，分类中的搜索引擎变为未分类
+func (h *SearchEngineCategoryHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(ownerID(c), c.Param("id")); err != nil {
+		respondSearchEngineCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Search engine category deleted successfully"})
+}
+
+// Reorder 重新排序搜索引擎分类
+func (h *SearchEngineCategoryHandler) Reorder(c *gin.Context) {
+	var req model.ReorderSearchEngineCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categories, err := h.service.Reorder(ownerID(c), req.CategoryIds)
+	if err != nil {
+		respondSearchEngineCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": categories})
+}
+
+func respondSearchEngineCategoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSearchEngineCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSearchEngineCategoryExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidSearchEngineCategory):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
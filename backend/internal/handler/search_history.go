@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHistoryHandler struct {
+	service *service.SearchHistoryService
+}
+
+func NewSearchHistoryHandler(service *service.SearchHistoryService) *SearchHistoryHandler {
+	return &SearchHistoryHandler{service: service}
+}
+
+// Record 记录一次搜索，关闭搜索历史或匹配排除规则时不记录
+func (h *SearchHistoryHandler) Record(c *gin.Context) {
+	var req model.RecordSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.service.Record(ownerID(c), req)
+	if err != nil {
+		respondSearchHistoryError(c, err)
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusOK, gin.H{"data": nil, "message": "Search history is disabled or the query is excluded"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": entry})
+}
+
+// GetRecent 获取最近的搜索记录，支持 ?limit=
+func (h *SearchHistoryHandler) GetRecent(c *gin.Context) {
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.GetRecent(ownerID(c), limit)
+	if err != nil {
+		respondSearchHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// Suggest 根据搜索历史返回建议，支持 ?q=&limit=
+func (h *SearchHistoryHandler) Suggest(c *gin.Context) {
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
+
+	suggestions, err := h.service.Suggest(ownerID(c), c.Query("q"), limit)
+	if err != nil {
+		respondSearchHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": suggestions})
+}
+
+// Delete 删除一条搜索记录
+func (h *SearchHistoryHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(ownerID(c), c.Param("id")); err != nil {
+		respondSearchHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Search history entry deleted successfully"})
+}
+
+// Clear 清空搜索历史
+func (h *SearchHistoryHandler) Clear(c *gin.Context) {
+	deleted, err := h.service.Clear(ownerID(c))
+	if err != nil {
+		respondSearchHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"deleted": deleted}, "message": "Search history cleared successfully"})
+}
+
+// GetSettings 获取搜索历史设置
+func (h *SearchHistoryHandler) GetSettings(c *gin.Context) {
+	settings, err := h.service.GetSettings(ownerID(c))
+	if err != nil {
+		respondSearchHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// UpdateSettings 更新搜索历史设置
+func (h *SearchHistoryHandler) UpdateSettings(c *gin.Context) {
+	var req model.UpdateSearchHistorySettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(ownerID(c), req)
+	if err != nil {
+		respondSearchHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// limitQuery 解析 limit 参数，省略时为 0（使用默认值），格式错误时直接返回 400
+func limitQuery(c *gin.Context) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return 0, false
+	}
+	return n, true
+}
+
+func respondSearchHistoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSearchHistoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidSearchHistory), errors.Is(err, service.ErrInvalidSearchHistorySettings):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
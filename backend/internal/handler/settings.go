@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,7 +21,7 @@ func NewSettingsHandler(service *service.SettingsService) *SettingsHandler {
 
 // Get 获取应用设置
 func (h *SettingsHandler) Get(c *gin.Context) {
-	settings, err := h.service.Get()
+	settings, err := h.service.Get(ownerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -35,7 +38,7 @@ func (h *SettingsHandler) Update(c *gin.Context) {
 		return
 	}
 
-	settings, err := h.service.Update(&req)
+	settings, err := h.service.Update(ownerID(c), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -46,7 +49,7 @@ func (h *SettingsHandler) Update(c *gin.Context) {
 
 // Export 导出所有数据
 func (h *SettingsHandler) Export(c *gin.Context) {
-	data, err := h.service.Export()
+	data, err := h.service.Export(ownerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -57,25 +60,97 @@ func (h *SettingsHandler) Export(c *gin.Context) {
 }
 
 // Import 导入数据
+// 支持 ?mode=replace|merge|dry-run，dry-run 时可用 ?strategy=replace|merge 指定预览的策略
+// ?fetchMetadata=true 时先获取网页元数据，补全缺少的网站名称和描述
 func (h *SettingsHandler) Import(c *gin.Context) {
-	var req model.ImportData
-	if err := c.ShouldBindJSON(&req); err != nil {
+	opts, err := service.ParseImportOptions(c.DefaultQuery("mode", string(model.ImportModeReplace)), c.Query("strategy"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	opts.FetchMetadata, _ = strconv.ParseBool(c.Query("fetchMetadata"))
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := service.DecodeImportData(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.Import(c.Request.Context(), ownerID(c), data, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrImportRejected) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "data": report})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	err := h.service.Import(&req)
+	message := "Data imported successfully"
+	if opts.DryRun {
+		message = "Dry run completed, no changes were made"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": report})
+}
+
+// ExportBookmarks 导出为浏览器书签文件
+func (h *SettingsHandler) ExportBookmarks(c *gin.Context) {
+	data, err := h.service.ExportBookmarks(ownerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Data imported successfully"})
+	c.Header("Content-Disposition", "attachment; filename=nav-panel-bookmarks.html")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", data)
+}
+
+// ImportBookmarks 导入浏览器书签文件
+// 支持 multipart 表单中的 file 字段或直接以请求体上传，默认使用 merge 模式
+// ?fetchMetadata=true 时为缺少描述的书签获取网页元数据
+func (h *SettingsHandler) ImportBookmarks(c *gin.Context) {
+	opts, err := service.ParseImportOptions(c.DefaultQuery("mode", string(model.ImportModeMerge)), c.Query("strategy"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.FetchMetadata, _ = strconv.ParseBool(c.Query("fetchMetadata"))
+
+	var body io.Reader = c.Request.Body
+	if file, _, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+	}
+
+	report, err := h.service.ImportBookmarks(c.Request.Context(), ownerID(c), body, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportRejected):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "data": report})
+		case errors.Is(err, service.ErrInvalidBookmarks):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	message := "Bookmarks imported successfully"
+	if opts.DryRun {
+		message = "Dry run completed, no changes were made"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": report})
 }
 
 // Reset 重置设置为默认值
 func (h *SettingsHandler) Reset(c *gin.Context) {
-	err := h.service.Reset()
+	err := h.service.Reset(ownerID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
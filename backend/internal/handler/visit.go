@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"nav-panel-backend/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VisitHandler struct {
+	service *service.VisitService
+}
+
+func NewVisitHandler(service *service.VisitService) *VisitHandler {
+	return &VisitHandler{service: service}
+}
+
+// Go 记录一次访问并 302 跳转到网站地址
+// 只能跳转到当前账号（未登录时为公开展示的账号）的网站，登录时记录访问者
+func (h *VisitHandler) Go(c *gin.Context) {
+	owner := ownerID(c)
+	if owner == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	visitor := ""
+	if user := currentUser(c); user != nil {
+		visitor = user.ID
+	}
+
+	target, err := h.service.Visit(owner, c.Param("websiteId"), visitor, c.Request.Referer())
+	if err != nil {
+		if errors.Is(err, service.ErrWebsiteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Website not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 禁止缓存跳转，保证每次点击都经过服务端
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, target)
+}
+
+// MostVisited 获取访问最多的网站，支持 ?days=30&limit=10
+func (h *VisitHandler) MostVisited(c *gin.Context) {
+	days, limit, ok := statsQuery(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.MostVisited(ownerID(c), days, limit)
+	if err != nil {
+		respondVisitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// LeastVisited 获取有访问但访问最少的网站，支持 ?days=30&limit=10
+func (h *VisitHandler) LeastVisited(c *gin.Context) {
+	days, limit, ok := statsQuery(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.LeastVisited(ownerID(c), days, limit)
+	if err != nil {
+		respondVisitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// NeverVisited 获取没有访问的网站，?days=N 时为最近 N 天内没有访问，省略时为从未访问
+func (h *VisitHandler) NeverVisited(c *gin.Context) {
+	days, _, ok := statsQuery(c)
+	if !ok {
+		return
+	}
+
+	websites, err := h.service.NeverVisited(ownerID(c), days)
+	if err != nil {
+		respondVisitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": websites})
+}
+
+// Visits 获取每天的访问次数，支持 ?days=30&websiteId=...
+func (h *VisitHandler) Visits(c *gin.Context) {
+	days, _, ok := statsQuery(c)
+	if !ok {
+		return
+	}
+
+	visits, err := h.service.DailyVisits(ownerID(c), optionalQuery(c, "websiteId"), days)
+	if err != nil {
+		respondVisitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": visits})
+}
+
+// statsQuery 解析 days 和 limit 参数，省略时为 0（使用默认值），格式错误时直接返回 400
+func statsQuery(c *gin.Context) (days, limit int, ok bool) {
+	params := []struct {
+		key    string
+		target *int
+	}{{"days", &days}, {"limit", &limit}}
+	for _, p := range params {
+		raw := c.Query(p.key)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a positive integer", p.key)})
+			return 0, 0, false
+		}
+		*p.target = n
+	}
+	return days, limit, true
+}
+
+func respondVisitError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebsiteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Website not found"})
+	case errors.Is(err, service.ErrInvalidVisitStats):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
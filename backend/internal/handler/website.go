@@ -2,24 +2,28 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type WebsiteHandler struct {
-	service *service.WebsiteService
+	service        *service.WebsiteService
+	previewService *service.PreviewService
 }
 
-func NewWebsiteHandler(service *service.WebsiteService) *WebsiteHandler {
-	return &WebsiteHandler{service: service}
+func NewWebsiteHandler(service *service.WebsiteService, previewService *service.PreviewService) *WebsiteHandler {
+	return &WebsiteHandler{service: service, previewService: previewService}
 }
 
 // GetAll 获取所有网站
+// 可以用 ?tag=a&tag=b 只返回同时带有这些标签的网站
 func (h *WebsiteHandler) GetAll(c *gin.Context) {
-	websites, err := h.service.GetAll()
+	websites, err := h.service.GetAll(ownerID(c), c.QueryArray("tag"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -28,6 +32,32 @@ func (h *WebsiteHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": websites})
 }
 
+// Search 全文搜索网站
+// 支持 ?q= 关键词（按前缀匹配），?groupId= 和 ?tag= 过滤，?limit= 限制数量
+func (h *WebsiteHandler) Search(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.service.Search(ownerID(c), c.Query("q"), optionalQuery(c, "groupId"), c.QueryArray("tag"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSearchQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
 // Create 创建网站
 func (h *WebsiteHandler) Create(c *gin.Context) {
 	var req model.CreateWebsiteRequest
@@ -36,8 +66,12 @@ func (h *WebsiteHandler) Create(c *gin.Context) {
 		return
 	}
 
-	website, err := h.service.Create(&req)
+	website, err := h.service.Create(ownerID(c), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidIcon) || errors.Is(err, service.ErrInvalidTag) || errors.Is(err, service.ErrGroupNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -59,12 +93,16 @@ func (h *WebsiteHandler) Update(c *gin.Context) {
 		return
 	}
 
-	website, err := h.service.Update(id, &req)
+	website, err := h.service.Update(ownerID(c), id, &req)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Website not found"})
 			return
 		}
+		if errors.Is(err, service.ErrInvalidIcon) || errors.Is(err, service.ErrInvalidTag) || errors.Is(err, service.ErrGroupNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -80,9 +118,9 @@ func (h *WebsiteHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Delete(id)
+	err := h.service.Delete(ownerID(c), id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Website not found"})
 			return
 		}
@@ -90,7 +128,58 @@ func (h *WebsiteHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Website deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Website moved to trash"})
+}
+
+// GetTrash 获取回收站中的网站
+func (h *WebsiteHandler) GetTrash(c *gin.Context) {
+	websites, err := h.service.GetTrash(ownerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": websites})
+}
+
+// Restore 从回收站恢复网站
+func (h *WebsiteHandler) Restore(c *gin.Context) {
+	website, err := h.service.Restore(ownerID(c), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": website})
+}
+
+// Purge 永久删除回收站中的网站
+func (h *WebsiteHandler) Purge(c *gin.Context) {
+	if err := h.service.Purge(ownerID(c), c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Website deleted permanently"})
+}
+
+// EmptyTrash 清空回收站中的网站
+func (h *WebsiteHandler) EmptyTrash(c *gin.Context) {
+	deleted, err := h.service.EmptyTrash(ownerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"deleted": deleted}, "message": "Website trash emptied successfully"})
 }
 
 // Reorder 重新排序网站
@@ -101,11 +190,53 @@ func (h *WebsiteHandler) Reorder(c *gin.Context) {
 		return
 	}
 
-	websites, err := h.service.Reorder(&req)
+	websites, err := h.service.Reorder(ownerID(c), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": websites})
-} 
\ No newline at end of file
+}
+
+// RefreshIcon 立即重新发现网站图标
+func (h *WebsiteHandler) RefreshIcon(c *gin.Context) {
+	website, err := h.service.RefreshIcon(c.Request.Context(), ownerID(c), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrWebsiteNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Website not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": website})
+}
+
+// Preview 获取网页元数据，返回可直接用于创建网站的建议内容
+func (h *WebsiteHandler) Preview(c *gin.Context) {
+	preview, err := h.previewService.Preview(c.Request.Context(), ownerID(c), c.Query("url"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPreviewURL) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrPreviewFetch) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": preview})
+}
+
+// optionalQuery 返回非空的查询参数，不存在或为空时返回 nil
+func optionalQuery(c *gin.Context, key string) *string {
+	if value := c.Query(key); value != "" {
+		return &value
+	}
+	return nil
+}
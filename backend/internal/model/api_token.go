@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// APITokenPrefix API 令牌的前缀，用于和登录会话令牌区分
+const APITokenPrefix = "nvp_"
+
+// TokenScope API 令牌的权限范围
+type TokenScope string
+
+const (
+	// ScopeWebsitesRead 读取网站和分组
+	ScopeWebsitesRead TokenScope = "websites:read"
+	// ScopeWebsitesWrite 创建、修改、删除网站和分组
+	ScopeWebsitesWrite TokenScope = "websites:write"
+	// ScopeSearchEnginesRead 读取搜索引擎
+	ScopeSearchEnginesRead TokenScope = "search-engines:read"
+	// ScopeSearchEnginesWrite 创建、修改、删除搜索引擎
+	ScopeSearchEnginesWrite TokenScope = "search-engines:write"
+	// ScopeSettingsRead 读取设置
+	ScopeSettingsRead TokenScope = "settings:read"
+	// ScopeSettingsWrite 修改设置
+	ScopeSettingsWrite TokenScope = "settings:write"
+	// ScopeDataRead 导出数据
+	ScopeDataRead TokenScope = "data:read"
+	// ScopeDataWrite 导入数据
+	ScopeDataWrite TokenScope = "data:write"
+	// ScopeAdmin 账号管理和备份，仅管理员可以创建
+	ScopeAdmin TokenScope = "admin"
+)
+
+// TokenScopes 所有可用的权限范围
+var TokenScopes = []TokenScope{
+	ScopeWebsitesRead, ScopeWebsitesWrite,
+	ScopeSearchEnginesRead, ScopeSearchEnginesWrite,
+	ScopeSettingsRead, ScopeSettingsWrite,
+	ScopeDataRead, ScopeDataWrite,
+	ScopeAdmin,
+}
+
+// APIToken 个人 API 令牌，数据库中只保存令牌摘要
+type APIToken struct {
+	ID         string       `json:"id" db:"id"`
+	UserID     string       `json:"-" db:"user_id"`
+	Name       string       `json:"name" db:"name"`
+	TokenHash  string       `json:"-" db:"token_hash"`
+	Scopes     []TokenScope `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time   `json:"expiresAt" db:"expires_at"`
+	LastUsedAt *time.Time   `json:"lastUsedAt" db:"last_used_at"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+}
+
+// Expired 令牌是否已过期
+func (t *APIToken) Expired() bool {
+	return t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now())
+}
+
+// CreateAPITokenRequest 创建 API 令牌请求，expiresAt 为空表示永不过期
+type CreateAPITokenRequest struct {
+	Name      string       `json:"name" binding:"required"`
+	Scopes    []TokenScope `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time   `json:"expiresAt"`
+}
+
+// CreateAPITokenResponse 创建结果，明文令牌只在此时返回一次
+type CreateAPITokenResponse struct {
+	Token string    `json:"token"`
+	Info  *APIToken `json:"info"`
+}
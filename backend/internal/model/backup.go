@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// BackupKind 备份类型
+type BackupKind string
+
+const (
+	// BackupKindScheduled 定时自动备份
+	BackupKindScheduled BackupKind = "scheduled"
+	// BackupKindManual 手动备份
+	BackupKindManual BackupKind = "manual"
+	// BackupKindPreRestore 恢复前自动生成的安全快照
+	BackupKindPreRestore BackupKind = "pre-restore"
+)
+
+// Backup 数据库备份文件
+type Backup struct {
+	Name      string     `json:"name"`
+	Kind      BackupKind `json:"kind"`
+	Size      int64      `json:"size"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
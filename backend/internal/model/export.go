@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// ExportFormatVersion 当前导出格式版本
+//
+// 版本历史：
+//   - 1：无 formatVersion 字段，仅包含 websites、searchEngines 和原始的 app_settings 行
+//   - 2：增加版本信封、分组以及类型化的设置
+//   - 3：网站的 category 改为 tags，增加标签列表
+//   - 4：增加搜索引擎分类，搜索引擎增加 keyword、method、body、sortOrder 和 categoryId
+const ExportFormatVersion = 4
+
+// ExportData 导出数据结构（带版本信息的备份信封）
+type ExportData struct {
+	FormatVersion          int                    `json:"formatVersion"`
+	ExportedAt             time.Time              `json:"exportedAt"`
+	AppVersion             string                 `json:"appVersion"`
+	Groups                 []Group                `json:"groups"`
+	Tags                   []Tag                  `json:"tags"`
+	Websites               []Website              `json:"websites"`
+	SearchEngineCategories []SearchEngineCategory `json:"searchEngineCategories"`
+	SearchEngines          []SearchEngine         `json:"searchEngines"`
+	Settings               *ExportSettings        `json:"settings"`
+}
+
+// ImportData 导入数据结构，旧版本格式会在解析时升级为当前格式
+type ImportData = ExportData
+
+// ExportSettings 导出的类型化设置，导入时为 nil 的字段保留当前值
+type ExportSettings struct {
+	Theme      string             `json:"theme,omitempty"`
+	Layout     *LayoutConfig      `json:"layout,omitempty"`
+	Background *BackgroundConfig  `json:"background,omitempty"`
+	CardStyle  *CardStyleConfig   `json:"cardStyle,omitempty"`
+	Search     *SearchPreferences `json:"search,omitempty"`
+}
+
+// SearchPreferences 搜索偏好（不含搜索引擎列表，搜索引擎单独导出）
+type SearchPreferences struct {
+	Enabled         bool   `json:"enabled"`
+	DefaultEngineId string `json:"defaultEngineId"`
+	OpenInNewTab    bool   `json:"openInNewTab"`
+}
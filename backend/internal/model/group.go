@@ -8,7 +8,8 @@ type Group struct {
 	Name        string    `json:"name" db:"name"`
 	Color       *string   `json:"color" db:"color"`
 	Icon        *string   `json:"icon" db:"icon"`
-	SortOrder   int       `json:"sortOrder" db:"sort_order"`
+	ParentId    *string   `json:"parentId" db:"parent_id"`   // null表示顶层分组
+	SortOrder   int       `json:"sortOrder" db:"sort_order"` // 在同一父分组内的排序
 	IsCollapsed bool      `json:"isCollapsed" db:"is_collapsed"`
 	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
@@ -16,9 +17,10 @@ type Group struct {
 
 // CreateGroupRequest 创建分组请求
 type CreateGroupRequest struct {
-	Name  string  `json:"name" binding:"required"`
-	Color *string `json:"color"`
-	Icon  *string `json:"icon"`
+	Name     string  `json:"name" binding:"required"`
+	Color    *string `json:"color"`
+	Icon     *string `json:"icon"`
+	ParentId *string `json:"parentId"` // null表示创建顶层分组
 }
 
 // UpdateGroupRequest 更新分组请求
@@ -29,7 +31,23 @@ type UpdateGroupRequest struct {
 	IsCollapsed *bool   `json:"isCollapsed"`
 }
 
-// ReorderGroupsRequest 重新排序分组请求
+// MoveGroupRequest 移动分组请求，可以同时修改父分组和在父分组中的位置
+type MoveGroupRequest struct {
+	ParentId *string `json:"parentId"` // null表示移到顶层
+	Position *int    `json:"position"` // 在父分组中的位置，为空时放到最后
+}
+
+// GroupDeleteMode 删除分组时子分组的处理方式
+type GroupDeleteMode string
+
+const (
+	// GroupDeletePromote 子分组提升到被删除分组所在的层级，占据它原来的位置
+	GroupDeletePromote GroupDeleteMode = "promote"
+	// GroupDeleteCascade 子分组随之删除
+	GroupDeleteCascade GroupDeleteMode = "cascade"
+)
+
+// ReorderGroupsRequest 重新排序分组请求，分组必须属于同一父分组
 type ReorderGroupsRequest struct {
 	GroupIds []string `json:"groupIds" binding:"required"`
 }
@@ -45,4 +63,10 @@ type MoveWebsiteToGroupRequest struct {
 type GroupWithWebsites struct {
 	*Group
 	Websites []Website `json:"websites"`
-}
\ No newline at end of file
+}
+
+// GroupTreeNode 分组树的节点，包含分组的网站和子分组
+type GroupTreeNode struct {
+	GroupWithWebsites
+	Children []GroupTreeNode `json:"children"`
+}
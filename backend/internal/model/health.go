@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// WebsiteHealth 网站链接最近一次健康检查的结果，尚未检查时 CheckedAt 为空
+type WebsiteHealth struct {
+	WebsiteID  string     `json:"websiteId" db:"website_id"`
+	Name       string     `json:"name" db:"name"`
+	URL        string     `json:"url" db:"url"`
+	StatusCode *int       `json:"statusCode" db:"status_code"`
+	LatencyMs  *int64     `json:"latencyMs" db:"latency_ms"`
+	FinalURL   *string    `json:"finalUrl" db:"final_url"`
+	Error      *string    `json:"error" db:"error"`
+	CheckedAt  *time.Time `json:"checkedAt" db:"checked_at"`
+	Healthy    *bool      `json:"healthy"` // 尚未检查时为空
+}
+
+// IsHealthy 请求成功且最终响应为 2xx 或 3xx 视为健康
+func (h *WebsiteHealth) IsHealthy() bool {
+	return h.CheckedAt != nil && h.Error == nil && h.StatusCode != nil && *h.StatusCode >= 200 && *h.StatusCode < 400
+}
+
+// SetHealthy 根据检查结果设置 Healthy，尚未检查时保持为空
+func (h *WebsiteHealth) SetHealthy() {
+	if h.CheckedAt == nil {
+		h.Healthy = nil
+		return
+	}
+	healthy := h.IsHealthy()
+	h.Healthy = &healthy
+}
+
+// HealthTarget 待检查的网站
+type HealthTarget struct {
+	ID  string
+	URL string
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// IconURLPrefix 本地图标的访问路径前缀
+const IconURLPrefix = "/api/icons/"
+
+// IconSource 网站图标的来源
+type IconSource string
+
+const (
+	// IconSourceAuto 从网站自动发现并缓存的图标
+	IconSourceAuto IconSource = "auto"
+	// IconSourceFallback 自动发现失败时生成的占位图标，定时刷新时会重新尝试
+	IconSourceFallback IconSource = "fallback"
+)
+
+// Icon 本地缓存的图标，以内容的 SHA-256 作为标识
+type Icon struct {
+	Hash        string    `json:"hash" db:"hash"`
+	ContentType string    `json:"contentType" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	Data        []byte    `json:"-" db:"data"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+
+	// Variants 标准化时生成的较小尺寸，按尺寸从小到大排列
+	Variants []IconVariant `json:"-" db:"-"`
+}
+
+// IconVariant 图标的缩略图，通过 /api/icons/<hash>?size=<px> 访问
+type IconVariant struct {
+	Size        int    `json:"size" db:"size"`
+	ContentType string `json:"contentType" db:"content_type"`
+	Data        []byte `json:"-" db:"data"`
+}
+
+// UploadIconResponse 上传图标的结果，url 可以直接作为网站、分组或搜索引擎的 icon
+type UploadIconResponse struct {
+	Hash        string `json:"hash"`
+	URL         string `json:"url"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Sizes       []int  `json:"sizes"` // 可通过 ?size= 访问的缩略图尺寸
+}
+
+// NewIcon 根据内容创建图标，相同内容得到相同的哈希
+func NewIcon(data []byte, contentType string) *Icon {
+	sum := sha256.Sum256(data)
+	return &Icon{
+		Hash:        hex.EncodeToString(sum[:]),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Data:        data,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// URL 图标的访问地址，网站、分组和搜索引擎的 icon 字段以此引用图标库中的图标
+func (i *Icon) URL() string {
+	return IconURLPrefix + i.Hash
+}
+
+// ETag 图标的强 ETag，内容不变哈希就不变
+func (i *Icon) ETag() string {
+	return `"` + i.Hash + `"`
+}
+
+// DataURL 以 DataURL 形式内联图标，用于导出可以独立使用的数据
+func (i *Icon) DataURL() string {
+	return "data:" + i.ContentType + ";base64," + base64.StdEncoding.EncodeToString(i.Data)
+}
+
+// IconHashFromRef 从 /api/icons/<hash> 形式的引用中取出图标哈希
+func IconHashFromRef(ref string) (string, bool) {
+	hash, ok := strings.CutPrefix(ref, IconURLPrefix)
+	if !ok || len(hash) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+	return hash, true
+}
+
+// IsDataURL 是否为 DataURL
+func IsDataURL(s string) bool {
+	return len(s) >= 5 && strings.EqualFold(s[:5], "data:")
+}
+
+// ParseDataURL 解析 DataURL，返回声明的内容类型和数据
+func ParseDataURL(s string) (string, []byte, error) {
+	if !IsDataURL(s) {
+		return "", nil, errors.New("not a data url")
+	}
+
+	meta, payload, ok := strings.Cut(s[5:], ",")
+	if !ok {
+		return "", nil, errors.New("malformed data url")
+	}
+
+	params := strings.Split(meta, ";")
+	contentType := strings.ToLower(strings.TrimSpace(params[0]))
+	isBase64 := false
+	for _, param := range params[1:] {
+		if strings.EqualFold(strings.TrimSpace(param), "base64") {
+			isBase64 = true
+		}
+	}
+
+	if !isBase64 {
+		data, err := url.PathUnescape(payload)
+		if err != nil {
+			return "", nil, err
+		}
+		return contentType, []byte(data), nil
+	}
+
+	// 旧数据中可能混有换行或省略了填充
+	payload = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, payload)
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		if data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "=")); err != nil {
+			return "", nil, err
+		}
+	}
+	return contentType, data, nil
+}
+
+// IconTarget 需要发现图标的网站
+type IconTarget struct {
+	ID         string
+	Name       string
+	URL        string
+	Icon       *string
+	IconSource *IconSource
+}
+
+// AutoIcon 图标是否由服务端管理，用户指定的图标不会被自动发现覆盖
+func (t *IconTarget) AutoIcon() bool {
+	return t.Icon == nil || *t.Icon == "" || t.IconSource != nil
+}
@@ -0,0 +1,65 @@
+package model
+
+// ImportMode 导入模式
+type ImportMode string
+
+const (
+	// ImportModeReplace 清空现有数据后导入
+	ImportModeReplace ImportMode = "replace"
+	// ImportModeMerge 按ID或规范化URL与现有数据合并
+	ImportModeMerge ImportMode = "merge"
+	// ImportModeDryRun 仅预览导入结果，不写入数据库
+	ImportModeDryRun ImportMode = "dry-run"
+)
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Mode          ImportMode `json:"mode"`          // 实际生效的导入策略（replace 或 merge）
+	DryRun        bool       `json:"dryRun"`        // 为true时在事务结束后回滚
+	FetchMetadata bool       `json:"fetchMetadata"` // 为true时先获取网页元数据，补全缺少的网站名称和描述
+}
+
+// ImportItem 单条导入记录的处理结果
+type ImportItem struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ImportEntityReport 某类实体的导入结果
+type ImportEntityReport struct {
+	Created  []ImportItem `json:"created"`
+	Updated  []ImportItem `json:"updated"`
+	Skipped  []ImportItem `json:"skipped"`
+	Rejected []ImportItem `json:"rejected"`
+}
+
+// ImportReport 导入报告
+type ImportReport struct {
+	Mode                   ImportMode         `json:"mode"`
+	DryRun                 bool               `json:"dryRun"`
+	Committed              bool               `json:"committed"`
+	MetadataFilled         int                `json:"metadataFilled"`   // 通过获取网页元数据补全了名称或描述的网站数量
+	MetadataUnfilled       []ImportItem       `json:"metadataUnfilled"` // 需要补全但未能补全的网站及原因
+	Groups                 ImportEntityReport `json:"groups"`
+	Tags                   ImportEntityReport `json:"tags"`
+	Websites               ImportEntityReport `json:"websites"`
+	SearchEngineCategories ImportEntityReport `json:"searchEngineCategories"`
+	SearchEngines          ImportEntityReport `json:"searchEngines"`
+	Settings               ImportEntityReport `json:"settings"`
+}
+
+// NewImportEntityReport 创建各列表均已初始化的实体报告，保证JSON中输出空数组而不是null
+func NewImportEntityReport() ImportEntityReport {
+	return ImportEntityReport{
+		Created:  []ImportItem{},
+		Updated:  []ImportItem{},
+		Skipped:  []ImportItem{},
+		Rejected: []ImportItem{},
+	}
+}
+
+// HasRejected 判断是否存在被拒绝的记录
+func (r *ImportReport) HasRejected() bool {
+	return len(r.Groups.Rejected) > 0 || len(r.Tags.Rejected) > 0 || len(r.Websites.Rejected) > 0 || len(r.SearchEngineCategories.Rejected) > 0 || len(r.SearchEngines.Rejected) > 0 || len(r.Settings.Rejected) > 0
+}
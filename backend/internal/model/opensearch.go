@@ -0,0 +1,38 @@
+package model
+
+import "encoding/xml"
+
+// OpenSearchDescription OpenSearch 描述文档，浏览器据此提供“添加为搜索引擎”
+type OpenSearchDescription struct {
+	XMLName       xml.Name         `xml:"OpenSearchDescription"`
+	Xmlns         string           `xml:"xmlns,attr"`
+	XmlnsMoz      string           `xml:"xmlns:moz,attr"`
+	ShortName     string           `xml:"ShortName"`
+	Description   string           `xml:"Description"`
+	InputEncoding string           `xml:"InputEncoding"`
+	Image         *OpenSearchImage `xml:"Image,omitempty"`
+	URLs          []OpenSearchURL  `xml:"Url"`
+	SearchForm    string           `xml:"moz:SearchForm"`
+}
+
+// OpenSearchImage 搜索引擎图标
+type OpenSearchImage struct {
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+	URL    string `xml:",chardata"`
+}
+
+// OpenSearchURL 搜索地址模板，{searchTerms} 由浏览器替换为搜索内容
+type OpenSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Method   string `xml:"method,attr,omitempty"`
+	Rel      string `xml:"rel,attr,omitempty"`
+	Template string `xml:"template,attr"`
+}
+
+// SearchCompletion 地址栏搜索建议中的一项
+type SearchCompletion struct {
+	Text        string // 建议的搜索内容
+	Description string // 建议的来源，如网站地址、分组、搜索历史
+	URL         string // 直接打开的地址
+}
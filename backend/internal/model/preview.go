@@ -0,0 +1,21 @@
+package model
+
+// PageMetadata 从网页 <head> 中解析出的元数据
+type PageMetadata struct {
+	URL          string            `json:"url"`          // 跟随重定向后的最终地址
+	Title        string            `json:"title"`        // <title> 的内容
+	Description  string            `json:"description"`  // <meta name="description">
+	Keywords     []string          `json:"keywords"`     // <meta name="keywords">
+	CanonicalURL string            `json:"canonicalUrl"` // <link rel="canonical">
+	SiteName     string            `json:"siteName"`     // og:site_name 或 application-name
+	OpenGraph    map[string]string `json:"openGraph"`    // og:* 字段，键名不带 og: 前缀
+	Twitter      map[string]string `json:"twitter"`      // twitter:* 字段，键名不带 twitter: 前缀
+}
+
+// WebsitePreview 网站预览结果
+// Website 可以直接作为创建网站的请求体，其中 icon 为已保存到本地的图标，groupId 为建议的分组
+type WebsitePreview struct {
+	Website        CreateWebsiteRequest `json:"website"`
+	Metadata       PageMetadata         `json:"metadata"`
+	SuggestedGroup *Group               `json:"suggestedGroup"`
+}
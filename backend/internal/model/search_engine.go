@@ -8,6 +8,11 @@ type SearchEngine struct {
 	Icon        *string `json:"icon,omitempty" db:"icon"`        // 直接存储DataURL格式
 	Placeholder *string `json:"placeholder" db:"placeholder"`
 	IsDefault   bool    `json:"isDefault" db:"is_default"`
+	Keyword     *string `json:"keyword" db:"keyword"`            // 快捷关键词，如 g、gh，/search?q=!gh foo 使用该引擎搜索
+	Method      string  `json:"method" db:"method"`              // 请求方法，GET 或 POST
+	Body        *string `json:"body" db:"body"`                  // POST 表单模板，如 q={query}&src=nav
+	SortOrder   int     `json:"sortOrder" db:"sort_order"`       // 显示顺序，从小到大
+	CategoryId  *string `json:"categoryId" db:"category_id"`     // null表示未分类
 }
 
 
@@ -16,10 +21,14 @@ type SearchEngine struct {
 type CreateSearchEngineRequest struct {
 	ID          string  `json:"id" binding:"required"`
 	Name        string  `json:"name" binding:"required"`
-	URL         string  `json:"url" binding:"required,url"`
+	URL         string  `json:"url" binding:"required"`          // 地址模板，由服务端校验
 	Icon        *string `json:"icon,omitempty"`                  // DataURL格式图标
 	Placeholder *string `json:"placeholder"`
 	IsDefault   *bool   `json:"isDefault"`
+	Keyword     *string `json:"keyword"`
+	Method      *string `json:"method"`                          // 默认 GET
+	Body        *string `json:"body"`
+	CategoryId  *string `json:"categoryId"`                      // 新建的搜索引擎排在最后
 }
 
 // UpdateSearchEngineRequest 更新搜索引擎请求
@@ -29,4 +38,43 @@ type UpdateSearchEngineRequest struct {
 	Icon        *string `json:"icon,omitempty"`          // DataURL格式图标
 	Placeholder *string `json:"placeholder"`
 	IsDefault   *bool   `json:"isDefault"`
-} 
\ No newline at end of file
+	Keyword     *string `json:"keyword"`                 // 空字符串表示清除关键词
+	Method      *string `json:"method"`
+	Body        *string `json:"body"`                    // 空字符串表示清除表单模板
+	CategoryId  *string `json:"categoryId"`              // 空字符串表示移出分类
+}
+
+// ReorderSearchEnginesRequest 重新排序搜索引擎请求
+// 列出的搜索引擎按给定顺序占据它们原来的位置，未列出的保持不动，可以只对同一分类中的引擎排序
+type ReorderSearchEnginesRequest struct {
+	EngineIds []string `json:"engineIds" binding:"required"`
+}
+
+// SearchRoute 搜索内容的解析结果
+type SearchRoute struct {
+	Engine  *SearchEngine  `json:"engine"`
+	Query   string         `json:"query"`   // 去掉关键词后的搜索内容
+	Request ResolvedSearch `json:"request"` // 最终的搜索请求
+}
+
+// ResolveSearchRequest 生成搜索请求的参数
+type ResolveSearchRequest struct {
+	Query string `json:"query" binding:"required"`
+	Lang  string `json:"lang"` // 替换 {lang}，为空时使用 en
+}
+
+// ResolvedSearch 按地址模板生成的最终搜索请求
+type ResolvedSearch struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	Encoding    string            `json:"encoding"`              // 搜索内容使用的字符编码
+	ContentType string            `json:"contentType,omitempty"` // POST 时为 application/x-www-form-urlencoded
+	Body        string            `json:"body,omitempty"`        // POST 时为编码后的表单
+	Fields      []SearchFormField `json:"fields,omitempty"`      // POST 时未编码的表单字段，可用于在浏览器中提交表单
+}
+
+// SearchFormField POST 表单字段
+type SearchFormField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// SearchEngineCategory 搜索引擎分类，如 Code、Docs、General
+type SearchEngineCategory struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	SortOrder int       `json:"sortOrder" db:"sort_order"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// CreateSearchEngineCategoryRequest 创建搜索引擎分类请求，新分类排在最后
+type CreateSearchEngineCategoryRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpdateSearchEngineCategoryRequest 更新搜索引擎分类请求
+type UpdateSearchEngineCategoryRequest struct {
+	Name *string `json:"name"`
+}
+
+// ReorderSearchEngineCategoriesRequest 重新排序搜索引擎分类请求
+type ReorderSearchEngineCategoriesRequest struct {
+	CategoryIds []string `json:"categoryIds" binding:"required"`
+}
+
+// SearchEngineCategoryWithEngines 带搜索引擎的分类
+type SearchEngineCategoryWithEngines struct {
+	SearchEngineCategory
+	Engines []SearchEngine `json:"engines"`
+}
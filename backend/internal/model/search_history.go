@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// SearchHistoryEntry 一次搜索记录
+type SearchHistoryEntry struct {
+	ID         string    `json:"id" db:"id"`
+	Query      string    `json:"query" db:"query"`
+	EngineId   *string   `json:"engineId" db:"engine_id"`
+	SearchedAt time.Time `json:"searchedAt" db:"searched_at"`
+}
+
+// SearchSuggestion 根据搜索历史给出的建议，相同查询（忽略大小写和多余空白）合并统计
+type SearchSuggestion struct {
+	Query          string    `json:"query"`
+	Count          int       `json:"count"`
+	LastSearchedAt time.Time `json:"lastSearchedAt"`
+}
+
+// SearchHistorySettings 搜索历史的隐私设置
+type SearchHistorySettings struct {
+	Enabled         bool     `json:"enabled"`
+	RetentionDays   int      `json:"retentionDays"`   // 为0时永久保留
+	ExcludePatterns []string `json:"excludePatterns"` // 匹配任一正则表达式（忽略大小写）的查询不记录
+}
+
+// RecordSearchRequest 记录搜索请求
+type RecordSearchRequest struct {
+	Query    string  `json:"query" binding:"required"`
+	EngineId *string `json:"engineId"`
+}
+
+// UpdateSearchHistorySettingsRequest 更新搜索历史设置请求
+type UpdateSearchHistorySettingsRequest struct {
+	Enabled         *bool     `json:"enabled"`
+	RetentionDays   *int      `json:"retentionDays"`
+	ExcludePatterns *[]string `json:"excludePatterns"`
+}
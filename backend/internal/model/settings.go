@@ -4,7 +4,7 @@ import "time"
 
 // AppSettings 应用设置模型（数据库存储格式）
 type AppSettings struct {
-	ID            int       `json:"id" db:"id"`
+	UserID        string    `json:"userId" db:"user_id"`
 	Theme         string    `json:"theme" db:"theme"`
 	LayoutConfig  string    `json:"layout" db:"layout_config"`
 	BackgroundConfig string `json:"background" db:"background_config"`
@@ -36,10 +36,12 @@ type CardStyleConfig struct {
 
 // SearchConfig 搜索配置
 type SearchConfig struct {
-	Enabled         bool            `json:"enabled"`
-	DefaultEngineId string          `json:"defaultEngineId"`
-	OpenInNewTab    bool            `json:"openInNewTab"`
-	Engines         []SearchEngine  `json:"engines"`
+	Enabled         bool                              `json:"enabled"`
+	DefaultEngineId string                            `json:"defaultEngineId"`
+	OpenInNewTab    bool                              `json:"openInNewTab"`
+	Engines         []SearchEngine                    `json:"engines"`
+	Categories      []SearchEngineCategoryWithEngines `json:"categories"`    // 按分类排列的搜索引擎
+	Uncategorized   []SearchEngine                    `json:"uncategorized"` // 不属于任何分类的搜索引擎
 }
 
 // AppSettingsResponse 应用设置响应格式（给前端的格式）
@@ -60,17 +62,3 @@ type UpdateSettingsRequest struct {
 	CardStyle    *interface{} `json:"cardStyle"`
 	Search       *interface{} `json:"search"`
 }
-
-// ExportData 导出数据结构
-type ExportData struct {
-	Websites      []Website      `json:"websites"`
-	SearchEngines []SearchEngine `json:"searchEngines"`
-	Settings      *AppSettings   `json:"settings"`
-}
-
-// ImportData 导入数据结构
-type ImportData struct {
-	Websites      []Website      `json:"websites"`
-	SearchEngines []SearchEngine `json:"searchEngines"`
-	Settings      *AppSettings   `json:"settings"`
-} 
\ No newline at end of file
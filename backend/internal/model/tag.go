@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Tag 标签模型，同一账号内名称忽略大小写唯一
+type Tag struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Color     *string   `json:"color" db:"color"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// TagWithCount 带网站数量的标签
+type TagWithCount struct {
+	Tag
+	WebsiteCount int `json:"websiteCount"`
+}
+
+// CreateTagRequest 创建标签请求
+type CreateTagRequest struct {
+	Name  string  `json:"name" binding:"required"`
+	Color *string `json:"color"`
+}
+
+// UpdateTagRequest 更新标签请求，修改名称即重命名
+type UpdateTagRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+// MergeTagsRequest 合并标签请求，源标签的网站转移到目标标签后删除源标签
+type MergeTagsRequest struct {
+	SourceIds []string `json:"sourceIds" binding:"required,min=1"`
+	TargetId  string   `json:"targetId" binding:"required"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// 回收站只列出被直接删除的条目，随分组一起删除的网站和子分组归属于该分组，恢复或永久删除该分组时一起处理
+
+// TrashedWebsite 回收站中的网站，GroupId 和 SortOrder 为删除前的位置，恢复时尽量回到原处
+type TrashedWebsite struct {
+	Website
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// TrashedGroup 回收站中的分组，ParentId 和 SortOrder 为删除前的位置
+type TrashedGroup struct {
+	Group
+	DeletedAt     time.Time `json:"deletedAt"`
+	WebsiteCount  int       `json:"websiteCount"`  // 随分组一起删除的网站数量，包括子孙分组中的网站
+	SubgroupCount int       `json:"subgroupCount"` // 随分组一起删除的子孙分组数量
+}
+
+// TrashedSearchEngine 回收站中的搜索引擎，SortOrder 为删除前的位置
+type TrashedSearchEngine struct {
+	SearchEngine
+	DeletedAt time.Time `json:"deletedAt"`
+}
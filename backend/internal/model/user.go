@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// UserRole 账号角色
+type UserRole string
+
+const (
+	// UserRoleAdmin 管理员，可以管理账号和备份
+	UserRoleAdmin UserRole = "admin"
+	// UserRoleUser 普通账号，只能管理自己的面板
+	UserRoleUser UserRole = "user"
+)
+
+// User 账号，每个账号拥有独立的网站、分组、搜索引擎和设置
+type User struct {
+	ID           string    `json:"id" db:"id"`
+	Username     string    `json:"username" db:"username"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         UserRole  `json:"role" db:"role"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Session 登录会话，数据库中只保存令牌摘要
+type Session struct {
+	TokenHash string    `json:"-" db:"token_hash"`
+	UserID    string    `json:"userId" db:"user_id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResponse 登录结果，token 也会以 HttpOnly Cookie 的形式下发
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      *User     `json:"user"`
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+// IsAdmin 是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == UserRoleAdmin
+}
+
+// CreateUserRequest 创建账号请求
+type CreateUserRequest struct {
+	Username string   `json:"username" binding:"required"`
+	Password string   `json:"password" binding:"required,min=8"`
+	Role     UserRole `json:"role"`
+}
+
+// UpdateUserRequest 更新账号请求，修改密码后该账号的所有会话失效
+type UpdateUserRequest struct {
+	Password *string   `json:"password" binding:"omitempty,min=8"`
+	Role     *UserRole `json:"role"`
+}
+
+// AuthStatus 当前认证状态
+type AuthStatus struct {
+	Enabled       bool  `json:"enabled"`
+	PublicRead    bool  `json:"publicRead"`
+	Authenticated bool  `json:"authenticated"`
+	User          *User `json:"user,omitempty"`
+}
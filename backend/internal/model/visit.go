@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// WebsiteVisitStat 网站在统计区间内的访问次数
+type WebsiteVisitStat struct {
+	Website       Website    `json:"website"`
+	Visits        int        `json:"visits"`
+	LastVisitedAt *time.Time `json:"lastVisitedAt"`
+}
+
+// DailyVisits 某一天的访问次数，Date 格式为 YYYY-MM-DD
+type DailyVisits struct {
+	Date   string `json:"date"`
+	Visits int    `json:"visits"`
+}
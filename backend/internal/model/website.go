@@ -9,7 +9,7 @@ type Website struct {
 	URL         string    `json:"url" db:"url"`
 	Icon        *string   `json:"icon" db:"icon"`
 	Description *string   `json:"description" db:"description"`
-	Category    *string   `json:"category" db:"category"`
+	Tags        []Tag     `json:"tags"`
 	GroupId     *string   `json:"groupId" db:"group_id"`
 	SortOrder   int       `json:"sortOrder" db:"sort_order"`
 	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
@@ -18,25 +18,49 @@ type Website struct {
 
 // CreateWebsiteRequest 创建网站请求
 type CreateWebsiteRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	URL         string  `json:"url" binding:"required,url"`
-	Icon        *string `json:"icon"`
-	Description *string `json:"description"`
-	Category    *string `json:"category"`
-	GroupId     *string `json:"groupId"`
+	Name        string   `json:"name" binding:"required"`
+	URL         string   `json:"url" binding:"required,url"`
+	Icon        *string  `json:"icon"`
+	Description *string  `json:"description"`
+	Tags        []string `json:"tags"` // 标签名称，不存在的标签会自动创建
+	GroupId     *string  `json:"groupId"`
 }
 
 // UpdateWebsiteRequest 更新网站请求
 type UpdateWebsiteRequest struct {
-	Name        *string `json:"name"`
-	URL         *string `json:"url"`
-	Icon        *string `json:"icon"`
-	Description *string `json:"description"`
-	Category    *string `json:"category"`
-	GroupId     *string `json:"groupId"`
+	Name        *string   `json:"name"`
+	URL         *string   `json:"url"`
+	Icon        *string   `json:"icon"`
+	Description *string   `json:"description"`
+	Tags        *[]string `json:"tags"` // 为 nil 时保持不变，空数组清除所有标签
+	GroupId     *string   `json:"groupId"`
 }
 
 // ReorderWebsitesRequest 重新排序网站请求
 type ReorderWebsitesRequest struct {
 	WebsiteIds []string `json:"websiteIds" binding:"required"`
-} 
\ No newline at end of file
+} 
+
+// WebsiteSearchQuery 网站全文搜索条件
+type WebsiteSearchQuery struct {
+	Match   string   // FTS5 查询表达式
+	GroupId *string  // 只搜索指定分组
+	Tags    []string // 只搜索同时带有这些标签的网站
+	Limit   int
+}
+
+// WebsiteSearchResult 网站搜索结果，按相关度排序
+type WebsiteSearchResult struct {
+	Website
+	Highlights WebsiteHighlights `json:"highlights"`
+	Rank       float64           `json:"rank"` // bm25 得分，越小越相关
+}
+
+// WebsiteHighlights 搜索结果中匹配词用 <mark> 标记的字段，其余内容已做 HTML 转义
+// description 为截取的片段，其他字段为完整内容
+type WebsiteHighlights struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	Tags        string `json:"tags"` // 以空格连接的标签名称
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"nav-panel-backend/internal/model"
+	"time"
+)
+
+type APITokenRepository struct {
+	db *sql.DB
+}
+
+func NewAPITokenRepository(db *sql.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// GetAll 获取账号的所有 API 令牌
+func (r *APITokenRepository) GetAll(userID string) ([]model.APIToken, error) {
+	query := `SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at
+	          FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []model.APIToken
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+
+	return tokens, rows.Err()
+}
+
+// GetByHash 根据令牌摘要获取 API 令牌
+func (r *APITokenRepository) GetByHash(tokenHash string) (*model.APIToken, error) {
+	query := `SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at
+	          FROM api_tokens WHERE token_hash = ?`
+
+	return scanAPIToken(r.db.QueryRow(query, tokenHash))
+}
+
+// Create 保存 API 令牌
+func (r *APITokenRepository) Create(token *model.APIToken) error {
+	scopes, err := json.Marshal(token.Scopes)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO api_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err = r.db.Exec(query, token.ID, token.UserID, token.Name, token.TokenHash, string(scopes), token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+// TouchLastUsed 记录令牌的最近使用时间
+func (r *APITokenRepository) TouchLastUsed(id string, at time.Time) error {
+	_, err := r.db.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", at, id)
+	return err
+}
+
+// Delete 吊销账号的 API 令牌，返回是否删除了记录
+func (r *APITokenRepository) Delete(userID, id string) (bool, error) {
+	result, err := r.db.Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// rowScanner 抽象 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIToken(row rowScanner) (*model.APIToken, error) {
+	var t model.APIToken
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &scopes, &expiresAt, &lastUsedAt, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &t.Scopes); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		t.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		t.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &t, nil
+}
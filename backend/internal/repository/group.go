@@ -21,11 +21,11 @@ func NewGroupRepository(db *sql.DB) *GroupRepository {
 }
 
 // GetAll 获取所有分组
-func (r *GroupRepository) GetAll() ([]model.Group, error) {
-	query := `SELECT id, name, color, icon, sort_order, is_collapsed, created_at, updated_at 
-	          FROM groups ORDER BY sort_order ASC`
+func (r *GroupRepository) GetAll(userID string) ([]model.Group, error) {
+	query := `SELECT id, name, color, icon, parent_id, sort_order, is_collapsed, created_at, updated_at 
+	          FROM groups WHERE user_id = ? AND deleted_at IS NULL ORDER BY sort_order ASC`
 	
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +34,7 @@ func (r *GroupRepository) GetAll() ([]model.Group, error) {
 	var groups []model.Group
 	for rows.Next() {
 		var g model.Group
-		err := rows.Scan(&g.ID, &g.Name, &g.Color, &g.Icon, &g.SortOrder, &g.IsCollapsed, &g.CreatedAt, &g.UpdatedAt)
+		err := rows.Scan(&g.ID, &g.Name, &g.Color, &g.Icon, &g.ParentId, &g.SortOrder, &g.IsCollapsed, &g.CreatedAt, &g.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -45,24 +45,25 @@ func (r *GroupRepository) GetAll() ([]model.Group, error) {
 }
 
 // GetAllWithWebsites 获取所有分组及其网站
-func (r *GroupRepository) GetAllWithWebsites() ([]model.GroupWithWebsites, error) {
+func (r *GroupRepository) GetAllWithWebsites(userID string) ([]model.GroupWithWebsites, error) {
 	// 首先获取所有分组
-	groups, err := r.GetAll()
+	groups, err := r.GetAll(userID)
 	if err != nil {
 		return nil, err
 	}
 
 	var result []model.GroupWithWebsites
 	for _, group := range groups {
+		group := group
 		groupWithWebsites := model.GroupWithWebsites{
 			Group: &group,
 		}
 
 		// 获取该分组下的所有网站
 		query := `SELECT id, name, url, description, icon, sort_order, group_id, created_at, updated_at 
-		          FROM websites WHERE group_id = ? ORDER BY sort_order ASC`
+		          FROM websites WHERE group_id = ? AND user_id = ? AND deleted_at IS NULL ORDER BY sort_order ASC`
 		
-		rows, err := r.db.Query(query, group.ID)
+		rows, err := r.db.Query(query, group.ID, userID)
 		if err != nil {
 			return nil, err
 		}
@@ -78,6 +79,9 @@ func (r *GroupRepository) GetAllWithWebsites() ([]model.GroupWithWebsites, error
 			websites = append(websites, w)
 		}
 
+		if err := loadWebsiteTags(r.db, userID, websites); err != nil {
+			return nil, err
+		}
 		groupWithWebsites.Websites = websites
 		result = append(result, groupWithWebsites)
 	}
@@ -86,10 +90,10 @@ func (r *GroupRepository) GetAllWithWebsites() ([]model.GroupWithWebsites, error
 }
 
 // Create 创建分组
-func (r *GroupRepository) Create(req model.CreateGroupRequest) (*model.Group, error) {
-	// 获取最大排序号
+func (r *GroupRepository) Create(userID string, req model.CreateGroupRequest) (*model.Group, error) {
+	// 获取同一父分组内的最大排序号
 	var maxSortOrder sql.NullInt64
-	err := r.db.QueryRow("SELECT MAX(sort_order) FROM groups").Scan(&maxSortOrder)
+	err := r.db.QueryRow("SELECT MAX(sort_order) FROM groups WHERE user_id = ? AND parent_id IS ? AND deleted_at IS NULL", userID, req.ParentId).Scan(&maxSortOrder)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -104,16 +108,17 @@ func (r *GroupRepository) Create(req model.CreateGroupRequest) (*model.Group, er
 		Name:        req.Name,
 		Color:       req.Color,
 		Icon:        req.Icon,
+		ParentId:    req.ParentId,
 		SortOrder:   sortOrder,
 		IsCollapsed: false,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `INSERT INTO groups (id, name, color, icon, sort_order, is_collapsed, created_at, updated_at) 
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO groups (id, user_id, name, color, icon, parent_id, sort_order, is_collapsed, created_at, updated_at) 
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	
-	_, err = r.db.Exec(query, group.ID, group.Name, group.Color, group.Icon, group.SortOrder, group.IsCollapsed, group.CreatedAt, group.UpdatedAt)
+	_, err = r.db.Exec(query, group.ID, userID, group.Name, group.Color, group.Icon, group.ParentId, group.SortOrder, group.IsCollapsed, group.CreatedAt, group.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +127,7 @@ func (r *GroupRepository) Create(req model.CreateGroupRequest) (*model.Group, er
 }
 
 // Update 更新分组
-func (r *GroupRepository) Update(id string, req model.UpdateGroupRequest) (*model.Group, error) {
+func (r *GroupRepository) Update(userID, id string, req model.UpdateGroupRequest) (*model.Group, error) {
 	setParts := []string{}
 	args := []interface{}{}
 
@@ -149,9 +154,9 @@ func (r *GroupRepository) Update(id string, req model.UpdateGroupRequest) (*mode
 
 	setParts = append(setParts, "updated_at = ?")
 	args = append(args, time.Now())
-	args = append(args, id) // 最后添加WHERE条件的参数
+	args = append(args, id, userID) // 最后添加WHERE条件的参数
 
-	query := fmt.Sprintf("UPDATE groups SET %s WHERE id = ?", strings.Join(setParts, ", "))
+	query := fmt.Sprintf("UPDATE groups SET %s WHERE id = ? AND user_id = ? AND deleted_at IS NULL", strings.Join(setParts, ", "))
 
 	_, err := r.db.Exec(query, args...)
 	if err != nil {
@@ -159,18 +164,18 @@ func (r *GroupRepository) Update(id string, req model.UpdateGroupRequest) (*mode
 	}
 
 	// 返回更新后的分组
-	return r.GetByID(id)
+	return r.GetByID(userID, id)
 }
 
 // GetByID 根据ID获取分组
-func (r *GroupRepository) GetByID(id string) (*model.Group, error) {
-	query := `SELECT id, name, color, icon, sort_order, is_collapsed, created_at, updated_at 
-	          FROM groups WHERE id = ?`
+func (r *GroupRepository) GetByID(userID, id string) (*model.Group, error) {
+	query := `SELECT id, name, color, icon, parent_id, sort_order, is_collapsed, created_at, updated_at 
+	          FROM groups WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
 	
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRow(query, id, userID)
 	
 	var group model.Group
-	err := row.Scan(&group.ID, &group.Name, &group.Color, &group.Icon, &group.SortOrder, &group.IsCollapsed, &group.CreatedAt, &group.UpdatedAt)
+	err := row.Scan(&group.ID, &group.Name, &group.Color, &group.Icon, &group.ParentId, &group.SortOrder, &group.IsCollapsed, &group.CreatedAt, &group.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -178,31 +183,280 @@ func (r *GroupRepository) GetByID(id string) (*model.Group, error) {
 	return &group, nil
 }
 
-// Delete 删除分组
-func (r *GroupRepository) Delete(id string) error {
+// Trash 把分组及其中的网站移入回收站，分组不存在时返回 sql.ErrNoRows
+// cascade 为 true 时所有子孙分组及其网站一起删除，否则直接子分组提升到被删除分组所在的层级并占据它原来的位置
+// 一起删除的网站和子孙分组记录 deleted_with，恢复该分组时一起恢复
+func (r *GroupRepository) Trash(userID, id string, cascade bool) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// 将分组下的网站移到未分组状态
-	_, err = tx.Exec("UPDATE websites SET group_id = NULL WHERE group_id = ?", id)
+	var parentID *string
+	err = tx.QueryRow("SELECT parent_id FROM groups WHERE id = ? AND user_id = ? AND deleted_at IS NULL", id, userID).Scan(&parentID)
 	if err != nil {
 		return err
 	}
 
-	// 删除分组
-	_, err = tx.Exec("DELETE FROM groups WHERE id = ?", id)
+	deleted := []string{id}
+	if cascade {
+		deleted, err = descendantGroupIDs(tx, userID, id)
+		if err != nil {
+			return err
+		}
+	} else {
+		// 子分组按原有顺序插入到被删除分组的位置
+		siblings, err := childGroupIDs(tx, userID, parentID)
+		if err != nil {
+			return err
+		}
+		children, err := childGroupIDs(tx, userID, &id)
+		if err != nil {
+			return err
+		}
+
+		order := make([]string, 0, len(siblings)+len(children))
+		for _, sibling := range siblings {
+			if sibling == id {
+				order = append(order, children...)
+			} else {
+				order = append(order, sibling)
+			}
+		}
+
+		_, err = tx.Exec("UPDATE groups SET parent_id = ?, updated_at = ? WHERE parent_id = ? AND user_id = ? AND deleted_at IS NULL",
+			parentID, time.Now(), id, userID)
+		if err != nil {
+			return err
+		}
+		if err := renumberGroups(tx, userID, order); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().Unix()
+	for _, groupID := range deleted {
+		// 分组下的网站保留 group_id 和排序，随分组一起进入回收站
+		_, err = tx.Exec("UPDATE websites SET deleted_at = ?, deleted_with = ? WHERE group_id = ? AND user_id = ? AND deleted_at IS NULL",
+			now, id, groupID, userID)
+		if err != nil {
+			return err
+		}
+
+		var deletedWith *string
+		if groupID != id {
+			deletedWith = &id
+		}
+		_, err = tx.Exec("UPDATE groups SET deleted_at = ?, deleted_with = ? WHERE id = ? AND user_id = ?", now, deletedWith, groupID, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cascade {
+		siblings, err := childGroupIDs(tx, userID, parentID)
+		if err != nil {
+			return err
+		}
+		if err := renumberGroups(tx, userID, siblings); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTrash 获取回收站中被直接删除的分组及随之一起删除的网站和子孙分组数量，最近删除的在前
+func (r *GroupRepository) GetTrash(userID string) ([]model.TrashedGroup, error) {
+	rows, err := r.db.Query(trashedGroupQuery+" AND g.deleted_with IS NULL ORDER BY g.deleted_at DESC, g.name COLLATE NOCASE ASC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trashed := []model.TrashedGroup{}
+	for rows.Next() {
+		t, err := scanTrashedGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		trashed = append(trashed, *t)
+	}
+
+	return trashed, rows.Err()
+}
+
+// GetTrashByID 获取回收站中被直接删除的分组，不存在时返回 sql.ErrNoRows
+func (r *GroupRepository) GetTrashByID(userID, id string) (*model.TrashedGroup, error) {
+	return scanTrashedGroup(r.db.QueryRow(trashedGroupQuery+" AND g.deleted_with IS NULL AND g.id = ?2", userID, id))
+}
+
+// GetTrashedSubgroups 获取随分组一起删除的子孙分组
+func (r *GroupRepository) GetTrashedSubgroups(userID, id string) ([]model.Group, error) {
+	query := `SELECT id, name, color, icon, parent_id, sort_order, is_collapsed, created_at, updated_at
+	          FROM groups WHERE user_id = ? AND deleted_with = ?`
+
+	rows, err := r.db.Query(query, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []model.Group
+	for rows.Next() {
+		var g model.Group
+		err := rows.Scan(&g.ID, &g.Name, &g.Color, &g.Icon, &g.ParentId, &g.SortOrder, &g.IsCollapsed, &g.CreatedAt, &g.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
+
+// Restore 从回收站恢复分组及随之一起删除的网站和子孙分组，分组放到 parentID 下删除前的位置
+// 调用方负责检查 parentID 存在且层级深度合法，分组不在回收站中时返回 sql.ErrNoRows
+func (r *GroupRepository) Restore(userID, id string, parentID *string) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	var sortOrder int
+	err = tx.QueryRow("SELECT COALESCE(sort_order, 0) FROM groups WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL AND deleted_with IS NULL",
+		id, userID).Scan(&sortOrder)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE groups SET deleted_at = NULL, parent_id = ?, updated_at = ? WHERE id = ? AND user_id = ?", parentID, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE groups SET deleted_at = NULL, deleted_with = NULL WHERE deleted_with = ? AND user_id = ?", id, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE websites SET deleted_at = NULL, deleted_with = NULL WHERE deleted_with = ? AND user_id = ?", id, userID); err != nil {
+		return err
+	}
+
+	// 按删除前的排序插回原层级，原位置已超出范围时放到最后
+	siblings, err := childGroupIDs(tx, userID, parentID)
+	if err != nil {
+		return err
+	}
+	order := make([]string, 0, len(siblings))
+	for _, sibling := range siblings {
+		if sibling != id {
+			order = append(order, sibling)
+		}
+	}
+	index := min(max(sortOrder, 0), len(order))
+	order = append(order[:index], append([]string{id}, order[index:]...)...)
+	if err := renumberGroups(tx, userID, order); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge 永久删除回收站中的分组及随之一起删除的网站和子孙分组，分组不在回收站中时返回 sql.ErrNoRows
+func (r *GroupRepository) Purge(userID, id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	n, err := purgeGroups(tx, "user_id = ?1 AND deleted_at IS NOT NULL AND (id = ?2 AND deleted_with IS NULL OR deleted_with = ?2)", userID, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// EmptyTrash 永久删除回收站中的所有分组及随之一起删除的网站，返回删除的分组数量（不含子孙分组）
+func (r *GroupRepository) EmptyTrash(userID string) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var count int64
+	err = tx.QueryRow("SELECT COUNT(*) FROM groups WHERE user_id = ? AND deleted_at IS NOT NULL AND deleted_with IS NULL", userID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := purgeGroups(tx, "user_id = ?1 AND deleted_at IS NOT NULL", userID); err != nil {
+		return 0, err
+	}
+
+	return count, tx.Commit()
+}
+
+// Move 把分组移到指定父分组的指定位置，position 为空或超出范围时放到最后
+// 新旧两个层级的 sort_order 都会重新编号为连续的 0..n-1，调用方负责检查循环和层级深度
+func (r *GroupRepository) Move(userID, id string, parentID *string, position *int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldParentID *string
+	err = tx.QueryRow("SELECT parent_id FROM groups WHERE id = ? AND user_id = ? AND deleted_at IS NULL", id, userID).Scan(&oldParentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE groups SET parent_id = ?, updated_at = ? WHERE id = ? AND user_id = ?", parentID, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	siblings, err := childGroupIDs(tx, userID, parentID)
+	if err != nil {
+		return err
+	}
+	order := make([]string, 0, len(siblings))
+	for _, sibling := range siblings {
+		if sibling != id {
+			order = append(order, sibling)
+		}
+	}
+	index := len(order)
+	if position != nil && *position >= 0 && *position < index {
+		index = *position
+	}
+	order = append(order[:index], append([]string{id}, order[index:]...)...)
+	if err := renumberGroups(tx, userID, order); err != nil {
+		return err
+	}
+
+	// 离开原来的层级后，原层级的排序号也要保持连续
+	if !sameGroupParent(oldParentID, parentID) {
+		oldSiblings, err := childGroupIDs(tx, userID, oldParentID)
+		if err != nil {
+			return err
+		}
+		if err := renumberGroups(tx, userID, oldSiblings); err != nil {
+			return err
+		}
+	}
 
 	return tx.Commit()
 }
 
 // Reorder 重新排序分组
-func (r *GroupRepository) Reorder(groupIds []string) error {
+func (r *GroupRepository) Reorder(userID string, groupIds []string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -210,8 +464,8 @@ func (r *GroupRepository) Reorder(groupIds []string) error {
 	defer tx.Rollback()
 
 	for i, groupId := range groupIds {
-		_, err = tx.Exec("UPDATE groups SET sort_order = ?, updated_at = ? WHERE id = ?", 
-			i, time.Now(), groupId)
+		_, err = tx.Exec("UPDATE groups SET sort_order = ?, updated_at = ? WHERE id = ? AND user_id = ? AND deleted_at IS NULL", 
+			i, time.Now(), groupId, userID)
 		if err != nil {
 			return err
 		}
@@ -221,7 +475,7 @@ func (r *GroupRepository) Reorder(groupIds []string) error {
 }
 
 // MoveWebsiteToGroup 移动网站到分组
-func (r *GroupRepository) MoveWebsiteToGroup(websiteId string, groupId *string, position *int) error {
+func (r *GroupRepository) MoveWebsiteToGroup(userID, websiteId string, groupId *string, position *int) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -232,20 +486,20 @@ func (r *GroupRepository) MoveWebsiteToGroup(websiteId string, groupId *string,
 	if position != nil && groupId != nil {
 		// 为该位置及之后的网站排序号加1
 		_, err = tx.Exec(`UPDATE websites SET sort_order = sort_order + 1 
-		                  WHERE group_id = ? AND sort_order >= ?`, *groupId, *position)
+		                  WHERE group_id = ? AND user_id = ? AND deleted_at IS NULL AND sort_order >= ?`, *groupId, userID, *position)
 		if err != nil {
 			return err
 		}
 
 		// 更新目标网站
 		_, err = tx.Exec(`UPDATE websites SET group_id = ?, sort_order = ?, updated_at = ? 
-		                  WHERE id = ?`, groupId, *position, time.Now(), websiteId)
+		                  WHERE id = ? AND user_id = ? AND deleted_at IS NULL`, groupId, *position, time.Now(), websiteId, userID)
 	} else {
 		// 如果没有指定位置，放到最后
 		var sortOrder int
 		if groupId != nil {
 			var maxSort sql.NullInt64
-			err = tx.QueryRow("SELECT MAX(sort_order) FROM websites WHERE group_id = ?", *groupId).Scan(&maxSort)
+			err = tx.QueryRow("SELECT MAX(sort_order) FROM websites WHERE group_id = ? AND user_id = ? AND deleted_at IS NULL", *groupId, userID).Scan(&maxSort)
 			if err != nil && err != sql.ErrNoRows {
 				return err
 			}
@@ -254,7 +508,7 @@ func (r *GroupRepository) MoveWebsiteToGroup(websiteId string, groupId *string,
 			}
 		} else {
 			var maxSort sql.NullInt64
-			err = tx.QueryRow("SELECT MAX(sort_order) FROM websites WHERE group_id IS NULL").Scan(&maxSort)
+			err = tx.QueryRow("SELECT MAX(sort_order) FROM websites WHERE group_id IS NULL AND user_id = ? AND deleted_at IS NULL", userID).Scan(&maxSort)
 			if err != nil && err != sql.ErrNoRows {
 				return err
 			}
@@ -264,7 +518,7 @@ func (r *GroupRepository) MoveWebsiteToGroup(websiteId string, groupId *string,
 		}
 
 		_, err = tx.Exec(`UPDATE websites SET group_id = ?, sort_order = ?, updated_at = ? 
-		                  WHERE id = ?`, groupId, sortOrder, time.Now(), websiteId)
+		                  WHERE id = ? AND user_id = ? AND deleted_at IS NULL`, groupId, sortOrder, time.Now(), websiteId, userID)
 	}
 
 	if err != nil {
@@ -272,4 +526,113 @@ func (r *GroupRepository) MoveWebsiteToGroup(websiteId string, groupId *string,
 	}
 
 	return tx.Commit()
-}
\ No newline at end of file
+}
+
+// childGroupIDs 按排序获取父分组的直接子分组ID（不含回收站中的分组），parentID 为空时获取顶层分组
+func childGroupIDs(tx *sql.Tx, userID string, parentID *string) ([]string, error) {
+	rows, err := tx.Query("SELECT id FROM groups WHERE user_id = ? AND parent_id IS ? AND deleted_at IS NULL ORDER BY sort_order ASC, created_at ASC",
+		userID, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// descendantGroupIDs 获取分组自身及其所有子孙分组的ID，不含回收站中的子孙分组
+func descendantGroupIDs(tx *sql.Tx, userID, id string) ([]string, error) {
+	query := `
+		WITH RECURSIVE subtree(id) AS (
+			SELECT ?1
+			UNION
+			SELECT g.id FROM groups g JOIN subtree s ON g.parent_id = s.id WHERE g.user_id = ?2 AND g.deleted_at IS NULL
+		)
+		SELECT id FROM subtree
+	`
+
+	rows, err := tx.Query(query, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var groupID string
+		if err := rows.Scan(&groupID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, groupID)
+	}
+
+	return ids, rows.Err()
+}
+
+// trashedGroupQuery 查询回收站中的分组及随之一起删除的网站和子孙分组数量，?1 为账号ID
+const trashedGroupQuery = `
+	SELECT g.id, g.name, g.color, g.icon, g.parent_id, COALESCE(g.sort_order, 0), g.is_collapsed, g.created_at, g.updated_at, g.deleted_at,
+		(SELECT COUNT(*) FROM websites w WHERE w.deleted_with = g.id AND w.user_id = ?1),
+		(SELECT COUNT(*) FROM groups sub WHERE sub.deleted_with = g.id AND sub.user_id = ?1)
+	FROM groups g
+	WHERE g.user_id = ?1 AND g.deleted_at IS NOT NULL`
+
+func scanTrashedGroup(row rowScanner) (*model.TrashedGroup, error) {
+	var t model.TrashedGroup
+	var deletedAt int64
+	g := &t.Group
+	err := row.Scan(&g.ID, &g.Name, &g.Color, &g.Icon, &g.ParentId, &g.SortOrder, &g.IsCollapsed, &g.CreatedAt, &g.UpdatedAt, &deletedAt,
+		&t.WebsiteCount, &t.SubgroupCount)
+	if err != nil {
+		return nil, err
+	}
+	t.DeletedAt = time.Unix(deletedAt, 0)
+	return &t, nil
+}
+
+// purgeGroups 永久删除满足 where 条件的分组及随之一起删除的网站，返回删除的分组数量
+// 仍引用这些分组的其他回收站条目改为未分组或顶层分组，外键约束只对启用它的连接生效，这里显式处理
+func purgeGroups(tx *sql.Tx, where string, args ...interface{}) (int64, error) {
+	ids := "SELECT id FROM groups WHERE " + where
+	if _, err := tx.Exec("DELETE FROM websites WHERE deleted_with IN ("+ids+")", args...); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE websites SET group_id = NULL WHERE group_id IN ("+ids+")", args...); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE groups SET parent_id = NULL WHERE parent_id IN ("+ids+")", args...); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("DELETE FROM groups WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// renumberGroups 按给定顺序把分组的 sort_order 设为 0..n-1
+func renumberGroups(tx *sql.Tx, userID string, ids []string) error {
+	for i, id := range ids {
+		if _, err := tx.Exec("UPDATE groups SET sort_order = ? WHERE id = ? AND user_id = ?", i, id, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sameGroupParent(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"nav-panel-backend/internal/model"
+	"time"
+)
+
+type HealthRepository struct {
+	db *sql.DB
+}
+
+func NewHealthRepository(db *sql.DB) *HealthRepository {
+	return &HealthRepository{db: db}
+}
+
+// GetAll 获取账号下所有网站的健康状态，尚未检查的网站也会返回
+func (r *HealthRepository) GetAll(userID string) ([]model.WebsiteHealth, error) {
+	query := `
+		SELECT w.id, w.name, w.url, h.status_code, h.latency_ms, h.final_url, h.error, h.checked_at
+		FROM websites w
+		LEFT JOIN website_health h ON h.website_id = w.id
+		WHERE w.user_id = ? AND w.deleted_at IS NULL
+		ORDER BY COALESCE(w.sort_order, 0) ASC, w.created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.WebsiteHealth
+	for rows.Next() {
+		h, err := scanWebsiteHealth(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *h)
+	}
+
+	return result, rows.Err()
+}
+
+// GetByWebsiteID 获取单个网站的健康状态
+func (r *HealthRepository) GetByWebsiteID(userID, websiteID string) (*model.WebsiteHealth, error) {
+	query := `
+		SELECT w.id, w.name, w.url, h.status_code, h.latency_ms, h.final_url, h.error, h.checked_at
+		FROM websites w
+		LEFT JOIN website_health h ON h.website_id = w.id
+		WHERE w.id = ? AND w.user_id = ? AND w.deleted_at IS NULL
+	`
+
+	return scanWebsiteHealth(r.db.QueryRow(query, websiteID, userID))
+}
+
+// Targets 获取所有账号下需要检查的网站，回收站中的网站不检查
+func (r *HealthRepository) Targets() ([]model.HealthTarget, error) {
+	rows, err := r.db.Query("SELECT id, url FROM websites WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []model.HealthTarget
+	for rows.Next() {
+		var t model.HealthTarget
+		if err := rows.Scan(&t.ID, &t.URL); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, rows.Err()
+}
+
+// LastCheckedAt 最近一次检查的时间，从未检查时返回零值
+func (r *HealthRepository) LastCheckedAt() (time.Time, error) {
+	// 聚合函数会丢失列类型，按时间倒序取第一行以便驱动解析为时间
+	var checkedAt time.Time
+	err := r.db.QueryRow("SELECT checked_at FROM website_health ORDER BY checked_at DESC LIMIT 1").Scan(&checkedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return time.Time{}, err
+	}
+	return checkedAt, nil
+}
+
+// Save 保存检查结果
+func (r *HealthRepository) Save(h *model.WebsiteHealth) error {
+	query := `
+		INSERT INTO website_health (website_id, status_code, latency_ms, final_url, error, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (website_id) DO UPDATE SET
+			status_code = excluded.status_code,
+			latency_ms = excluded.latency_ms,
+			final_url = excluded.final_url,
+			error = excluded.error,
+			checked_at = excluded.checked_at
+	`
+
+	_, err := r.db.Exec(query, h.WebsiteID, h.StatusCode, h.LatencyMs, h.FinalURL, h.Error, h.CheckedAt)
+	return err
+}
+
+// PruneOrphans 删除已不存在的网站的检查结果
+func (r *HealthRepository) PruneOrphans() error {
+	_, err := r.db.Exec("DELETE FROM website_health WHERE website_id NOT IN (SELECT id FROM websites)")
+	return err
+}
+
+func scanWebsiteHealth(row rowScanner) (*model.WebsiteHealth, error) {
+	var h model.WebsiteHealth
+	var statusCode sql.NullInt64
+	var latency sql.NullInt64
+	var finalURL, checkErr sql.NullString
+	var checkedAt sql.NullTime
+	if err := row.Scan(&h.WebsiteID, &h.Name, &h.URL, &statusCode, &latency, &finalURL, &checkErr, &checkedAt); err != nil {
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		h.StatusCode = &code
+	}
+	if latency.Valid {
+		h.LatencyMs = &latency.Int64
+	}
+	if finalURL.Valid {
+		h.FinalURL = &finalURL.String
+	}
+	if checkErr.Valid {
+		h.Error = &checkErr.String
+	}
+	if checkedAt.Valid {
+		h.CheckedAt = &checkedAt.Time
+	}
+	h.SetHealthy()
+
+	return &h, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"nav-panel-backend/internal/model"
+	"time"
+)
+
+type IconRepository struct {
+	db *sql.DB
+}
+
+func NewIconRepository(db *sql.DB) *IconRepository {
+	return &IconRepository{db: db}
+}
+
+// Get 根据哈希获取图标
+func (r *IconRepository) Get(hash string) (*model.Icon, error) {
+	var icon model.Icon
+	err := r.db.QueryRow("SELECT hash, content_type, size, data, created_at FROM icons WHERE hash = ?", hash).
+		Scan(&icon.Hash, &icon.ContentType, &icon.Size, &icon.Data, &icon.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &icon, nil
+}
+
+// Save 保存图标及其缩略图，内容相同的图标只保存一份
+func (r *IconRepository) Save(icon *model.Icon) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveIcon(tx, icon); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetVariant 获取不小于 size 的最小缩略图，没有合适的缩略图时返回 sql.ErrNoRows
+func (r *IconRepository) GetVariant(hash string, size int) (*model.IconVariant, error) {
+	var variant model.IconVariant
+	err := r.db.QueryRow("SELECT size, content_type, data FROM icon_variants WHERE hash = ? AND size >= ? ORDER BY size ASC LIMIT 1", hash, size).
+		Scan(&variant.Size, &variant.ContentType, &variant.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// saveIcon 在事务中保存图标及其缩略图
+func saveIcon(tx *sql.Tx, icon *model.Icon) error {
+	if _, err := tx.Exec("INSERT OR IGNORE INTO icons (hash, content_type, size, data, created_at) VALUES (?, ?, ?, ?, ?)",
+		icon.Hash, icon.ContentType, icon.Size, icon.Data, icon.CreatedAt); err != nil {
+		return err
+	}
+
+	for _, variant := range icon.Variants {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO icon_variants (hash, size, content_type, data) VALUES (?, ?, ?, ?)",
+			icon.Hash, variant.Size, variant.ContentType, variant.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTarget 获取账号下网站的图标信息
+func (r *IconRepository) GetTarget(userID, websiteID string) (*model.IconTarget, error) {
+	var t model.IconTarget
+	err := r.db.QueryRow("SELECT id, name, url, icon, icon_source FROM websites WHERE id = ? AND user_id = ? AND deleted_at IS NULL", websiteID, userID).
+		Scan(&t.ID, &t.Name, &t.URL, &t.Icon, &t.IconSource)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// StaleTargets 获取图标由服务端管理且在 before 之前未检查过的网站，回收站中的网站不刷新
+func (r *IconRepository) StaleTargets(before time.Time, limit int) ([]model.IconTarget, error) {
+	query := `
+		SELECT id, name, url, icon, icon_source FROM websites
+		WHERE deleted_at IS NULL AND (icon IS NULL OR icon = '' OR icon_source IS NOT NULL)
+		  AND (icon_checked_at IS NULL OR icon_checked_at < ?)
+		ORDER BY icon_checked_at ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []model.IconTarget
+	for rows.Next() {
+		var t model.IconTarget
+		if err := rows.Scan(&t.ID, &t.Name, &t.URL, &t.Icon, &t.IconSource); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, rows.Err()
+}
+
+// SetWebsiteIcon 更新网站的图标及其来源
+// onlyAuto 为 true 时不覆盖用户在此期间指定的图标
+func (r *IconRepository) SetWebsiteIcon(websiteID, icon string, source model.IconSource, checkedAt time.Time, onlyAuto bool) error {
+	query := "UPDATE websites SET icon = ?, icon_source = ?, icon_checked_at = ? WHERE id = ?"
+	if onlyAuto {
+		query += " AND (icon IS NULL OR icon = '' OR icon_source IS NOT NULL)"
+	}
+
+	_, err := r.db.Exec(query, icon, source, checkedAt, websiteID)
+	return err
+}
+
+// TouchWebsiteIcon 只更新网站图标的检查时间
+func (r *IconRepository) TouchWebsiteIcon(websiteID string, checkedAt time.Time) error {
+	_, err := r.db.Exec("UPDATE websites SET icon_checked_at = ? WHERE id = ?", checkedAt, websiteID)
+	return err
+}
+
+// PruneUnused 删除 before 之前保存且没有被网站、分组或搜索引擎（包括回收站中的）引用的图标
+func (r *IconRepository) PruneUnused(before time.Time) (int64, error) {
+	query := `
+		DELETE FROM icons
+		WHERE created_at < ?1
+		  AND NOT EXISTS (SELECT 1 FROM websites WHERE websites.icon = ?2 || icons.hash)
+		  AND NOT EXISTS (SELECT 1 FROM groups WHERE groups.icon = ?2 || icons.hash)
+		  AND NOT EXISTS (SELECT 1 FROM search_engines WHERE search_engines.icon = ?2 || icons.hash)
+	`
+
+	result, err := r.db.Exec(query, before, model.IconURLPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	// 外键约束只对启用它的连接生效，显式删除缩略图
+	if _, err := r.db.Exec("DELETE FROM icon_variants WHERE hash NOT IN (SELECT hash FROM icons)"); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"database/sql"
+	"nav-panel-backend/internal/model"
+	"time"
+)
+
+// ImportRepository 数据导入仓库，所有写操作都在同一个事务中完成
+type ImportRepository struct {
+	db *sql.DB
+}
+
+func NewImportRepository(db *sql.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+// Begin 开启导入事务，事务内的所有读写都限定在该账号的数据中
+func (r *ImportRepository) Begin(userID string) (*ImportTx, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &ImportTx{tx: tx, userID: userID}, nil
+}
+
+// ImportTx 导入事务
+type ImportTx struct {
+	tx     *sql.Tx
+	userID string
+}
+
+func (t *ImportTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *ImportTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// Savepoint 在保存点中执行单行操作，失败时只回滚该行，不影响整个事务
+func (t *ImportTx) Savepoint(fn func() error) error {
+	if _, err := t.tx.Exec("SAVEPOINT import_row"); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		t.tx.Exec("ROLLBACK TO import_row")
+		t.tx.Exec("RELEASE import_row")
+		return err
+	}
+
+	_, err := t.tx.Exec("RELEASE import_row")
+	return err
+}
+
+// Websites 获取事务内可见的所有网站，不含回收站中的网站
+func (t *ImportTx) Websites() ([]model.Website, error) {
+	query := `
+		SELECT id, name, url, icon, description, group_id, created_at, updated_at, COALESCE(sort_order, 0) as sort_order
+		FROM websites
+		WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY sort_order ASC, created_at DESC
+	`
+
+	rows, err := t.tx.Query(query, t.userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var websites []model.Website
+	for rows.Next() {
+		var w model.Website
+		err := rows.Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder)
+		if err != nil {
+			return nil, err
+		}
+		websites = append(websites, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadWebsiteTags(t.tx, t.userID, websites); err != nil {
+		return nil, err
+	}
+	return websites, nil
+}
+
+// Tags 获取事务内可见的所有标签
+func (t *ImportTx) Tags() ([]model.Tag, error) {
+	rows, err := t.tx.Query("SELECT id, name, color, created_at, updated_at FROM tags WHERE user_id = ? ORDER BY name COLLATE NOCASE", t.userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []model.Tag
+	for rows.Next() {
+		var tag model.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// DeleteAllTags 删除所有标签及其与网站的关联
+func (t *ImportTx) DeleteAllTags() error {
+	_, err := t.tx.Exec("DELETE FROM tags WHERE user_id = ?", t.userID)
+	return err
+}
+
+// TagIDInUse 标签ID是否已被占用，包括其他账号的标签
+func (t *ImportTx) TagIDInUse(id string) (bool, error) {
+	return t.idInUse("tags", id)
+}
+
+// CreateTag 插入标签
+func (t *ImportTx) CreateTag(tag *model.Tag) error {
+	query := "INSERT INTO tags (id, user_id, name, color, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err := t.tx.Exec(query, tag.ID, t.userID, tag.Name, tag.Color, tag.CreatedAt, tag.UpdatedAt)
+	return err
+}
+
+// UpdateTag 用导入数据覆盖已有标签的名称和颜色
+func (t *ImportTx) UpdateTag(id string, tag *model.Tag) error {
+	_, err := t.tx.Exec("UPDATE tags SET name = ?, color = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		tag.Name, tag.Color, time.Now(), id, t.userID)
+	return err
+}
+
+// SetWebsiteTags 按名称替换网站的标签，不存在的标签使用导入数据中的颜色创建
+func (t *ImportTx) SetWebsiteTags(websiteID string, tags []model.Tag) error {
+	return setWebsiteTags(t.tx, t.userID, websiteID, tags)
+}
+
+// Groups 获取事务内可见的所有分组，不含回收站中的分组
+func (t *ImportTx) Groups() ([]model.Group, error) {
+	query := `SELECT id, name, color, icon, parent_id, sort_order, is_collapsed, created_at, updated_at
+	          FROM groups WHERE user_id = ? AND deleted_at IS NULL ORDER BY sort_order ASC`
+
+	rows, err := t.tx.Query(query, t.userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []model.Group
+	for rows.Next() {
+		var g model.Group
+		err := rows.Scan(&g.ID, &g.Name, &g.Color, &g.Icon, &g.ParentId, &g.SortOrder, &g.IsCollapsed, &g.CreatedAt, &g.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
+
+// GroupIDs 获取事务内可见的所有分组ID，不含回收站中的分组
+func (t *ImportTx) GroupIDs() (map[string]bool, error) {
+	rows, err := t.tx.Query("SELECT id FROM groups WHERE user_id = ? AND deleted_at IS NULL", t.userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// DeleteAllGroups 删除所有分组，分组下的网站变为未分组，回收站中的分组保持不变
+func (t *ImportTx) DeleteAllGroups() error {
+	if _, err := t.tx.Exec("UPDATE websites SET group_id = NULL WHERE group_id IS NOT NULL AND user_id = ? AND deleted_at IS NULL", t.userID); err != nil {
+		return err
+	}
+	_, err := t.tx.Exec("DELETE FROM groups WHERE user_id = ? AND deleted_at IS NULL", t.userID)
+	return err
+}
+
+// GroupIDInUse 分组ID是否已被占用，包括其他账号的分组
+func (t *ImportTx) GroupIDInUse(id string) (bool, error) {
+	return t.idInUse("groups", id)
+}
+
+// SaveIcon 把导入数据中内联的图标保存到图标库，随事务一起提交或回滚
+func (t *ImportTx) SaveIcon(icon *model.Icon) error {
+	return saveIcon(t.tx, icon)
+}
+
+// CreateGroup 插入分组，保留导入数据中的排序与创建时间
+func (t *ImportTx) CreateGroup(g *model.Group) error {
+	query := `INSERT INTO groups (id, user_id, name, color, icon, parent_id, sort_order, is_collapsed, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := t.tx.Exec(query, g.ID, t.userID, g.Name, g.Color, g.Icon, g.ParentId, g.SortOrder, g.IsCollapsed, g.CreatedAt, g.UpdatedAt)
+	return err
+}
+
+// UpdateGroup 用导入数据覆盖已有分组
+func (t *ImportTx) UpdateGroup(id string, g *model.Group) error {
+	query := `UPDATE groups SET name = ?, color = ?, icon = ?, parent_id = ?, sort_order = ?, is_collapsed = ?, updated_at = ?
+	          WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	_, err := t.tx.Exec(query, g.Name, g.Color, g.Icon, g.ParentId, g.SortOrder, g.IsCollapsed, time.Now(), id, t.userID)
+	return err
+}
+
+// DeleteAllWebsites 删除所有网站，回收站中的网站保持不变
+func (t *ImportTx) DeleteAllWebsites() error {
+	_, err := t.tx.Exec("DELETE FROM websites WHERE user_id = ? AND deleted_at IS NULL", t.userID)
+	return err
+}
+
+// WebsiteIDInUse 网站ID是否已被占用，包括其他账号的网站
+func (t *ImportTx) WebsiteIDInUse(id string) (bool, error) {
+	return t.idInUse("websites", id)
+}
+
+// CreateWebsite 插入网站，保留导入数据中的排序与创建时间
+func (t *ImportTx) CreateWebsite(w *model.Website) error {
+	query := `
+		INSERT INTO websites (id, user_id, name, url, icon, description, group_id, sort_order, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := t.tx.Exec(query, w.ID, t.userID, w.Name, w.URL, w.Icon, w.Description, w.GroupId, w.SortOrder, w.CreatedAt, w.UpdatedAt)
+	return err
+}
+
+// UpdateWebsite 用导入数据覆盖已有网站
+func (t *ImportTx) UpdateWebsite(id string, w *model.Website) error {
+	query := `
+		UPDATE websites SET name = ?, url = ?, icon = ?, description = ?, group_id = ?, sort_order = ?, updated_at = ?
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL
+	`
+
+	_, err := t.tx.Exec(query, w.Name, w.URL, w.Icon, w.Description, w.GroupId, w.SortOrder, time.Now(), id, t.userID)
+	return err
+}
+
+// SearchEngineCategories 获取事务内可见的所有搜索引擎分类
+func (t *ImportTx) SearchEngineCategories() ([]model.SearchEngineCategory, error) {
+	query := `SELECT id, name, sort_order, created_at, updated_at
+	          FROM search_engine_categories WHERE user_id = ? ORDER BY sort_order ASC`
+
+	rows, err := t.tx.Query(query, t.userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []model.SearchEngineCategory
+	for rows.Next() {
+		var c model.SearchEngineCategory
+		if err := rows.Scan(&c.ID, &c.Name, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, rows.Err()
+}
+
+// DeleteAllSearchEngineCategories 删除所有搜索引擎分类，分类中的搜索引擎变为未分类
+func (t *ImportTx) DeleteAllSearchEngineCategories() error {
+	if _, err := t.tx.Exec("UPDATE search_engines SET category_id = NULL WHERE category_id IS NOT NULL AND user_id = ?", t.userID); err != nil {
+		return err
+	}
+	_, err := t.tx.Exec("DELETE FROM search_engine_categories WHERE user_id = ?", t.userID)
+	return err
+}
+
+// SearchEngineCategoryIDInUse 分类ID是否已被占用，包括其他账号的分类
+func (t *ImportTx) SearchEngineCategoryIDInUse(id string) (bool, error) {
+	return t.idInUse("search_engine_categories", id)
+}
+
+// CreateSearchEngineCategory 插入搜索引擎分类，保留导入数据中的排序与创建时间
+func (t *ImportTx) CreateSearchEngineCategory(c *model.SearchEngineCategory) error {
+	query := "INSERT INTO search_engine_categories (id, user_id, name, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err := t.tx.Exec(query, c.ID, t.userID, c.Name, c.SortOrder, c.CreatedAt, c.UpdatedAt)
+	return err
+}
+
+// UpdateSearchEngineCategory 用导入数据覆盖已有分类的名称和排序
+func (t *ImportTx) UpdateSearchEngineCategory(id string, c *model.SearchEngineCategory) error {
+	_, err := t.tx.Exec("UPDATE search_engine_categories SET name = ?, sort_order = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		c.Name, c.SortOrder, time.Now(), id, t.userID)
+	return err
+}
+
+// SearchEngines 获取事务内可见的所有搜索引擎，不含回收站中的搜索引擎
+func (t *ImportTx) SearchEngines() ([]model.SearchEngine, error) {
+	query := `
+		SELECT id, name, url, icon, placeholder, is_default, keyword, method, body, sort_order, category_id
+		FROM search_engines
+		WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY sort_order ASC, name ASC
+	`
+
+	rows, err := t.tx.Query(query, t.userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var engines []model.SearchEngine
+	for rows.Next() {
+		var e model.SearchEngine
+		err := rows.Scan(&e.ID, &e.Name, &e.URL, &e.Icon, &e.Placeholder, &e.IsDefault, &e.Keyword, &e.Method, &e.Body, &e.SortOrder, &e.CategoryId)
+		if err != nil {
+			return nil, err
+		}
+		engines = append(engines, e)
+	}
+
+	return engines, rows.Err()
+}
+
+// DeleteAllSearchEngines 删除所有搜索引擎，回收站中的搜索引擎保持不变
+func (t *ImportTx) DeleteAllSearchEngines() error {
+	_, err := t.tx.Exec("DELETE FROM search_engines WHERE user_id = ? AND deleted_at IS NULL", t.userID)
+	return err
+}
+
+// CreateSearchEngine 插入搜索引擎，回收站中ID相同的搜索引擎被导入的数据取代，永久删除
+func (t *ImportTx) CreateSearchEngine(e *model.SearchEngine) error {
+	if _, err := t.tx.Exec("DELETE FROM search_engines WHERE user_id = ? AND id = ? AND deleted_at IS NOT NULL", t.userID, e.ID); err != nil {
+		return err
+	}
+
+	if e.IsDefault {
+		if _, err := t.tx.Exec("UPDATE search_engines SET is_default = FALSE WHERE user_id = ?", t.userID); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO search_engines (user_id, id, name, url, icon, placeholder, is_default, keyword, method, body, sort_order, category_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := t.tx.Exec(query, t.userID, e.ID, e.Name, e.URL, e.Icon, e.Placeholder, e.IsDefault, e.Keyword, e.Method, e.Body, e.SortOrder, e.CategoryId)
+	return err
+}
+
+// UpdateSearchEngine 用导入数据覆盖已有搜索引擎
+func (t *ImportTx) UpdateSearchEngine(id string, e *model.SearchEngine) error {
+	if e.IsDefault {
+		if _, err := t.tx.Exec("UPDATE search_engines SET is_default = FALSE WHERE user_id = ?", t.userID); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE search_engines SET name = ?, url = ?, icon = ?, placeholder = ?, is_default = ?, keyword = ?, method = ?, body = ?,
+			sort_order = ?, category_id = ?
+		WHERE user_id = ? AND id = ? AND deleted_at IS NULL
+	`
+
+	_, err := t.tx.Exec(query, e.Name, e.URL, e.Icon, e.Placeholder, e.IsDefault, e.Keyword, e.Method, e.Body, e.SortOrder, e.CategoryId, t.userID, id)
+	return err
+}
+
+// Settings 获取事务内可见的应用设置
+func (t *ImportTx) Settings() (*model.AppSettings, error) {
+	query := `
+		SELECT user_id, theme, layout_config, background_config, card_style_config, search_config, updated_at
+		FROM app_settings
+		WHERE user_id = ?
+	`
+
+	var s model.AppSettings
+	err := t.tx.QueryRow(query, t.userID).Scan(&s.UserID, &s.Theme, &s.LayoutConfig, &s.BackgroundConfig, &s.CardStyleConfig, &s.SearchConfig, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// UpdateSettings 覆盖应用设置
+func (t *ImportTx) UpdateSettings(s *model.AppSettings) error {
+	query := `
+		UPDATE app_settings SET theme = ?, layout_config = ?, background_config = ?, card_style_config = ?, search_config = ?, updated_at = ?
+		WHERE user_id = ?
+	`
+
+	_, err := t.tx.Exec(query, s.Theme, s.LayoutConfig, s.BackgroundConfig, s.CardStyleConfig, s.SearchConfig, time.Now(), t.userID)
+	return err
+}
+
+func (t *ImportTx) idInUse(table, id string) (bool, error) {
+	var exists bool
+	err := t.tx.QueryRow("SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = ?)", id).Scan(&exists)
+	return exists, err
+}
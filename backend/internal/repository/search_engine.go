@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"nav-panel-backend/internal/model"
+	"time"
 )
 
 type SearchEngineRepository struct {
@@ -13,14 +14,16 @@ func NewSearchEngineRepository(db *sql.DB) *SearchEngineRepository {
 	return &SearchEngineRepository{db: db}
 }
 
-func (r *SearchEngineRepository) GetAll() ([]model.SearchEngine, error) {
+// GetAll 按显示顺序获取所有搜索引擎
+func (r *SearchEngineRepository) GetAll(userID string) ([]model.SearchEngine, error) {
 	query := `
-		SELECT id, name, url, icon, placeholder, is_default 
+		SELECT id, name, url, icon, placeholder, is_default, keyword, method, body, sort_order, category_id
 		FROM search_engines 
-		ORDER BY is_default DESC, name ASC
+		WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY sort_order ASC, name ASC
 	`
 	
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +32,7 @@ func (r *SearchEngineRepository) GetAll() ([]model.SearchEngine, error) {
 	var engines []model.SearchEngine
 	for rows.Next() {
 		var e model.SearchEngine
-		err := rows.Scan(&e.ID, &e.Name, &e.URL, &e.Icon, &e.Placeholder, &e.IsDefault)
+		err := rows.Scan(&e.ID, &e.Name, &e.URL, &e.Icon, &e.Placeholder, &e.IsDefault, &e.Keyword, &e.Method, &e.Body, &e.SortOrder, &e.CategoryId)
 		if err != nil {
 			return nil, err
 		}
@@ -39,15 +42,15 @@ func (r *SearchEngineRepository) GetAll() ([]model.SearchEngine, error) {
 	return engines, nil
 }
 
-func (r *SearchEngineRepository) GetByID(id string) (*model.SearchEngine, error) {
+func (r *SearchEngineRepository) GetByID(userID, id string) (*model.SearchEngine, error) {
 	query := `
-		SELECT id, name, url, icon, placeholder, is_default 
+		SELECT id, name, url, icon, placeholder, is_default, keyword, method, body, sort_order, category_id
 		FROM search_engines 
-		WHERE id = ?
+		WHERE user_id = ? AND id = ? AND deleted_at IS NULL
 	`
 	
 	var e model.SearchEngine
-	err := r.db.QueryRow(query, id).Scan(&e.ID, &e.Name, &e.URL, &e.Icon, &e.Placeholder, &e.IsDefault)
+	err := r.db.QueryRow(query, userID, id).Scan(&e.ID, &e.Name, &e.URL, &e.Icon, &e.Placeholder, &e.IsDefault, &e.Keyword, &e.Method, &e.Body, &e.SortOrder, &e.CategoryId)
 	if err != nil {
 		return nil, err
 	}
@@ -55,27 +58,54 @@ func (r *SearchEngineRepository) GetByID(id string) (*model.SearchEngine, error)
 	return &e, nil
 }
 
-func (r *SearchEngineRepository) Create(engine *model.SearchEngine) error {
+// GetByKeyword 按快捷关键词（小写）获取搜索引擎
+func (r *SearchEngineRepository) GetByKeyword(userID, keyword string) (*model.SearchEngine, error) {
+	query := `
+		SELECT id, name, url, icon, placeholder, is_default, keyword, method, body, sort_order, category_id
+		FROM search_engines
+		WHERE user_id = ? AND keyword = ? AND deleted_at IS NULL
+	`
+
+	var e model.SearchEngine
+	err := r.db.QueryRow(query, userID, keyword).Scan(&e.ID, &e.Name, &e.URL, &e.Icon, &e.Placeholder, &e.IsDefault, &e.Keyword, &e.Method, &e.Body, &e.SortOrder, &e.CategoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func (r *SearchEngineRepository) Create(userID string, engine *model.SearchEngine) error {
 	// 如果设置为默认，先取消其他默认引擎
 	if engine.IsDefault {
-		if err := r.clearDefaultFlags(); err != nil {
+		if err := r.clearDefaultFlags(userID); err != nil {
 			return err
 		}
 	}
 
+	// 新建的搜索引擎排在最后
+	var maxSortOrder sql.NullInt64
+	if err := r.db.QueryRow("SELECT MAX(sort_order) FROM search_engines WHERE user_id = ? AND deleted_at IS NULL", userID).Scan(&maxSortOrder); err != nil {
+		return err
+	}
+	engine.SortOrder = 0
+	if maxSortOrder.Valid {
+		engine.SortOrder = int(maxSortOrder.Int64) + 1
+	}
+
 	query := `
-		INSERT INTO search_engines (id, name, url, icon, placeholder, is_default)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO search_engines (user_id, id, name, url, icon, placeholder, is_default, keyword, method, body, sort_order, category_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	
-	_, err := r.db.Exec(query, engine.ID, engine.Name, engine.URL, engine.Icon, engine.Placeholder, engine.IsDefault)
+	_, err := r.db.Exec(query, userID, engine.ID, engine.Name, engine.URL, engine.Icon, engine.Placeholder, engine.IsDefault, engine.Keyword, engine.Method, engine.Body, engine.SortOrder, engine.CategoryId)
 	return err
 }
 
-func (r *SearchEngineRepository) Update(id string, updates map[string]interface{}) error {
+func (r *SearchEngineRepository) Update(userID, id string, updates map[string]interface{}) error {
 	// 如果更新默认状态为true，先清除其他默认状态
 	if isDefault, exists := updates["is_default"]; exists && isDefault.(bool) {
-		if err := r.clearDefaultFlags(); err != nil {
+		if err := r.clearDefaultFlags(userID); err != nil {
 			return err
 		}
 	}
@@ -94,21 +124,187 @@ func (r *SearchEngineRepository) Update(id string, updates map[string]interface{
 		first = false
 	}
 
-	query += " WHERE id = ?"
-	args = append(args, id)
+	query += " WHERE user_id = ? AND id = ? AND deleted_at IS NULL"
+	args = append(args, userID, id)
 
 	_, err := r.db.Exec(query, args...)
 	return err
 }
 
-func (r *SearchEngineRepository) Delete(id string) error {
-	query := "DELETE FROM search_engines WHERE id = ?"
-	_, err := r.db.Exec(query, id)
-	return err
+// Trash 把搜索引擎移入回收站并取消默认状态，保留排序以便恢复，搜索引擎不存在时返回 sql.ErrNoRows
+func (r *SearchEngineRepository) Trash(userID, id string) error {
+	query := "UPDATE search_engines SET deleted_at = ?, is_default = FALSE WHERE user_id = ? AND id = ? AND deleted_at IS NULL"
+	result, err := r.db.Exec(query, time.Now().Unix(), userID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// InTrash 回收站中是否有该ID的搜索引擎，ID 在同一账号内唯一，回收站中的搜索引擎同样占用ID
+func (r *SearchEngineRepository) InTrash(userID, id string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM search_engines WHERE user_id = ? AND id = ? AND deleted_at IS NOT NULL)", userID, id).Scan(&exists)
+	return exists, err
+}
+
+// GetTrash 获取回收站中的搜索引擎，最近删除的在前
+func (r *SearchEngineRepository) GetTrash(userID string) ([]model.TrashedSearchEngine, error) {
+	query := `
+		SELECT id, name, url, icon, placeholder, is_default, keyword, method, body, sort_order, category_id, deleted_at
+		FROM search_engines
+		WHERE user_id = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, name ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trashed := []model.TrashedSearchEngine{}
+	for rows.Next() {
+		var t model.TrashedSearchEngine
+		var deletedAt int64
+		e := &t.SearchEngine
+		err := rows.Scan(&e.ID, &e.Name, &e.URL, &e.Icon, &e.Placeholder, &e.IsDefault, &e.Keyword, &e.Method, &e.Body, &e.SortOrder, &e.CategoryId, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		t.DeletedAt = time.Unix(deletedAt, 0)
+		trashed = append(trashed, t)
+	}
+
+	return trashed, rows.Err()
+}
+
+// Restore 从回收站恢复搜索引擎到删除前的位置，恢复后不是默认引擎
+// 关键词已被其他搜索引擎使用时清空关键词，分类已被删除时变为未分类，搜索引擎不在回收站中时返回 sql.ErrNoRows
+func (r *SearchEngineRepository) Restore(userID, id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var sortOrder int
+	var keyword, categoryID *string
+	err = tx.QueryRow("SELECT sort_order, keyword, category_id FROM search_engines WHERE user_id = ? AND id = ? AND deleted_at IS NOT NULL",
+		userID, id).Scan(&sortOrder, &keyword, &categoryID)
+	if err != nil {
+		return err
+	}
+
+	if keyword != nil {
+		var taken bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM search_engines WHERE user_id = ? AND keyword = ? AND deleted_at IS NULL)", userID, *keyword).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken {
+			keyword = nil
+		}
+	}
+	if categoryID != nil {
+		var exists bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM search_engine_categories WHERE user_id = ? AND id = ?)", userID, *categoryID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			categoryID = nil
+		}
+	}
+
+	// 原位置及之后的搜索引擎后移一位
+	_, err = tx.Exec("UPDATE search_engines SET sort_order = sort_order + 1 WHERE user_id = ? AND deleted_at IS NULL AND sort_order >= ?", userID, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE search_engines SET deleted_at = NULL, keyword = ?, category_id = ? WHERE user_id = ? AND id = ?", keyword, categoryID, userID, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge 永久删除回收站中的搜索引擎，搜索引擎不在回收站中时返回 sql.ErrNoRows
+func (r *SearchEngineRepository) Purge(userID, id string) error {
+	result, err := r.db.Exec("DELETE FROM search_engines WHERE user_id = ? AND id = ? AND deleted_at IS NOT NULL", userID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// EmptyTrash 永久删除回收站中的所有搜索引擎，返回删除的数量
+func (r *SearchEngineRepository) EmptyTrash(userID string) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM search_engines WHERE user_id = ? AND deleted_at IS NOT NULL", userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Reorder 列出的搜索引擎按给定顺序占据它们原来的位置，之后把所有搜索引擎的 sort_order 重新编号为 0..n-1
+// 调用方负责检查ID存在且不重复
+func (r *SearchEngineRepository) Reorder(userID string, engineIds []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM search_engines WHERE user_id = ? AND deleted_at IS NULL ORDER BY sort_order ASC, name ASC", userID)
+	if err != nil {
+		return err
+	}
+	var order []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		order = append(order, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	listed := make(map[string]bool, len(engineIds))
+	for _, id := range engineIds {
+		listed[id] = true
+	}
+	next := 0
+	for i, id := range order {
+		if listed[id] {
+			order[i] = engineIds[next]
+			next++
+		}
+	}
+
+	for i, id := range order {
+		if _, err := tx.Exec("UPDATE search_engines SET sort_order = ? WHERE id = ? AND user_id = ?", i, id, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (r *SearchEngineRepository) clearDefaultFlags() error {
-	query := "UPDATE search_engines SET is_default = FALSE"
-	_, err := r.db.Exec(query)
+func (r *SearchEngineRepository) clearDefaultFlags(userID string) error {
+	query := "UPDATE search_engines SET is_default = FALSE WHERE user_id = ?"
+	_, err := r.db.Exec(query, userID)
 	return err
 } 
\ No newline at end of file
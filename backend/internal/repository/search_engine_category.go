@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"nav-panel-backend/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SearchEngineCategoryRepository struct {
+	db *sql.DB
+}
+
+func NewSearchEngineCategoryRepository(db *sql.DB) *SearchEngineCategoryRepository {
+	return &SearchEngineCategoryRepository{db: db}
+}
+
+// GetAll 按排序获取所有搜索引擎分类
+func (r *SearchEngineCategoryRepository) GetAll(userID string) ([]model.SearchEngineCategory, error) {
+	query := `SELECT id, name, sort_order, created_at, updated_at
+	          FROM search_engine_categories WHERE user_id = ? ORDER BY sort_order ASC, name COLLATE NOCASE ASC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []model.SearchEngineCategory{}
+	for rows.Next() {
+		var c model.SearchEngineCategory
+		if err := rows.Scan(&c.ID, &c.Name, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetByID 根据ID获取搜索引擎分类
+func (r *SearchEngineCategoryRepository) GetByID(userID, id string) (*model.SearchEngineCategory, error) {
+	query := "SELECT id, name, sort_order, created_at, updated_at FROM search_engine_categories WHERE id = ? AND user_id = ?"
+
+	var c model.SearchEngineCategory
+	err := r.db.QueryRow(query, id, userID).Scan(&c.ID, &c.Name, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// FindByName 按名称查找分类（忽略大小写），不存在时返回 sql.ErrNoRows
+func (r *SearchEngineCategoryRepository) FindByName(userID, name string) (*model.SearchEngineCategory, error) {
+	query := "SELECT id, name, sort_order, created_at, updated_at FROM search_engine_categories WHERE user_id = ? AND name = ? COLLATE NOCASE"
+
+	var c model.SearchEngineCategory
+	err := r.db.QueryRow(query, userID, name).Scan(&c.ID, &c.Name, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Create 创建分类，排在已有分类之后
+func (r *SearchEngineCategoryRepository) Create(userID, name string) (*model.SearchEngineCategory, error) {
+	var maxSortOrder sql.NullInt64
+	err := r.db.QueryRow("SELECT MAX(sort_order) FROM search_engine_categories WHERE user_id = ?", userID).Scan(&maxSortOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	category := &model.SearchEngineCategory{
+		ID:        uuid.New().String(),
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if maxSortOrder.Valid {
+		category.SortOrder = int(maxSortOrder.Int64) + 1
+	}
+
+	query := "INSERT INTO search_engine_categories (id, user_id, name, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err = r.db.Exec(query, category.ID, userID, category.Name, category.SortOrder, category.CreatedAt, category.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// Rename 修改分类名称，分类不存在时返回 sql.ErrNoRows
+func (r *SearchEngineCategoryRepository) Rename(userID, id, name string) (*model.SearchEngineCategory, error) {
+	result, err := r.db.Exec("UPDATE search_engine_categories SET name = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		name, time.Now(), id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return r.GetByID(userID, id)
+}
+
+// Delete 删除分类，分类中的搜索引擎变为未分类，分类不存在时返回 sql.ErrNoRows
+func (r *SearchEngineCategoryRepository) Delete(userID, id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM search_engine_categories WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec("UPDATE search_engines SET category_id = NULL WHERE category_id = ? AND user_id = ?", id, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reorder 按给定顺序把分类的 sort_order 设为 0..n-1
+func (r *SearchEngineCategoryRepository) Reorder(userID string, categoryIds []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, id := range categoryIds {
+		_, err := tx.Exec("UPDATE search_engine_categories SET sort_order = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+			i, time.Now(), id, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
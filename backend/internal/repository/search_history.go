@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"nav-panel-backend/internal/model"
+	"time"
+)
+
+type SearchHistoryRepository struct {
+	db *sql.DB
+}
+
+func NewSearchHistoryRepository(db *sql.DB) *SearchHistoryRepository {
+	return &SearchHistoryRepository{db: db}
+}
+
+// GetSettings 获取搜索历史设置，尚未设置时返回默认值（记录历史、永久保留、没有排除规则）
+func (r *SearchHistoryRepository) GetSettings(userID string) (*model.SearchHistorySettings, error) {
+	settings := &model.SearchHistorySettings{Enabled: true, ExcludePatterns: []string{}}
+
+	var patterns string
+	err := r.db.QueryRow("SELECT enabled, retention_days, exclude_patterns FROM search_history_settings WHERE user_id = ?", userID).
+		Scan(&settings.Enabled, &settings.RetentionDays, &patterns)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(patterns), &settings.ExcludePatterns); err != nil || settings.ExcludePatterns == nil {
+		settings.ExcludePatterns = []string{}
+	}
+	return settings, nil
+}
+
+// SaveSettings 保存搜索历史设置
+func (r *SearchHistoryRepository) SaveSettings(userID string, settings *model.SearchHistorySettings) error {
+	patterns, err := json.Marshal(settings.ExcludePatterns)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO search_history_settings (user_id, enabled, retention_days, exclude_patterns, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled = excluded.enabled,
+			retention_days = excluded.retention_days,
+			exclude_patterns = excluded.exclude_patterns,
+			updated_at = excluded.updated_at
+	`
+	_, err = r.db.Exec(query, userID, settings.Enabled, settings.RetentionDays, string(patterns), time.Now())
+	return err
+}
+
+// Create 记录一次搜索，normalized 用于合并相同的查询
+func (r *SearchHistoryRepository) Create(userID string, entry *model.SearchHistoryEntry, normalized string) error {
+	query := "INSERT INTO search_history (id, user_id, query, normalized, engine_id, searched_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err := r.db.Exec(query, entry.ID, userID, entry.Query, normalized, entry.EngineId, entry.SearchedAt.Unix())
+	return err
+}
+
+// GetRecent 按时间倒序获取最近的搜索记录
+func (r *SearchHistoryRepository) GetRecent(userID string, limit int) ([]model.SearchHistoryEntry, error) {
+	query := `SELECT id, query, engine_id, searched_at FROM search_history
+	          WHERE user_id = ? ORDER BY searched_at DESC, rowid DESC LIMIT ?`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.SearchHistoryEntry{}
+	for rows.Next() {
+		var e model.SearchHistoryEntry
+		var searchedAt int64
+		if err := rows.Scan(&e.ID, &e.Query, &e.EngineId, &searchedAt); err != nil {
+			return nil, err
+		}
+		e.SearchedAt = time.Unix(searchedAt, 0)
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Suggest 返回包含 term 的历史查询，以 term 开头的排在前面，其余按得分排序
+// 每次搜索的权重随时间衰减（一周前的搜索权重为一半），得分为权重之和，兼顾频率和新近程度
+// 同一查询有多种写法时返回最近一次的写法（SQLite 中与 MAX 一起查询的裸列取自最大值所在的行）
+func (r *SearchHistoryRepository) Suggest(userID, term string, now time.Time, limit int) ([]model.SearchSuggestion, error) {
+	query := `
+		SELECT query, COUNT(*), MAX(searched_at),
+			SUM(1.0 / (1 + MAX(?2 - searched_at, 0) / 604800.0)) AS score
+		FROM search_history
+		WHERE user_id = ?1 AND instr(normalized, ?3) > 0
+		GROUP BY normalized
+		ORDER BY instr(normalized, ?3) = 1 DESC, score DESC
+		LIMIT ?4
+	`
+
+	rows, err := r.db.Query(query, userID, now.Unix(), term, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []model.SearchSuggestion{}
+	for rows.Next() {
+		var s model.SearchSuggestion
+		var lastSearched int64
+		var score float64
+		if err := rows.Scan(&s.Query, &s.Count, &lastSearched, &score); err != nil {
+			return nil, err
+		}
+		s.LastSearchedAt = time.Unix(lastSearched, 0)
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions, rows.Err()
+}
+
+// Delete 删除一条搜索记录，记录不存在时返回 sql.ErrNoRows
+func (r *SearchHistoryRepository) Delete(userID, id string) error {
+	result, err := r.db.Exec("DELETE FROM search_history WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Clear 删除账号的所有搜索记录，返回删除的数量
+func (r *SearchHistoryRepository) Clear(userID string) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM search_history WHERE user_id = ?", userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteBefore 删除指定时间之前的搜索记录
+func (r *SearchHistoryRepository) DeleteBefore(userID string, before time.Time) error {
+	_, err := r.db.Exec("DELETE FROM search_history WHERE user_id = ? AND searched_at < ?", userID, before.Unix())
+	return err
+}
+
+// DeleteMatching 删除查询满足 match 的搜索记录
+func (r *SearchHistoryRepository) DeleteMatching(userID string, match func(query string) bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, query FROM search_history WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id, query string
+		if err := rows.Scan(&id, &query); err != nil {
+			rows.Close()
+			return err
+		}
+		if match(query) {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := tx.Exec("DELETE FROM search_history WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
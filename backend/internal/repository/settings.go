@@ -14,15 +14,15 @@ func NewSettingsRepository(db *sql.DB) *SettingsRepository {
 	return &SettingsRepository{db: db}
 }
 
-func (r *SettingsRepository) Get() (*model.AppSettings, error) {
+func (r *SettingsRepository) Get(userID string) (*model.AppSettings, error) {
 	query := `
-		SELECT id, theme, layout_config, background_config, card_style_config, search_config, updated_at 
+		SELECT user_id, theme, layout_config, background_config, card_style_config, search_config, updated_at 
 		FROM app_settings 
-		WHERE id = 1
+		WHERE user_id = ?
 	`
 	
 	var s model.AppSettings
-	err := r.db.QueryRow(query).Scan(&s.ID, &s.Theme, &s.LayoutConfig, &s.BackgroundConfig, &s.CardStyleConfig, &s.SearchConfig, &s.UpdatedAt)
+	err := r.db.QueryRow(query, userID).Scan(&s.UserID, &s.Theme, &s.LayoutConfig, &s.BackgroundConfig, &s.CardStyleConfig, &s.SearchConfig, &s.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +30,7 @@ func (r *SettingsRepository) Get() (*model.AppSettings, error) {
 	return &s, nil
 }
 
-func (r *SettingsRepository) Update(updates map[string]interface{}) error {
+func (r *SettingsRepository) Update(userID string, updates map[string]interface{}) error {
 	// 动态构建更新查询
 	query := "UPDATE app_settings SET updated_at = ?"
 	args := []interface{}{time.Now()}
@@ -40,13 +40,14 @@ func (r *SettingsRepository) Update(updates map[string]interface{}) error {
 		args = append(args, value)
 	}
 
-	query += " WHERE id = 1"
+	query += " WHERE user_id = ?"
+	args = append(args, userID)
 
 	_, err := r.db.Exec(query, args...)
 	return err
 }
 
-func (r *SettingsRepository) Reset() error {
+func (r *SettingsRepository) Reset(userID string) error {
 	query := `
 		UPDATE app_settings SET 
 			theme = 'light',
@@ -55,9 +56,9 @@ func (r *SettingsRepository) Reset() error {
 			card_style_config = '{"borderRadius":12,"opacity":0.9,"shadow":true}',
 			search_config = '{"enabled":true,"defaultEngineId":"google","openInNewTab":true}',
 			updated_at = ?
-		WHERE id = 1
+		WHERE user_id = ?
 	`
 	
-	_, err := r.db.Exec(query, time.Now())
+	_, err := r.db.Exec(query, time.Now(), userID)
 	return err
 } 
\ No newline at end of file
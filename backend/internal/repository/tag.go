@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// tagQuerier 抽象 *sql.DB 与 *sql.Tx，标签辅助函数可在导入事务中使用
+type tagQuerier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// GetAll 获取所有标签及其网站数量（不含回收站中的网站），按名称排序
+func (r *TagRepository) GetAll(userID string) ([]model.TagWithCount, error) {
+	query := `
+		SELECT t.id, t.name, t.color, t.created_at, t.updated_at, COUNT(w.id)
+		FROM tags t
+		LEFT JOIN website_tags wt ON wt.tag_id = t.id
+		LEFT JOIN websites w ON w.id = wt.website_id AND w.deleted_at IS NULL
+		WHERE t.user_id = ?
+		GROUP BY t.id
+		ORDER BY t.name COLLATE NOCASE ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []model.TagWithCount{}
+	for rows.Next() {
+		var t model.TagWithCount
+		if err := rows.Scan(&t.ID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt, &t.WebsiteCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetByID 根据ID获取标签
+func (r *TagRepository) GetByID(userID, id string) (*model.TagWithCount, error) {
+	query := `
+		SELECT t.id, t.name, t.color, t.created_at, t.updated_at,
+			(SELECT COUNT(*) FROM website_tags wt JOIN websites w ON w.id = wt.website_id WHERE wt.tag_id = t.id AND w.deleted_at IS NULL)
+		FROM tags t
+		WHERE t.id = ? AND t.user_id = ?
+	`
+
+	var t model.TagWithCount
+	err := r.db.QueryRow(query, id, userID).Scan(&t.ID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt, &t.WebsiteCount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// FindByName 按名称查找标签（忽略大小写），不存在时返回 sql.ErrNoRows
+func (r *TagRepository) FindByName(userID, name string) (*model.Tag, error) {
+	query := "SELECT id, name, color, created_at, updated_at FROM tags WHERE user_id = ? AND name = ? COLLATE NOCASE"
+
+	var t model.Tag
+	err := r.db.QueryRow(query, userID, name).Scan(&t.ID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// Create 创建标签
+func (r *TagRepository) Create(userID string, req model.CreateTagRequest) (*model.TagWithCount, error) {
+	now := time.Now()
+	tag := &model.TagWithCount{
+		Tag: model.Tag{
+			ID:        uuid.New().String(),
+			Name:      req.Name,
+			Color:     req.Color,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+
+	query := "INSERT INTO tags (id, user_id, name, color, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err := r.db.Exec(query, tag.ID, userID, tag.Name, tag.Color, tag.CreatedAt, tag.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// Update 更新标签名称或颜色
+func (r *TagRepository) Update(userID, id string, req model.UpdateTagRequest) (*model.TagWithCount, error) {
+	setParts := []string{}
+	args := []interface{}{}
+
+	if req.Name != nil {
+		setParts = append(setParts, "name = ?")
+		args = append(args, *req.Name)
+	}
+	if req.Color != nil {
+		setParts = append(setParts, "color = ?")
+		args = append(args, *req.Color)
+	}
+
+	if len(setParts) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+
+	setParts = append(setParts, "updated_at = ?")
+	args = append(args, time.Now(), id, userID)
+
+	query := fmt.Sprintf("UPDATE tags SET %s WHERE id = ? AND user_id = ?", strings.Join(setParts, ", "))
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return r.GetByID(userID, id)
+}
+
+// Delete 删除标签，触发器会同时删除网站与该标签的关联
+func (r *TagRepository) Delete(userID, id string) error {
+	result, err := r.db.Exec("DELETE FROM tags WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Merge 把源标签的网站转移到目标标签并删除源标签，所有标签都必须属于该账号
+func (r *TagRepository) Merge(userID string, sourceIDs []string, targetID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range append([]string{targetID}, sourceIDs...) {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM tags WHERE id = ? AND user_id = ?)", id, userID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return sql.ErrNoRows
+		}
+	}
+
+	for _, id := range sourceIDs {
+		if id == targetID {
+			continue
+		}
+		_, err := tx.Exec(`INSERT OR IGNORE INTO website_tags (website_id, tag_id)
+		                   SELECT website_id, ? FROM website_tags WHERE tag_id = ?`, targetID, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM tags WHERE id = ? AND user_id = ?", id, userID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE tags SET updated_at = ? WHERE id = ?", time.Now(), targetID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetWebsiteTags 用给定名称的标签替换网站的所有标签，不存在的标签自动创建
+func (r *TagRepository) SetWebsiteTags(userID, websiteID string, names []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tags := make([]model.Tag, len(names))
+	for i, name := range names {
+		tags[i].Name = name
+	}
+	if err := setWebsiteTags(tx, userID, websiteID, tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setWebsiteTags 按名称（忽略大小写）匹配标签并替换网站的标签
+// 新建标签时使用传入的颜色，已有标签保持原有名称和颜色
+func setWebsiteTags(q tagQuerier, userID, websiteID string, tags []model.Tag) error {
+	if _, err := q.Exec("DELETE FROM website_tags WHERE website_id = ?", websiteID); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		var id string
+		err := q.QueryRow("SELECT id FROM tags WHERE user_id = ? AND name = ? COLLATE NOCASE", userID, tag.Name).Scan(&id)
+		if err == sql.ErrNoRows {
+			id = uuid.New().String()
+			now := time.Now()
+			_, err = q.Exec("INSERT INTO tags (id, user_id, name, color, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+				id, userID, tag.Name, tag.Color, now, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.Exec("INSERT OR IGNORE INTO website_tags (website_id, tag_id) VALUES (?, ?)", websiteID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadWebsiteTags 为网站填充标签，没有标签的网站得到空数组
+func loadWebsiteTags(q tagQuerier, userID string, websites []model.Website) error {
+	if len(websites) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT wt.website_id, t.id, t.name, t.color, t.created_at, t.updated_at
+		FROM website_tags wt
+		JOIN tags t ON t.id = wt.tag_id
+		WHERE t.user_id = ?
+		ORDER BY t.name COLLATE NOCASE ASC
+	`
+
+	rows, err := q.Query(query, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byWebsite := make(map[string][]model.Tag)
+	for rows.Next() {
+		var websiteID string
+		var t model.Tag
+		if err := rows.Scan(&websiteID, &t.ID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return err
+		}
+		byWebsite[websiteID] = append(byWebsite[websiteID], t)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range websites {
+		websites[i].Tags = byWebsite[websites[i].ID]
+		if websites[i].Tags == nil {
+			websites[i].Tags = []model.Tag{}
+		}
+	}
+	return nil
+}
+
+// tagFilterSQL 返回筛选同时带有所有指定标签（按名称，忽略大小写）的网站ID的子查询及其参数
+// 子查询使用编号参数，从 ?offset+1 开始，便于拼接到已有参数之后
+func tagFilterSQL(userID string, names []string, offset int) (string, []interface{}) {
+	args := []interface{}{userID}
+	param := func() string {
+		return fmt.Sprintf("?%d", offset+len(args))
+	}
+
+	userParam := param()
+	distinct := make(map[string]bool)
+	placeholders := make([]string, 0, len(names))
+	for _, name := range names {
+		if key := strings.ToLower(name); !distinct[key] {
+			distinct[key] = true
+			args = append(args, name)
+			placeholders = append(placeholders, param())
+		}
+	}
+	args = append(args, len(distinct))
+
+	query := `SELECT wt.website_id FROM website_tags wt JOIN tags t ON t.id = wt.tag_id
+		WHERE t.user_id = ` + userParam + ` AND t.name COLLATE NOCASE IN (` + strings.Join(placeholders, ", ") + `)
+		GROUP BY wt.website_id HAVING COUNT(DISTINCT t.id) = ` + param()
+	return query, args
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TrashRepository 回收站的定期清理，跨所有账号
+type TrashRepository struct {
+	db *sql.DB
+}
+
+func NewTrashRepository(db *sql.DB) *TrashRepository {
+	return &TrashRepository{db: db}
+}
+
+// PurgeBefore 永久删除 before 之前移入回收站的网站、分组和搜索引擎，返回删除的数量
+// 随分组一起删除的网站和子孙分组与该分组的删除时间相同，会一起被清理
+func (r *TrashRepository) PurgeBefore(before time.Time) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	cutoff := before.Unix()
+	var total int64
+
+	result, err := tx.Exec("DELETE FROM websites WHERE deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	total += n
+
+	n, err = purgeGroups(tx, "deleted_at < ?1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	total += n
+
+	result, err = tx.Exec("DELETE FROM search_engines WHERE deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n, _ = result.RowsAffected()
+	total += n
+
+	return total, tx.Commit()
+}
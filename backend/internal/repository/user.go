@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"database/sql"
+	"nav-panel-backend/internal/database"
+	"nav-panel-backend/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Count 获取账号数量
+func (r *UserRepository) Count() (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	return count, err
+}
+
+// CountAdmins 获取管理员数量
+func (r *UserRepository) CountAdmins() (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE role = ?", model.UserRoleAdmin).Scan(&count)
+	return count, err
+}
+
+// GetAll 获取所有账号
+func (r *UserRepository) GetAll() ([]model.User, error) {
+	rows, err := r.db.Query(`SELECT id, username, password_hash, role, created_at, updated_at FROM users ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var u model.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// GetByID 根据ID获取账号
+func (r *UserRepository) GetByID(id string) (*model.User, error) {
+	return r.scanUser(r.db.QueryRow(`SELECT id, username, password_hash, role, created_at, updated_at FROM users WHERE id = ?`, id))
+}
+
+// GetByUsername 根据用户名获取账号，用户名不区分大小写
+func (r *UserRepository) GetByUsername(username string) (*model.User, error) {
+	return r.scanUser(r.db.QueryRow(`SELECT id, username, password_hash, role, created_at, updated_at FROM users WHERE username = ?`, username))
+}
+
+// GetFirstAdmin 获取最早创建的管理员
+func (r *UserRepository) GetFirstAdmin() (*model.User, error) {
+	return r.scanUser(r.db.QueryRow(`SELECT id, username, password_hash, role, created_at, updated_at FROM users WHERE role = ? ORDER BY created_at ASC LIMIT 1`, model.UserRoleAdmin))
+}
+
+// Create 创建账号并写入默认设置和默认搜索引擎
+// 第一个账号会认领升级前遗留的无主数据，此时不再写入默认数据
+func (r *UserRepository) Create(username, passwordHash string, role model.UserRole) (*model.User, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	user := &model.User{
+		ID:           uuid.New().String(),
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         role,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`INSERT INTO users (id, username, password_hash, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		user.ID, user.Username, user.PasswordHash, user.Role, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := false
+	if count == 0 {
+		for _, table := range []string{"groups", "websites", "tags", "search_engines", "app_settings"} {
+			result, err := tx.Exec("UPDATE "+table+" SET user_id = ? WHERE user_id IS NULL", user.ID)
+			if err != nil {
+				return nil, err
+			}
+			if n, _ := result.RowsAffected(); n > 0 && table == "app_settings" {
+				claimed = true
+			}
+		}
+	}
+
+	if !claimed {
+		if err := database.SeedUser(tx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// UpdateRole 更新账号角色
+func (r *UserRepository) UpdateRole(id string, role model.UserRole) error {
+	_, err := r.db.Exec("UPDATE users SET role = ?, updated_at = ? WHERE id = ?", role, time.Now(), id)
+	return err
+}
+
+// Delete 删除账号及其所有数据
+func (r *UserRepository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"sessions", "api_tokens", "websites", "tags", "groups", "search_engines", "search_engine_categories", "app_settings", "search_history", "search_history_settings"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE user_id = ?", id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM users WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdatePassword 更新密码并注销该账号的所有会话
+func (r *UserRepository) UpdatePassword(id, passwordHash string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?", passwordHash, time.Now(), id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM sessions WHERE user_id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateSession 保存登录会话
+func (r *UserRepository) CreateSession(session *model.Session) error {
+	_, err := r.db.Exec(`INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		session.TokenHash, session.UserID, session.CreatedAt, session.ExpiresAt)
+	return err
+}
+
+// GetSession 根据令牌摘要获取未过期的会话
+func (r *UserRepository) GetSession(tokenHash string) (*model.Session, error) {
+	var s model.Session
+	err := r.db.QueryRow(`SELECT token_hash, user_id, created_at, expires_at FROM sessions WHERE token_hash = ? AND expires_at > ?`,
+		tokenHash, time.Now()).Scan(&s.TokenHash, &s.UserID, &s.CreatedAt, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteSession 删除会话
+func (r *UserRepository) DeleteSession(tokenHash string) error {
+	_, err := r.db.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
+
+// DeleteExpiredSessions 清理过期会话
+func (r *UserRepository) DeleteExpiredSessions() error {
+	_, err := r.db.Exec("DELETE FROM sessions WHERE expires_at <= ?", time.Now())
+	return err
+}
+
+func (r *UserRepository) scanUser(row *sql.Row) (*model.User, error) {
+	var u model.User
+	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
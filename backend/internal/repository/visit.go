@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"nav-panel-backend/internal/model"
+	"time"
+)
+
+// VisitDayFormat website_visits.day 的日期格式
+const VisitDayFormat = "2006-01-02"
+
+type VisitRepository struct {
+	db *sql.DB
+}
+
+func NewVisitRepository(db *sql.DB) *VisitRepository {
+	return &VisitRepository{db: db}
+}
+
+// Record 记录一次访问，同一天内相同访问者和来源页面的访问累加到同一行
+func (r *VisitRepository) Record(userID, websiteID, visitorID, referrer string, at time.Time) error {
+	query := `
+		INSERT INTO website_visits (website_id, user_id, day, visitor_id, referrer, count, last_visited_at)
+		VALUES (?, ?, ?, ?, ?, 1, ?)
+		ON CONFLICT (website_id, day, visitor_id, referrer)
+		DO UPDATE SET count = count + 1, last_visited_at = MAX(last_visited_at, excluded.last_visited_at)
+	`
+
+	_, err := r.db.Exec(query, websiteID, userID, at.Format(VisitDayFormat), visitorID, referrer, at.Unix())
+	return err
+}
+
+// Ranking 按访问次数排序统计 since（含）之后有访问的网站，ascending 为 true 时访问最少的在前
+func (r *VisitRepository) Ranking(userID, since string, limit int, ascending bool) ([]model.WebsiteVisitStat, error) {
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+
+	query := `
+		SELECT w.id, w.name, w.url, w.icon, w.description, w.group_id, w.created_at, w.updated_at, COALESCE(w.sort_order, 0),
+			SUM(v.count) AS visits, MAX(v.last_visited_at)
+		FROM website_visits v
+		JOIN websites w ON w.id = v.website_id
+		WHERE v.user_id = ? AND w.user_id = ? AND w.deleted_at IS NULL AND v.day >= ?
+		GROUP BY w.id
+		ORDER BY visits ` + order + `, w.name COLLATE NOCASE ASC
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, userID, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []model.WebsiteVisitStat{}
+	for rows.Next() {
+		var stat model.WebsiteVisitStat
+		var lastVisited int64
+		w := &stat.Website
+		err := rows.Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder,
+			&stat.Visits, &lastVisited)
+		if err != nil {
+			return nil, err
+		}
+		at := time.Unix(lastVisited, 0)
+		stat.LastVisitedAt = &at
+		stats = append(stats, stat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	websites := make([]model.Website, len(stats))
+	for i := range stats {
+		websites[i] = stats[i].Website
+	}
+	if err := loadWebsiteTags(r.db, userID, websites); err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		stats[i].Website.Tags = websites[i].Tags
+	}
+
+	return stats, nil
+}
+
+// NeverVisited 获取 since（含）之后没有任何访问的网站，since 为空时为从未访问过的网站
+func (r *VisitRepository) NeverVisited(userID, since string) ([]model.Website, error) {
+	query := `
+		SELECT w.id, w.name, w.url, w.icon, w.description, w.group_id, w.created_at, w.updated_at, COALESCE(w.sort_order, 0)
+		FROM websites w
+		WHERE w.user_id = ? AND w.deleted_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM website_visits v WHERE v.website_id = w.id AND v.day >= ?)
+		ORDER BY w.created_at ASC
+	`
+
+	rows, err := r.db.Query(query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	websites := []model.Website{}
+	for rows.Next() {
+		var w model.Website
+		err := rows.Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder)
+		if err != nil {
+			return nil, err
+		}
+		websites = append(websites, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadWebsiteTags(r.db, userID, websites); err != nil {
+		return nil, err
+	}
+	return websites, nil
+}
+
+// Daily 按天汇总 since（含）之后的访问次数，websiteID 不为空时只统计该网站，没有访问的日期不返回
+// 回收站中的网站的访问不计入
+func (r *VisitRepository) Daily(userID string, websiteID *string, since string) ([]model.DailyVisits, error) {
+	query := `SELECT day, SUM(count) FROM website_visits WHERE user_id = ? AND day >= ?
+		AND website_id NOT IN (SELECT id FROM websites WHERE deleted_at IS NOT NULL)`
+	args := []interface{}{userID, since}
+	if websiteID != nil {
+		query += " AND website_id = ?"
+		args = append(args, *websiteID)
+	}
+	query += " GROUP BY day ORDER BY day ASC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []model.DailyVisits
+	for rows.Next() {
+		var d model.DailyVisits
+		if err := rows.Scan(&d.Date, &d.Visits); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 	"nav-panel-backend/internal/model"
 	"time"
 )
@@ -14,14 +15,26 @@ func NewWebsiteRepository(db *sql.DB) *WebsiteRepository {
 	return &WebsiteRepository{db: db}
 }
 
-func (r *WebsiteRepository) GetAll() ([]model.Website, error) {
+func (r *WebsiteRepository) GetAll(userID string) ([]model.Website, error) {
+	return r.GetByTags(userID, nil)
+}
+
+// GetByTags 获取同时带有所有指定标签的网站，names 为空时返回所有网站
+func (r *WebsiteRepository) GetByTags(userID string, names []string) ([]model.Website, error) {
 	query := `
-		SELECT id, name, url, icon, description, category, group_id, created_at, updated_at, COALESCE(sort_order, 0) as sort_order
+		SELECT id, name, url, icon, description, group_id, created_at, updated_at, COALESCE(sort_order, 0) as sort_order
 		FROM websites 
-		ORDER BY sort_order ASC, created_at DESC
+		WHERE user_id = ?1 AND deleted_at IS NULL
 	`
+	args := []interface{}{userID}
+	if len(names) > 0 {
+		filter, filterArgs := tagFilterSQL(userID, names, len(args))
+		query += " AND id IN (" + filter + ")"
+		args = append(args, filterArgs...)
+	}
+	query += " ORDER BY sort_order ASC, created_at DESC"
 	
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -30,35 +43,45 @@ func (r *WebsiteRepository) GetAll() ([]model.Website, error) {
 	var websites []model.Website
 	for rows.Next() {
 		var w model.Website
-		err := rows.Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.Category, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder)
+		err := rows.Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder)
 		if err != nil {
 			return nil, err
 		}
 		websites = append(websites, w)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	if err := loadWebsiteTags(r.db, userID, websites); err != nil {
+		return nil, err
+	}
 	return websites, nil
 }
 
-func (r *WebsiteRepository) GetByID(id string) (*model.Website, error) {
+func (r *WebsiteRepository) GetByID(userID, id string) (*model.Website, error) {
 	query := `
-		SELECT id, name, url, icon, description, category, group_id, created_at, updated_at, COALESCE(sort_order, 0) as sort_order
+		SELECT id, name, url, icon, description, group_id, created_at, updated_at, COALESCE(sort_order, 0) as sort_order
 		FROM websites 
-		WHERE id = ?
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL
 	`
 	
 	var w model.Website
-	err := r.db.QueryRow(query, id).Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.Category, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder)
+	err := r.db.QueryRow(query, id, userID).Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder)
 	if err != nil {
 		return nil, err
 	}
 
-	return &w, nil
+	websites := []model.Website{w}
+	if err := loadWebsiteTags(r.db, userID, websites); err != nil {
+		return nil, err
+	}
+	return &websites[0], nil
 }
 
-func (r *WebsiteRepository) Create(website *model.Website) error {
+func (r *WebsiteRepository) Create(userID string, website *model.Website) error {
 	query := `
-		INSERT INTO websites (id, name, url, icon, description, category, group_id, created_at, updated_at)
+		INSERT INTO websites (id, user_id, name, url, icon, description, group_id, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	
@@ -66,11 +89,11 @@ func (r *WebsiteRepository) Create(website *model.Website) error {
 	website.CreatedAt = now
 	website.UpdatedAt = now
 
-	_, err := r.db.Exec(query, website.ID, website.Name, website.URL, website.Icon, website.Description, website.Category, website.GroupId, website.CreatedAt, website.UpdatedAt)
+	_, err := r.db.Exec(query, website.ID, userID, website.Name, website.URL, website.Icon, website.Description, website.GroupId, website.CreatedAt, website.UpdatedAt)
 	return err
 }
 
-func (r *WebsiteRepository) Update(id string, updates map[string]interface{}) error {
+func (r *WebsiteRepository) Update(userID, id string, updates map[string]interface{}) error {
 	// 动态构建更新查询
 	query := "UPDATE websites SET updated_at = ?"
 	args := []interface{}{time.Now()}
@@ -80,20 +103,148 @@ func (r *WebsiteRepository) Update(id string, updates map[string]interface{}) er
 		args = append(args, value)
 	}
 
-	query += " WHERE id = ?"
-	args = append(args, id)
+	query += " WHERE id = ? AND user_id = ? AND deleted_at IS NULL"
+	args = append(args, id, userID)
 
 	_, err := r.db.Exec(query, args...)
 	return err
 }
 
-func (r *WebsiteRepository) Delete(id string) error {
-	query := "DELETE FROM websites WHERE id = ?"
-	_, err := r.db.Exec(query, id)
-	return err
+// Trash 把网站移入回收站，保留所在分组和排序以便恢复，网站不存在时返回 sql.ErrNoRows
+func (r *WebsiteRepository) Trash(userID, id string) error {
+	query := "UPDATE websites SET deleted_at = ?, deleted_with = NULL WHERE id = ? AND user_id = ? AND deleted_at IS NULL"
+	result, err := r.db.Exec(query, time.Now().Unix(), id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetTrash 获取回收站中被直接删除的网站，最近删除的在前
+func (r *WebsiteRepository) GetTrash(userID string) ([]model.TrashedWebsite, error) {
+	query := `
+		SELECT id, name, url, icon, description, group_id, created_at, updated_at, COALESCE(sort_order, 0), deleted_at
+		FROM websites
+		WHERE user_id = ? AND deleted_at IS NOT NULL AND deleted_with IS NULL
+		ORDER BY deleted_at DESC, name COLLATE NOCASE ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trashed := []model.TrashedWebsite{}
+	for rows.Next() {
+		var t model.TrashedWebsite
+		var deletedAt int64
+		w := &t.Website
+		err := rows.Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		t.DeletedAt = time.Unix(deletedAt, 0)
+		trashed = append(trashed, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	websites := make([]model.Website, len(trashed))
+	for i := range trashed {
+		websites[i] = trashed[i].Website
+	}
+	if err := loadWebsiteTags(r.db, userID, websites); err != nil {
+		return nil, err
+	}
+	for i := range trashed {
+		trashed[i].Tags = websites[i].Tags
+	}
+	return trashed, nil
 }
 
-func (r *WebsiteRepository) Reorder(websiteIds []string) error {
+// Restore 从回收站恢复网站并回到原位置，原分组已被删除时放到未分组网站的最后
+// 网站不在回收站中（或随分组一起删除）时返回 sql.ErrNoRows
+func (r *WebsiteRepository) Restore(userID, id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var groupID *string
+	var sortOrder int
+	err = tx.QueryRow("SELECT group_id, COALESCE(sort_order, 0) FROM websites WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL AND deleted_with IS NULL",
+		id, userID).Scan(&groupID, &sortOrder)
+	if err != nil {
+		return err
+	}
+
+	orphaned := false
+	if groupID != nil {
+		var active bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM groups WHERE id = ? AND user_id = ? AND deleted_at IS NULL)", *groupID, userID).Scan(&active)
+		if err != nil {
+			return err
+		}
+		orphaned = !active
+	}
+
+	if orphaned {
+		groupID = nil
+		var maxSort sql.NullInt64
+		err = tx.QueryRow("SELECT MAX(sort_order) FROM websites WHERE group_id IS NULL AND user_id = ? AND deleted_at IS NULL", userID).Scan(&maxSort)
+		if err != nil {
+			return err
+		}
+		sortOrder = 0
+		if maxSort.Valid {
+			sortOrder = int(maxSort.Int64) + 1
+		}
+	} else {
+		// 原位置及之后的网站后移一位
+		_, err = tx.Exec(`UPDATE websites SET sort_order = sort_order + 1
+		                  WHERE group_id IS ? AND user_id = ? AND deleted_at IS NULL AND sort_order >= ?`, groupID, userID, sortOrder)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE websites SET deleted_at = NULL, group_id = ?, sort_order = ?, updated_at = ? WHERE id = ? AND user_id = ?",
+		groupID, sortOrder, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge 永久删除回收站中的网站，网站不在回收站中（或随分组一起删除）时返回 sql.ErrNoRows
+func (r *WebsiteRepository) Purge(userID, id string) error {
+	result, err := r.db.Exec("DELETE FROM websites WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL AND deleted_with IS NULL", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// EmptyTrash 永久删除回收站中所有被直接删除的网站，返回删除的数量
+func (r *WebsiteRepository) EmptyTrash(userID string) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM websites WHERE user_id = ? AND deleted_at IS NOT NULL AND deleted_with IS NULL", userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *WebsiteRepository) Reorder(userID string, websiteIds []string) error {
 	// 开始事务
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -103,8 +254,8 @@ func (r *WebsiteRepository) Reorder(websiteIds []string) error {
 
 	// 更新每个网站的排序
 	for i, id := range websiteIds {
-		query := "UPDATE websites SET sort_order = ?, updated_at = ? WHERE id = ?"
-		_, err := tx.Exec(query, i, time.Now(), id)
+		query := "UPDATE websites SET sort_order = ?, updated_at = ? WHERE id = ? AND user_id = ? AND deleted_at IS NULL"
+		_, err := tx.Exec(query, i, time.Now(), id, userID)
 		if err != nil {
 			return err
 		}
@@ -112,4 +263,72 @@ func (r *WebsiteRepository) Reorder(websiteIds []string) error {
 
 	// 提交事务
 	return tx.Commit()
-} 
\ No newline at end of file
+} 
+
+// 全文搜索结果中标记匹配词的字符，使用 Unicode 私用区字符，不会与网站内容冲突
+const (
+	HighlightStart = "\ue000"
+	HighlightEnd   = "\ue001"
+)
+
+// Search 在全文索引中搜索网站，按 bm25 相关度排序，名称的权重最高
+// 返回的 Highlights 中匹配词由 HighlightStart 和 HighlightEnd 包围，尚未做 HTML 转义
+func (r *WebsiteRepository) Search(userID string, q model.WebsiteSearchQuery) ([]model.WebsiteSearchResult, error) {
+	query := `
+		SELECT w.id, w.name, w.url, w.icon, w.description, w.group_id, w.created_at, w.updated_at, COALESCE(w.sort_order, 0),
+			highlight(websites_fts, 0, ?1, ?2),
+			highlight(websites_fts, 1, ?1, ?2),
+			snippet(websites_fts, 2, ?1, ?2, '…', 24),
+			highlight(websites_fts, 3, ?1, ?2),
+			bm25(websites_fts, 10.0, 5.0, 2.0, 3.0) AS rank
+		FROM websites_fts
+		JOIN websites w ON w.id = websites_fts.website_id
+		WHERE websites_fts MATCH ?3 AND websites_fts.user_id = ?4 AND w.deleted_at IS NULL`
+	args := []interface{}{HighlightStart, HighlightEnd, q.Match, userID}
+
+	if q.GroupId != nil {
+		args = append(args, *q.GroupId)
+		query += fmt.Sprintf(" AND w.group_id = ?%d", len(args))
+	}
+	if len(q.Tags) > 0 {
+		filter, filterArgs := tagFilterSQL(userID, q.Tags, len(args))
+		query += " AND w.id IN (" + filter + ")"
+		args = append(args, filterArgs...)
+	}
+	args = append(args, q.Limit)
+	query += fmt.Sprintf(" ORDER BY rank LIMIT ?%d", len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.WebsiteSearchResult{}
+	for rows.Next() {
+		var res model.WebsiteSearchResult
+		w := &res.Website
+		h := &res.Highlights
+		err := rows.Scan(&w.ID, &w.Name, &w.URL, &w.Icon, &w.Description, &w.GroupId, &w.CreatedAt, &w.UpdatedAt, &w.SortOrder,
+			&h.Name, &h.URL, &h.Description, &h.Tags, &res.Rank)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	websites := make([]model.Website, len(results))
+	for i := range results {
+		websites[i] = results[i].Website
+	}
+	if err := loadWebsiteTags(r.db, userID, websites); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Tags = websites[i].Tags
+	}
+	return results, nil
+}
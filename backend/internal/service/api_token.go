@@ -0,0 +1,142 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrAPITokenNotFound API 令牌不存在
+var ErrAPITokenNotFound = errors.New("api token not found")
+
+// ErrInvalidAPIToken 创建令牌的参数无效
+var ErrInvalidAPIToken = errors.New("invalid api token")
+
+// lastUsedResolution 最近使用时间的记录精度，避免每个请求都写数据库
+const lastUsedResolution = time.Minute
+
+type APITokenService struct {
+	repo     *repository.APITokenRepository
+	userRepo *repository.UserRepository
+}
+
+func NewAPITokenService(repo *repository.APITokenRepository, userRepo *repository.UserRepository) *APITokenService {
+	return &APITokenService{repo: repo, userRepo: userRepo}
+}
+
+// GetAll 获取账号的所有 API 令牌
+func (s *APITokenService) GetAll(userID string) ([]model.APIToken, error) {
+	tokens, err := s.repo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	return emptyIfNil(tokens), nil
+}
+
+// Create 创建 API 令牌，返回的明文令牌只在此时可见
+func (s *APITokenService) Create(user *model.User, req model.CreateAPITokenRequest) (*model.CreateAPITokenResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIToken)
+	}
+
+	var scopes []model.TokenScope
+	for _, scope := range req.Scopes {
+		if !slices.Contains(model.TokenScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIToken, scope)
+		}
+		if scope == model.ScopeAdmin && !user.IsAdmin() {
+			return nil, fmt.Errorf("%w: only admins can create tokens with the admin scope", ErrInvalidAPIToken)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidAPIToken)
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	plain := model.APITokenPrefix + secret
+
+	token := &model.APIToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: hashToken(plain),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(token); err != nil {
+		return nil, err
+	}
+
+	return &model.CreateAPITokenResponse{Token: plain, Info: token}, nil
+}
+
+// Revoke 吊销 API 令牌
+func (s *APITokenService) Revoke(userID, id string) error {
+	deleted, err := s.repo.Delete(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// Authenticate 校验 API 令牌，返回令牌所属账号和令牌信息
+func (s *APITokenService) Authenticate(plain string) (*model.User, *model.APIToken, error) {
+	token, err := s.repo.GetByHash(hashToken(plain))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrUnauthenticated
+		}
+		return nil, nil, err
+	}
+	if token.Expired() {
+		return nil, nil, ErrUnauthenticated
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrUnauthenticated
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(token.ID, now); err != nil {
+			return nil, nil, err
+		}
+		token.LastUsedAt = &now
+	}
+
+	return user, token, nil
+}
+
+// TokenAllows 令牌是否拥有所需的权限范围，写权限包含对应的读权限
+func TokenAllows(token *model.APIToken, required model.TokenScope) bool {
+	if slices.Contains(token.Scopes, required) {
+		return true
+	}
+	if resource, ok := strings.CutSuffix(string(required), ":read"); ok {
+		return slices.Contains(token.Scopes, model.TokenScope(resource+":write"))
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"nav-panel-backend/internal/config"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCredentials 用户名或密码错误
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrTooManyAttempts 登录失败次数过多，暂时锁定
+	ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+	// ErrUnauthenticated 会话不存在或已过期
+	ErrUnauthenticated = errors.New("authentication required")
+)
+
+const (
+	// maxLoginFailures 同一来源在 loginLockout 内允许的连续失败次数
+	maxLoginFailures = 5
+	loginLockout     = 15 * time.Minute
+)
+
+// loginFailures 某个来源的连续登录失败记录
+type loginFailures struct {
+	count int
+	last  time.Time
+}
+
+type AuthService struct {
+	userRepo *repository.UserRepository
+	config   config.Auth
+
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+
+	// dummyHash 用户不存在时也做一次 bcrypt 比较，避免通过响应时间探测用户名
+	dummyHash []byte
+}
+
+func NewAuthService(userRepo *repository.UserRepository, config config.Auth) *AuthService {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("nav-panel"), bcrypt.DefaultCost)
+	return &AuthService{
+		userRepo:  userRepo,
+		config:    config,
+		failures:  make(map[string]*loginFailures),
+		dummyHash: dummyHash,
+	}
+}
+
+// Enabled 是否启用认证
+func (s *AuthService) Enabled() bool {
+	return s.config.Enabled
+}
+
+// PublicRead 未登录时是否允许只读访问
+func (s *AuthService) PublicRead() bool {
+	return !s.config.Enabled || s.config.PublicRead
+}
+
+// EnsureAdmin 没有任何账号时创建管理员，关闭认证时数据也归属于该账号
+// 未配置密码时随机生成一个并返回，由调用方打印到日志，之后应及时修改
+func (s *AuthService) EnsureAdmin() (*model.User, string, error) {
+	count, err := s.userRepo.Count()
+	if err != nil || count > 0 {
+		return nil, "", err
+	}
+
+	password := s.config.AdminPassword
+	generated := ""
+	if password == "" {
+		if password, err = randomToken(12); err != nil {
+			return nil, "", err
+		}
+		generated = password
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.userRepo.Create(strings.TrimSpace(s.config.AdminUsername), string(hash), model.UserRoleAdmin)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, generated, nil
+}
+
+// DefaultUser 未登录访问或关闭认证时使用的账号
+func (s *AuthService) DefaultUser() (*model.User, error) {
+	if s.config.PublicUser != "" {
+		return s.userRepo.GetByUsername(s.config.PublicUser)
+	}
+	return s.userRepo.GetFirstAdmin()
+}
+
+// Login 校验用户名密码并创建会话，返回明文令牌（只在此时可见）
+func (s *AuthService) Login(req model.LoginRequest, source string) (*model.LoginResponse, error) {
+	if s.locked(source) {
+		return nil, ErrTooManyAttempts
+	}
+
+	user, err := s.userRepo.GetByUsername(strings.TrimSpace(req.Username))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	hash := s.dummyHash
+	if user != nil {
+		hash = []byte(user.PasswordHash)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(req.Password)); err != nil || user == nil {
+		s.recordFailure(source)
+		return nil, ErrInvalidCredentials
+	}
+	s.clearFailures(source)
+
+	if err := s.userRepo.DeleteExpiredSessions(); err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &model.Session{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(s.config.SessionTTL)),
+	}
+	if err := s.userRepo.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResponse{Token: token, ExpiresAt: session.ExpiresAt, User: user}, nil
+}
+
+// Authenticate 根据令牌获取当前登录的账号
+func (s *AuthService) Authenticate(token string) (*model.User, error) {
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	session, err := s.userRepo.GetSession(hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUnauthenticated
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUnauthenticated
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Logout 注销会话
+func (s *AuthService) Logout(token string) error {
+	if token == "" {
+		return nil
+	}
+	return s.userRepo.DeleteSession(hashToken(token))
+}
+
+// ChangePassword 修改密码，成功后该账号的所有会话都会失效
+func (s *AuthService) ChangePassword(user *model.User, req model.ChangePasswordRequest) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.UpdatePassword(user.ID, string(hash))
+}
+
+// Status 返回当前认证状态
+func (s *AuthService) Status(user *model.User) *model.AuthStatus {
+	return &model.AuthStatus{
+		Enabled:       s.config.Enabled,
+		PublicRead:    s.PublicRead(),
+		Authenticated: user != nil,
+		User:          user,
+	}
+}
+
+// SessionTTL 会话有效期
+func (s *AuthService) SessionTTL() time.Duration {
+	return time.Duration(s.config.SessionTTL)
+}
+
+// CookieSecure 会话 Cookie 是否仅通过 HTTPS 发送
+func (s *AuthService) CookieSecure() bool {
+	return s.config.CookieSecure
+}
+
+func (s *AuthService) locked(source string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.failures[source]
+	if !ok {
+		return false
+	}
+	if time.Since(f.last) > loginLockout {
+		delete(s.failures, source)
+		return false
+	}
+	return f.count >= maxLoginFailures
+}
+
+// recordFailure 记录一次失败，同时清理已过期的记录，避免不断更换来源地址使记录无限增长
+// 每次登录都要做 bcrypt 比较，遍历的开销可以忽略
+func (s *AuthService) recordFailure(source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, f := range s.failures {
+		if time.Since(f.last) > loginLockout {
+			delete(s.failures, key)
+		}
+	}
+
+	f, ok := s.failures[source]
+	if !ok || time.Since(f.last) > loginLockout {
+		f = &loginFailures{}
+		s.failures[source] = f
+	}
+	f.count++
+	f.last = time.Now()
+}
+
+func (s *AuthService) clearFailures(source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, source)
+}
+
+// randomToken 生成 n 字节的随机令牌，使用 URL 安全的 base64 编码
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken 数据库中只保存令牌的 SHA-256 摘要
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"nav-panel-backend/internal/database"
+	"nav-panel-backend/internal/model"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrBackupNotFound 备份文件不存在
+var ErrBackupNotFound = errors.New("backup not found")
+
+// maxPreRestoreBackups 保留的恢复前安全快照数量
+const maxPreRestoreBackups = 5
+
+const backupTimeLayout = "20060102-150405"
+
+// backupNamePattern 备份文件名，同一秒内创建的同类备份依次加上 -2、-3 等序号
+var backupNamePattern = regexp.MustCompile(`^nav-panel-(\d{8}-\d{6})-(scheduled|manual|pre-restore)(?:-\d+)?\.db$`)
+
+// BackupConfig 自动备份配置
+type BackupConfig struct {
+	Dir        string        // 备份目录
+	Interval   time.Duration // 自动备份间隔，为0时不启用定时备份
+	KeepDaily  int           // 保留最近多少天的每日备份
+	KeepWeekly int           // 保留最近多少周的每周备份
+}
+
+type BackupService struct {
+	db     *sql.DB
+	config BackupConfig
+	mu     sync.Mutex
+}
+
+func NewBackupService(db *sql.DB, config BackupConfig) *BackupService {
+	return &BackupService{db: db, config: config}
+}
+
+// Start 启动定时备份，ctx 取消时停止
+func (s *BackupService) Start(ctx context.Context) {
+	if s.config.Interval <= 0 {
+		return
+	}
+
+	// 距上次自动备份已超过间隔时立即备份，否则等到下一个周期
+	wait := time.Duration(0)
+	if latest := s.latest(model.BackupKindScheduled); latest != nil {
+		if elapsed := time.Since(latest.CreatedAt); elapsed < s.config.Interval {
+			wait = s.config.Interval - elapsed
+		}
+	}
+
+	go func() {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if backup, err := s.Create(model.BackupKindScheduled); err != nil {
+					log.Println("Scheduled backup failed:", err)
+				} else {
+					log.Println("Scheduled backup created:", backup.Name)
+				}
+				timer.Reset(s.config.Interval)
+			}
+		}
+	}()
+}
+
+// List 列出所有备份，按时间倒序
+func (s *BackupService) List() ([]model.Backup, error) {
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []model.Backup{}, nil
+		}
+		return nil, err
+	}
+
+	backups := []model.Backup{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		backup, ok := parseBackupName(entry.Name())
+		if !ok {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// Create 立即创建一个备份
+func (s *BackupService) Create(kind model.BackupKind) (*model.Backup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.create(kind)
+}
+
+// Path 返回备份文件的路径，只接受 List 中出现的文件名，防止路径穿越
+func (s *BackupService) Path(name string) (string, error) {
+	if _, ok := parseBackupName(name); !ok {
+		return "", ErrBackupNotFound
+	}
+
+	path := filepath.Join(s.config.Dir, name)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrBackupNotFound
+		}
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Restore 从备份恢复数据库，恢复前会先生成一个安全快照
+// 返回该安全快照，便于在恢复结果不符合预期时再恢复回去
+func (s *BackupService) Restore(name string) (*model.Backup, error) {
+	path, err := s.Path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.Verify(path); err != nil {
+		return nil, fmt.Errorf("backup %s is not usable: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	safety, err := s.create(model.BackupKindPreRestore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create safety snapshot, restore aborted: %w", err)
+	}
+
+	if err := database.Restore(s.db, path); err != nil {
+		return safety, fmt.Errorf("restore failed: %w", err)
+	}
+
+	// 旧备份可能来自较早的表结构，恢复后补齐迁移
+	if err := database.Migrate(s.db); err != nil {
+		return safety, fmt.Errorf("restored backup but migration failed: %w", err)
+	}
+
+	return safety, nil
+}
+
+func (s *BackupService) create(kind model.BackupKind) (*model.Backup, error) {
+	if err := os.MkdirAll(s.config.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("nav-panel-%s-%s.db", now.Format(backupTimeLayout), kind)
+	path := filepath.Join(s.config.Dir, name)
+	// 同一秒内已有同类备份时加上序号，避免覆盖
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		name = fmt.Sprintf("nav-panel-%s-%s-%d.db", now.Format(backupTimeLayout), kind, i)
+		path = filepath.Join(s.config.Dir, name)
+	}
+
+	if err := database.Snapshot(s.db, path); err != nil {
+		return nil, err
+	}
+
+	backup := &model.Backup{Name: name, Kind: kind, CreatedAt: now}
+	if info, err := os.Stat(path); err == nil {
+		backup.Size = info.Size()
+	}
+
+	if err := s.prune(); err != nil {
+		log.Println("Failed to prune backups:", err)
+	}
+
+	return backup, nil
+}
+
+// prune 按保留策略清理旧备份：
+// 自动备份保留最近 KeepDaily 天每天最新的一份和最近 KeepWeekly 周每周最新的一份，
+// 恢复前快照保留最新的 maxPreRestoreBackups 份，手动备份不会被自动清理
+func (s *BackupService) prune() error {
+	backups, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	preRestore := 0
+
+	for _, b := range backups {
+		switch b.Kind {
+		case model.BackupKindScheduled:
+			day := b.CreatedAt.Format("2006-01-02")
+			if !days[day] && len(days) < s.config.KeepDaily {
+				days[day] = true
+				keep[b.Name] = true
+			}
+
+			year, week := b.CreatedAt.ISOWeek()
+			weekKey := fmt.Sprintf("%d-%02d", year, week)
+			if !weeks[weekKey] && len(weeks) < s.config.KeepWeekly {
+				weeks[weekKey] = true
+				keep[b.Name] = true
+			}
+		case model.BackupKindPreRestore:
+			if preRestore < maxPreRestoreBackups {
+				keep[b.Name] = true
+			}
+			preRestore++
+		default:
+			keep[b.Name] = true
+		}
+	}
+
+	for _, b := range backups {
+		if keep[b.Name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.config.Dir, b.Name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *BackupService) latest(kind model.BackupKind) *model.Backup {
+	backups, err := s.List()
+	if err != nil {
+		return nil
+	}
+
+	for _, b := range backups {
+		if b.Kind == kind {
+			return &b
+		}
+	}
+	return nil
+}
+
+func parseBackupName(name string) (model.Backup, bool) {
+	matches := backupNamePattern.FindStringSubmatch(name)
+	if matches == nil {
+		return model.Backup{}, false
+	}
+
+	createdAt, err := time.ParseInLocation(backupTimeLayout, matches[1], time.Local)
+	if err != nil {
+		return model.Backup{}, false
+	}
+
+	return model.Backup{
+		Name:      name,
+		Kind:      model.BackupKind(matches[2]),
+		CreatedAt: createdAt,
+	}, true
+}
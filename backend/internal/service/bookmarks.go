@@ -0,0 +1,359 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"nav-panel-backend/internal/model"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrInvalidBookmarks 上传的文件不是可识别的 Netscape 书签文件
+var ErrInvalidBookmarks = errors.New("invalid bookmark file")
+
+// bookmarkFolderSeparator 嵌套超过分组层级上限的文件夹合并为一个分组时，分组名使用的分隔符
+const bookmarkFolderSeparator = " / "
+
+// bookmarkFolder 书签文件夹
+type bookmarkFolder struct {
+	name    string
+	addedAt time.Time
+}
+
+// ImportBookmarks 导入 Netscape 格式的浏览器书签，文件夹转换为分组，链接转换为网站
+func (s *SettingsService) ImportBookmarks(ctx context.Context, userID string, r io.Reader, opts model.ImportOptions) (*model.ImportReport, error) {
+	data, skipped, err := ParseBookmarks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.Import(ctx, userID, data, opts)
+	if report != nil {
+		report.Websites.Skipped = append(report.Websites.Skipped, skipped...)
+	}
+	return report, err
+}
+
+// ExportBookmarks 导出为 Netscape 格式的书签文件，可被主流浏览器导入
+func (s *SettingsService) ExportBookmarks(userID string) ([]byte, error) {
+	groups, err := s.groupRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	websites, err := s.websiteRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.inlineWebsiteIcons(websites); err != nil {
+		return nil, err
+	}
+
+	return RenderBookmarks(groups, websites), nil
+}
+
+// ParseBookmarks 解析 Netscape 书签HTML
+// 返回的数据中分组和网站保持书签中的顺序，非 http/https 链接（如书签小工具）会被跳过并单独返回
+func ParseBookmarks(r io.Reader) (*model.ImportData, []model.ImportItem, error) {
+	z := xhtml.NewTokenizer(r)
+
+	data := &model.ImportData{
+		Groups:   []model.Group{},
+		Websites: []model.Website{},
+	}
+	var skipped []model.ImportItem
+
+	// folders 为当前所在的文件夹路径，每个 <DL> 对应一层
+	var folders []bookmarkFolder
+	groupIDs := make(map[string]string)
+	groupOrders := make(map[string]int)
+	sortOrders := make(map[string]int)
+
+	var (
+		text          strings.Builder
+		capturing     bool
+		pendingFolder *bookmarkFolder
+		folderAddedAt time.Time
+		toolbarFolder bool
+		link          *model.Website
+		lastWasLink   bool
+		description   *model.Website
+	)
+
+	// groupFor 返回当前文件夹对应的分组，文件夹路径上的每一层都对应一个子分组
+	groupFor := func() *string {
+		var path []bookmarkFolder
+		for _, f := range folders {
+			if f.name != "" {
+				path = append(path, f)
+			}
+		}
+		if len(path) == 0 {
+			return nil
+		}
+
+		// 超出层级上限的文件夹合并到最深一层
+		if len(path) > maxGroupDepth {
+			names := make([]string, 0, len(path)-maxGroupDepth+1)
+			for _, f := range path[maxGroupDepth-1:] {
+				names = append(names, f.name)
+			}
+			merged := bookmarkFolder{name: strings.Join(names, bookmarkFolderSeparator), addedAt: path[len(path)-1].addedAt}
+			path = append(path[:maxGroupDepth-1:maxGroupDepth-1], merged)
+		}
+
+		var parent *string
+		key := ""
+		for _, f := range path {
+			parentKey := key
+			key += "\x00" + f.name
+			id, ok := groupIDs[key]
+			if !ok {
+				id = uuid.New().String()
+				groupIDs[key] = id
+				data.Groups = append(data.Groups, model.Group{
+					ID:        id,
+					Name:      f.name,
+					ParentId:  parent,
+					SortOrder: groupOrders[parentKey],
+					CreatedAt: f.addedAt,
+				})
+				groupOrders[parentKey]++
+			}
+			parent = &id
+		}
+		return parent
+	}
+
+	finishDescription := func() {
+		if description != nil {
+			if desc := strings.TrimSpace(text.String()); desc != "" {
+				description.Description = &desc
+			}
+			description = nil
+			capturing = false
+		}
+	}
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			if z.Err() == io.EOF {
+				finishDescription()
+				if len(data.Groups) == 0 && len(data.Websites) == 0 && len(skipped) == 0 {
+					return nil, nil, fmt.Errorf("%w: no bookmarks found, expected a Netscape bookmark file", ErrInvalidBookmarks)
+				}
+				return data, skipped, nil
+			}
+			return nil, nil, z.Err()
+
+		case xhtml.TextToken:
+			if capturing {
+				text.Write(z.Text())
+			}
+
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := atom.Lookup(name)
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(val)
+			}
+
+			switch tag {
+			case atom.Dt, atom.Dl:
+				finishDescription()
+				if tag == atom.Dl {
+					folder := bookmarkFolder{}
+					if pendingFolder != nil {
+						folder = *pendingFolder
+						pendingFolder = nil
+					}
+					folders = append(folders, folder)
+				}
+
+			case atom.H3:
+				finishDescription()
+				// 浏览器的书签栏根目录不作为分组名的一部分
+				toolbarFolder = attrs["personal_toolbar_folder"] == "true"
+				folderAddedAt = parseBookmarkDate(attrs["add_date"])
+				text.Reset()
+				capturing = true
+
+			case atom.A:
+				finishDescription()
+				link = &model.Website{
+					URL:       strings.TrimSpace(attrs["href"]),
+					CreatedAt: parseBookmarkDate(attrs["add_date"]),
+				}
+				if icon := attrs["icon"]; strings.HasPrefix(icon, "data:image/") {
+					link.Icon = &icon
+				}
+				text.Reset()
+				capturing = true
+
+			case atom.Dd:
+				// <DD> 紧跟在链接之后时为该链接的描述
+				if n := len(data.Websites); n > 0 && lastWasLink {
+					description = &data.Websites[n-1]
+					text.Reset()
+					capturing = true
+				}
+			}
+
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.H3:
+				folder := bookmarkFolder{name: strings.TrimSpace(text.String()), addedAt: folderAddedAt}
+				if toolbarFolder {
+					folder.name = ""
+				}
+				capturing = false
+				pendingFolder = &folder
+				lastWasLink = false
+
+			case atom.A:
+				if link == nil {
+					continue
+				}
+				capturing = false
+				lastWasLink = false
+				link.Name = strings.TrimSpace(text.String())
+				if !isValidWebURL(link.URL) {
+					skipped = append(skipped, model.ImportItem{Name: link.Name, Reason: fmt.Sprintf("unsupported bookmark url '%s'", link.URL)})
+					link = nil
+					continue
+				}
+				if link.Name == "" {
+					if u, err := url.Parse(link.URL); err == nil {
+						link.Name = u.Hostname()
+					}
+				}
+
+				link.GroupId = groupFor()
+				key := ""
+				if link.GroupId != nil {
+					key = *link.GroupId
+				}
+				link.SortOrder = sortOrders[key]
+				sortOrders[key]++
+
+				data.Websites = append(data.Websites, *link)
+				link = nil
+				lastWasLink = true
+
+			case atom.Dl:
+				finishDescription()
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			}
+		}
+	}
+}
+
+// parseBookmarkDate 解析 ADD_DATE，兼容秒、毫秒和微秒级时间戳
+func parseBookmarkDate(value string) time.Time {
+	ts, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || ts <= 0 {
+		return time.Time{}
+	}
+
+	switch {
+	case ts > 1e15:
+		return time.UnixMicro(ts)
+	case ts > 1e12:
+		return time.UnixMilli(ts)
+	default:
+		return time.Unix(ts, 0)
+	}
+}
+
+// RenderBookmarks 生成 Netscape 书签HTML，分组对应文件夹，子分组对应嵌套的文件夹，未分组的网站位于顶层
+func RenderBookmarks(groups []model.Group, websites []model.Website) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n")
+	buf.WriteString("<!-- This is an automatically generated file.\n     It will be read and overwritten.\n     DO NOT EDIT! -->\n")
+	buf.WriteString("<META HTTP-EQUIV=\"Content-Type\" CONTENT=\"text/html; charset=UTF-8\">\n")
+	buf.WriteString("<TITLE>Bookmarks</TITLE>\n<H1>Bookmarks</H1>\n<DL><p>\n")
+
+	byGroup := make(map[string][]model.Website)
+	for _, w := range websites {
+		key := ""
+		if w.GroupId != nil {
+			key = *w.GroupId
+		}
+		byGroup[key] = append(byGroup[key], w)
+	}
+
+	// 父分组不存在的分组作为顶层文件夹
+	ids := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		ids[g.ID] = true
+	}
+	children := make(map[string][]model.Group)
+	for _, g := range groups {
+		parent := ""
+		if g.ParentId != nil && ids[*g.ParentId] {
+			parent = *g.ParentId
+		}
+		children[parent] = append(children[parent], g)
+	}
+
+	written := make(map[string]bool)
+	var writeFolders func(parent, indent string)
+	writeFolders = func(parent, indent string) {
+		for _, g := range children[parent] {
+			if written[g.ID] {
+				continue
+			}
+			written[g.ID] = true
+			fmt.Fprintf(&buf, "%s<DT><H3 ADD_DATE=\"%d\" LAST_MODIFIED=\"%d\">%s</H3>\n", indent, g.CreatedAt.Unix(), g.UpdatedAt.Unix(), html.EscapeString(g.Name))
+			buf.WriteString(indent + "<DL><p>\n")
+			writeFolders(g.ID, indent+"    ")
+			for _, w := range byGroup[g.ID] {
+				writeBookmark(&buf, indent+"    ", w)
+			}
+			buf.WriteString(indent + "</DL><p>\n")
+			delete(byGroup, g.ID)
+		}
+	}
+	writeFolders("", "    ")
+
+	// 未分组以及引用了不存在分组的网站放在顶层
+	for _, w := range websites {
+		if w.GroupId == nil {
+			writeBookmark(&buf, "    ", w)
+		} else if _, ok := byGroup[*w.GroupId]; ok {
+			writeBookmark(&buf, "    ", w)
+		}
+	}
+
+	buf.WriteString("</DL><p>\n")
+	return buf.Bytes()
+}
+
+func writeBookmark(buf *bytes.Buffer, indent string, w model.Website) {
+	fmt.Fprintf(buf, "%s<DT><A HREF=\"%s\" ADD_DATE=\"%d\"", indent, html.EscapeString(w.URL), w.CreatedAt.Unix())
+	if w.Icon != nil && strings.HasPrefix(*w.Icon, "data:image/") {
+		fmt.Fprintf(buf, " ICON=\"%s\"", html.EscapeString(*w.Icon))
+	}
+	fmt.Fprintf(buf, ">%s</A>\n", html.EscapeString(w.Name))
+	if w.Description != nil && *w.Description != "" {
+		fmt.Fprintf(buf, "%s<DD>%s\n", indent, html.EscapeString(*w.Description))
+	}
+}
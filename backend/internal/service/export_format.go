@@ -0,0 +1,162 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"strings"
+)
+
+// importUpgraders 将某一版本的导出数据升级到下一版本，键为升级前的版本号
+var importUpgraders = map[int]func(raw []byte) ([]byte, error){
+	1: upgradeExportV1,
+	2: upgradeExportV2,
+	3: upgradeExportV3,
+}
+
+// DecodeImportData 解析导入数据，旧版本格式会逐级升级为当前格式
+func DecodeImportData(raw []byte) (*model.ImportData, error) {
+	var envelope struct {
+		FormatVersion int `json:"formatVersion"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid import data: %w", err)
+	}
+
+	// 第一版导出数据没有 formatVersion 字段
+	formatVersion := envelope.FormatVersion
+	if formatVersion == 0 {
+		formatVersion = 1
+	}
+	if formatVersion > model.ExportFormatVersion {
+		return nil, fmt.Errorf("unsupported format version %d, this server supports up to %d", formatVersion, model.ExportFormatVersion)
+	}
+
+	for ; formatVersion < model.ExportFormatVersion; formatVersion++ {
+		upgrade, ok := importUpgraders[formatVersion]
+		if !ok {
+			return nil, fmt.Errorf("no upgrade path from format version %d", formatVersion)
+		}
+
+		var err error
+		raw, err = upgrade(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade format version %d: %w", formatVersion, err)
+		}
+	}
+
+	var data model.ImportData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("invalid import data: %w", err)
+	}
+
+	return &data, nil
+}
+
+// upgradeExportV1 第一版将设置导出为 app_settings 原始行，各配置项是JSON字符串
+func upgradeExportV1(raw []byte) ([]byte, error) {
+	var v1 struct {
+		Websites      json.RawMessage `json:"websites"`
+		SearchEngines json.RawMessage `json:"searchEngines"`
+		Settings      *struct {
+			Theme      string `json:"theme"`
+			Layout     string `json:"layout"`
+			Background string `json:"background"`
+			CardStyle  string `json:"cardStyle"`
+			Search     string `json:"search"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal(raw, &v1); err != nil {
+		return nil, err
+	}
+
+	v2 := map[string]interface{}{
+		"formatVersion": 2,
+		"websites":      v1.Websites,
+		"searchEngines": v1.SearchEngines,
+	}
+
+	if v1.Settings != nil {
+		settings := map[string]interface{}{
+			"theme": v1.Settings.Theme,
+		}
+
+		fields := []struct {
+			name  string
+			value string
+		}{
+			{"layout", v1.Settings.Layout},
+			{"background", v1.Settings.Background},
+			{"cardStyle", v1.Settings.CardStyle},
+			{"search", v1.Settings.Search},
+		}
+		for _, f := range fields {
+			if f.value == "" {
+				continue
+			}
+			if !json.Valid([]byte(f.value)) {
+				return nil, fmt.Errorf("settings.%s is not valid JSON", f.name)
+			}
+			settings[f.name] = json.RawMessage(f.value)
+		}
+
+		v2["settings"] = settings
+	}
+
+	return json.Marshal(v2)
+}
+
+// upgradeExportV2 第二版的网站使用自由文本的 category，转换为同名标签
+func upgradeExportV2(raw []byte) ([]byte, error) {
+	var v2 map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &v2); err != nil {
+		return nil, err
+	}
+
+	if websitesRaw, ok := v2["websites"]; ok && string(websitesRaw) != "null" {
+		var websites []map[string]json.RawMessage
+		if err := json.Unmarshal(websitesRaw, &websites); err != nil {
+			return nil, fmt.Errorf("websites: %w", err)
+		}
+
+		for _, w := range websites {
+			var category *string
+			if categoryRaw, ok := w["category"]; ok {
+				if err := json.Unmarshal(categoryRaw, &category); err != nil {
+					return nil, fmt.Errorf("websites.category: %w", err)
+				}
+				delete(w, "category")
+			}
+
+			tags := []model.Tag{}
+			if category != nil && strings.TrimSpace(*category) != "" {
+				tags = append(tags, model.Tag{Name: *category})
+			}
+			encoded, err := json.Marshal(tags)
+			if err != nil {
+				return nil, err
+			}
+			w["tags"] = encoded
+		}
+
+		encoded, err := json.Marshal(websites)
+		if err != nil {
+			return nil, err
+		}
+		v2["websites"] = encoded
+	}
+
+	v2["formatVersion"] = json.RawMessage("3")
+	return json.Marshal(v2)
+}
+
+// upgradeExportV3 第三版没有搜索引擎分类，新增的搜索引擎字段均可省略，只需更新版本号
+func upgradeExportV3(raw []byte) ([]byte, error) {
+	var v3 map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &v3); err != nil {
+		return nil, err
+	}
+
+	v3["formatVersion"] = json.RawMessage("4")
+	return json.Marshal(v3)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrFetchAddressBlocked 服务端请求的目标地址不允许访问
+var ErrFetchAddressBlocked = errors.New("address is not allowed")
+
+// maxFetchRedirects 服务端请求最多跟随的重定向次数
+const maxFetchRedirects = 10
+
+// FetchConfig 服务端请求外部网址（预览、图标发现、健康检查）的访问限制
+type FetchConfig struct {
+	AllowPrivateNetworks bool // 允许访问回环和内网地址，链路本地地址（包括云服务的元数据地址）始终禁止
+}
+
+// NewFetchClient 创建服务端请求外部网址使用的 HTTP 客户端
+// 在 DNS 解析之后按实际连接的 IP 检查目标地址，重定向和解析到内网的域名同样受限；
+// 不使用环境变量中的代理，否则检查的是代理的地址而不是目标地址
+func NewFetchClient(config FetchConfig) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			return checkFetchAddress(address, config.AllowPrivateNetworks)
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// checkFetchAddress 检查即将连接的 IP，address 为解析后的 ip:port
+func checkFetchAddress(address string, allowPrivate bool) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrFetchAddressBlocked, address)
+	}
+
+	ip := addrPort.Addr().Unmap()
+	switch {
+	// 0.0.0.0/8 在 Linux 上会连接到本机
+	case ip.IsUnspecified(), ip.Is4() && ip.As4()[0] == 0, ip.IsLinkLocalUnicast(), ip.IsMulticast():
+		return fmt.Errorf("%w: %s", ErrFetchAddressBlocked, ip)
+	case !allowPrivate && (ip.IsLoopback() || ip.IsPrivate()):
+		return fmt.Errorf("%w: %s is a private address", ErrFetchAddressBlocked, ip)
+	}
+	return nil
+}
@@ -1,68 +1,325 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/repository"
 )
 
+var (
+	// ErrGroupNotFound 分组不存在
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrGroupCycle 分组不能移到自身或自己的子孙分组下
+	ErrGroupCycle = errors.New("a group cannot be moved into itself or one of its sub-groups")
+	// ErrGroupTooDeep 分组嵌套超过层级上限
+	ErrGroupTooDeep = errors.New("groups are nested too deep")
+	// ErrInvalidGroupReorder 重新排序的分组不属于同一父分组
+	ErrInvalidGroupReorder = errors.New("groups being reordered must share the same parent")
+	// ErrInvalidGroupDeleteMode 未知的子分组处理方式
+	ErrInvalidGroupDeleteMode = errors.New("children must be 'promote' or 'cascade'")
+)
+
+// maxGroupDepth 分组嵌套的层级上限，顶层分组为第 1 层
+const maxGroupDepth = 5
+
 type GroupService struct {
-	repo *repository.GroupRepository
+	repo        *repository.GroupRepository
+	iconService *IconService
 }
 
-func NewGroupService(repo *repository.GroupRepository) *GroupService {
-	return &GroupService{repo: repo}
+func NewGroupService(repo *repository.GroupRepository, iconService *IconService) *GroupService {
+	return &GroupService{repo: repo, iconService: iconService}
 }
 
 // GetAllGroups 获取所有分组
-func (s *GroupService) GetAllGroups() ([]model.Group, error) {
-	return s.repo.GetAll()
+func (s *GroupService) GetAllGroups(userID string) ([]model.Group, error) {
+	return s.repo.GetAll(userID)
 }
 
 // GetAllGroupsWithWebsites 获取所有分组及其网站
-func (s *GroupService) GetAllGroupsWithWebsites() ([]model.GroupWithWebsites, error) {
-	return s.repo.GetAllWithWebsites()
+func (s *GroupService) GetAllGroupsWithWebsites(userID string) ([]model.GroupWithWebsites, error) {
+	return s.repo.GetAllWithWebsites(userID)
+}
+
+// GetGroupTree 获取嵌套的分组树，每个节点包含分组的网站和按排序排列的子分组
+func (s *GroupService) GetGroupTree(userID string) ([]model.GroupTreeNode, error) {
+	groups, err := s.repo.GetAllWithWebsites(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		ids[g.ID] = true
+	}
+
+	// 分组已按 sort_order 排序，按父分组归类后各层级的顺序保持不变
+	// 父分组不存在的分组作为顶层分组，避免因数据异常而丢失
+	children := make(map[string][]model.GroupWithWebsites)
+	for _, g := range groups {
+		parent := ""
+		if g.ParentId != nil && ids[*g.ParentId] {
+			parent = *g.ParentId
+		}
+		children[parent] = append(children[parent], g)
+	}
+
+	visited := make(map[string]bool)
+	var build func(parent string) []model.GroupTreeNode
+	build = func(parent string) []model.GroupTreeNode {
+		nodes := []model.GroupTreeNode{}
+		for _, g := range children[parent] {
+			if visited[g.ID] {
+				continue
+			}
+			visited[g.ID] = true
+			nodes = append(nodes, model.GroupTreeNode{GroupWithWebsites: g, Children: build(g.ID)})
+		}
+		return nodes
+	}
+
+	return build(""), nil
 }
 
-// CreateGroup 创建分组
-func (s *GroupService) CreateGroup(req model.CreateGroupRequest) (*model.Group, error) {
+// CreateGroup 创建分组，指定 ParentId 时创建为该分组的子分组
+func (s *GroupService) CreateGroup(userID string, req model.CreateGroupRequest) (*model.Group, error) {
 	// 业务逻辑验证
 	if req.Name == "" {
 		return nil, fmt.Errorf("分组名称不能为空")
 	}
 
-	return s.repo.Create(req)
+	if req.ParentId != nil {
+		groups, err := s.repo.GetAll(userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := newGroupForest(groups).checkPlacement("", req.ParentId); err != nil {
+			return nil, err
+		}
+	}
+
+	icon, err := s.iconService.StoreRef(req.Icon)
+	if err != nil {
+		return nil, err
+	}
+	req.Icon = icon
+
+	return s.repo.Create(userID, req)
 }
 
 // UpdateGroup 更新分组
-func (s *GroupService) UpdateGroup(id string, req model.UpdateGroupRequest) (*model.Group, error) {
+func (s *GroupService) UpdateGroup(userID, id string, req model.UpdateGroupRequest) (*model.Group, error) {
 	// 业务逻辑验证
 	if req.Name != nil && *req.Name == "" {
 		return nil, fmt.Errorf("分组名称不能为空")
 	}
 
-	return s.repo.Update(id, req)
+	icon, err := s.iconService.StoreRef(req.Icon)
+	if err != nil {
+		return nil, err
+	}
+	req.Icon = icon
+
+	return s.repo.Update(userID, id, req)
+}
+
+// DeleteGroup 把分组及其中的网站移入回收站，mode 决定子分组是提升一级还是一起删除
+func (s *GroupService) DeleteGroup(userID, id string, mode model.GroupDeleteMode) error {
+	if mode != model.GroupDeletePromote && mode != model.GroupDeleteCascade {
+		return ErrInvalidGroupDeleteMode
+	}
+
+	if err := s.repo.Trash(userID, id, mode == model.GroupDeleteCascade); err == sql.ErrNoRows {
+		return ErrGroupNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetTrash 获取回收站中的分组
+func (s *GroupService) GetTrash(userID string) ([]model.TrashedGroup, error) {
+	return s.repo.GetTrash(userID)
+}
+
+// RestoreGroup 从回收站恢复分组及随之一起删除的网站和子孙分组
+// 原父分组仍然存在且恢复后不超出层级上限时回到原位置，否则作为顶层分组恢复
+func (s *GroupService) RestoreGroup(userID, id string) (*model.Group, error) {
+	trashed, err := s.repo.GetTrashByID(userID, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotInTrash
+	} else if err != nil {
+		return nil, err
+	}
+
+	parentID := trashed.ParentId
+	if parentID != nil {
+		groups, err := s.repo.GetAll(userID)
+		if err != nil {
+			return nil, err
+		}
+		subgroups, err := s.repo.GetTrashedSubgroups(userID, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// 把要恢复的子树加入现有分组中检查层级深度
+		root := trashed.Group
+		root.ParentId = nil
+		forest := newGroupForest(append(append(groups, root), subgroups...))
+		if forest.checkPlacement(id, parentID) != nil {
+			parentID = nil
+		}
+	}
+
+	if err := s.repo.Restore(userID, id, parentID); err == sql.ErrNoRows {
+		return nil, ErrNotInTrash
+	} else if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(userID, id)
 }
 
-// DeleteGroup 删除分组
-func (s *GroupService) DeleteGroup(id string) error {
-	return s.repo.Delete(id)
+// PurgeGroup 永久删除回收站中的分组及随之一起删除的网站和子孙分组
+func (s *GroupService) PurgeGroup(userID, id string) error {
+	if err := s.repo.Purge(userID, id); err == sql.ErrNoRows {
+		return ErrNotInTrash
+	} else if err != nil {
+		return err
+	}
+	return nil
 }
 
-// ReorderGroups 重新排序分组
-func (s *GroupService) ReorderGroups(groupIds []string) error {
+// EmptyTrash 清空回收站中的分组，返回删除的分组数量
+func (s *GroupService) EmptyTrash(userID string) (int64, error) {
+	return s.repo.EmptyTrash(userID)
+}
+
+// MoveGroup 把分组移到新的父分组下的指定位置，也可以只在同一层级内调整位置
+func (s *GroupService) MoveGroup(userID, id string, req model.MoveGroupRequest) (*model.Group, error) {
+	groups, err := s.repo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	forest := newGroupForest(groups)
+	if _, ok := forest[id]; !ok {
+		return nil, ErrGroupNotFound
+	}
+	if err := forest.checkPlacement(id, req.ParentId); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Move(userID, id, req.ParentId, req.Position); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(userID, id)
+}
+
+// ReorderGroups 重新排序同一父分组下的分组
+func (s *GroupService) ReorderGroups(userID string, groupIds []string) error {
 	if len(groupIds) == 0 {
 		return fmt.Errorf("分组ID列表不能为空")
 	}
 
-	return s.repo.Reorder(groupIds)
+	groups, err := s.repo.GetAll(userID)
+	if err != nil {
+		return err
+	}
+	forest := newGroupForest(groups)
+	for _, id := range groupIds {
+		parent, ok := forest[id]
+		if !ok {
+			return ErrGroupNotFound
+		}
+		if !equalStringPtr(parent, forest[groupIds[0]]) {
+			return ErrInvalidGroupReorder
+		}
+	}
+
+	return s.repo.Reorder(userID, groupIds)
 }
 
 // MoveWebsiteToGroup 移动网站到分组
-func (s *GroupService) MoveWebsiteToGroup(websiteId string, groupId *string, position *int) error {
+func (s *GroupService) MoveWebsiteToGroup(userID, websiteId string, groupId *string, position *int) error {
 	if websiteId == "" {
 		return fmt.Errorf("网站ID不能为空")
 	}
 
-	return s.repo.MoveWebsiteToGroup(websiteId, groupId, position)
-}
\ No newline at end of file
+	// 只能移动到自己的分组
+	if groupId != nil {
+		if _, err := s.repo.GetByID(userID, *groupId); err != nil {
+			return fmt.Errorf("分组不存在: %w", err)
+		}
+	}
+
+	return s.repo.MoveWebsiteToGroup(userID, websiteId, groupId, position)
+}
+
+// groupForest 分组ID到父分组ID的映射，用于检查移动分组是否会形成循环或超出层级上限
+type groupForest map[string]*string
+
+func newGroupForest(groups []model.Group) groupForest {
+	forest := make(groupForest, len(groups))
+	for _, g := range groups {
+		forest[g.ID] = g.ParentId
+	}
+	return forest
+}
+
+// depth 分组所在的层级，顶层分组为 1；数据中存在循环时在遍历所有分组后停止
+func (f groupForest) depth(id string) int {
+	depth := 1
+	for parent := f[id]; parent != nil && depth <= len(f); parent = f[*parent] {
+		depth++
+	}
+	return depth
+}
+
+// isAncestor 判断 ancestor 是否为 id 自身或其祖先分组
+func (f groupForest) isAncestor(ancestor, id string) bool {
+	current := &id
+	for i := 0; current != nil && i <= len(f); i++ {
+		if *current == ancestor {
+			return true
+		}
+		current = f[*current]
+	}
+	return false
+}
+
+// height 以分组为根的子树的层数，没有子分组时为 1
+func (f groupForest) height(id string) int {
+	height := 1
+	base := f.depth(id)
+	for other := range f {
+		if other != id && f.isAncestor(id, other) {
+			height = max(height, f.depth(other)-base+1)
+		}
+	}
+	return height
+}
+
+// checkPlacement 检查把分组 id（新建分组时为空）放到 parentID 下是否合法
+func (f groupForest) checkPlacement(id string, parentID *string) error {
+	if parentID == nil {
+		return nil
+	}
+	if _, ok := f[*parentID]; !ok {
+		return fmt.Errorf("parent %w", ErrGroupNotFound)
+	}
+
+	height := 1
+	if id != "" {
+		if f.isAncestor(id, *parentID) {
+			return ErrGroupCycle
+		}
+		height = f.height(id)
+	}
+	if f.depth(*parentID)+height > maxGroupDepth {
+		return fmt.Errorf("%w: at most %d levels are allowed", ErrGroupTooDeep, maxGroupDepth)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"nav-panel-backend/internal/version"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrWebsiteNotFound 网站不存在
+var ErrWebsiteNotFound = errors.New("website not found")
+
+// HealthConfig 链接健康检查配置
+type HealthConfig struct {
+	Interval    time.Duration // 定时检查间隔，为0时不启用定时检查
+	Concurrency int           // 同时检查的网站数量
+	Timeout     time.Duration // 单个网站的检查超时，包括重定向
+}
+
+// HealthService 定期探测网站链接是否可用
+type HealthService struct {
+	repo   *repository.HealthRepository
+	config HealthConfig
+	client *http.Client
+
+	// running 防止定时检查和手动触发的全量检查重叠
+	running sync.Mutex
+}
+
+func NewHealthService(repo *repository.HealthRepository, config HealthConfig, client *http.Client) *HealthService {
+	return &HealthService{repo: repo, config: config, client: client}
+}
+
+// Start 启动定时检查，ctx 取消时停止
+func (s *HealthService) Start(ctx context.Context) {
+	if s.config.Interval <= 0 {
+		return
+	}
+
+	// 距上次检查已超过间隔时立即检查，否则等到下一个周期
+	wait := time.Duration(0)
+	if last, err := s.repo.LastCheckedAt(); err == nil && !last.IsZero() {
+		if elapsed := time.Since(last); elapsed < s.config.Interval {
+			wait = s.config.Interval - elapsed
+		}
+	}
+
+	go func() {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if checked, err := s.CheckAll(ctx); err != nil {
+					log.Println("Link health check failed:", err)
+				} else {
+					log.Printf("Link health check finished, %d websites checked", checked)
+				}
+				timer.Reset(s.config.Interval)
+			}
+		}
+	}()
+}
+
+// GetAll 获取账号下所有网站的健康状态
+func (s *HealthService) GetAll(userID string) ([]model.WebsiteHealth, error) {
+	result, err := s.repo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	return emptyIfNil(result), nil
+}
+
+// CheckAll 检查所有账号的所有网站，返回检查的网站数量
+func (s *HealthService) CheckAll(ctx context.Context) (int, error) {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	if err := s.repo.PruneOrphans(); err != nil {
+		return 0, err
+	}
+
+	targets, err := s.repo.Targets()
+	if err != nil {
+		return 0, err
+	}
+
+	sem := make(chan struct{}, s.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		target := target
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return 0, ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := s.repo.Save(s.probe(ctx, target)); err != nil {
+				log.Printf("Failed to save health check result for %s: %v", target.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return len(targets), nil
+}
+
+// Check 立即检查账号下的单个网站并返回结果
+func (s *HealthService) Check(ctx context.Context, userID, websiteID string) (*model.WebsiteHealth, error) {
+	current, err := s.repo.GetByWebsiteID(userID, websiteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebsiteNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.repo.Save(s.probe(ctx, model.HealthTarget{ID: current.WebsiteID, URL: current.URL})); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByWebsiteID(userID, websiteID)
+}
+
+// probe 先发送 HEAD 请求，失败或返回错误状态时改用 GET 重试，部分网站不支持 HEAD
+func (s *HealthService) probe(ctx context.Context, target model.HealthTarget) *model.WebsiteHealth {
+	result := s.request(ctx, http.MethodHead, target)
+	if result.Error != nil || *result.StatusCode >= 400 {
+		result = s.request(ctx, http.MethodGet, target)
+	}
+	return result
+}
+
+func (s *HealthService) request(ctx context.Context, method string, target model.HealthTarget) *model.WebsiteHealth {
+	checkedAt := time.Now()
+	result := &model.WebsiteHealth{WebsiteID: target.ID, URL: target.URL, CheckedAt: &checkedAt}
+	fail := func(err error) *model.WebsiteHealth {
+		message := err.Error()
+		result.Error = &message
+		result.SetHealthy()
+		return result
+	}
+
+	// 超时包括重定向和读取响应体
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, target.URL, nil)
+	if err != nil {
+		return fail(err)
+	}
+	req.Header.Set("User-Agent", "nav-panel-health-check/"+version.Version)
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	// 只需要状态码，读取少量响应体以便连接复用
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	latency := time.Since(start).Milliseconds()
+	finalURL := resp.Request.URL.String()
+	result.StatusCode = &resp.StatusCode
+	result.LatencyMs = &latency
+	result.FinalURL = &finalURL
+	result.SetHealthy()
+
+	return result
+}
@@ -0,0 +1,586 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"nav-panel-backend/internal/version"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	xhtml "golang.org/x/net/html"
+)
+
+var (
+	// ErrIconNotFound 图标不存在
+	ErrIconNotFound = errors.New("icon not found")
+	// ErrInvalidIcon 图标无法解析或不是图片
+	ErrInvalidIcon = errors.New("invalid icon")
+)
+
+const (
+	// maxIconPageSize 发现图标时最多读取的页面大小，<head> 通常在开头
+	maxIconPageSize = 1 << 20
+	// maxManifestSize web manifest 的大小上限
+	maxManifestSize = 256 << 10
+	// iconDiscoveryConcurrency 同时在后台发现图标的网站数量
+	iconDiscoveryConcurrency = 4
+	// iconRefreshBatch 每次定时刷新最多处理的网站数量
+	iconRefreshBatch = 50
+	// iconRefreshTick 定时刷新检查间隔，每次只处理已过期的网站
+	iconRefreshTick = time.Hour
+)
+
+// IconConfig 网站图标自动发现配置
+type IconConfig struct {
+	Discovery       bool          // 创建网站或修改 URL 时自动发现图标
+	RefreshInterval time.Duration // 自动发现的图标多久重新获取一次，为0时不定时刷新
+	Timeout         time.Duration // 单个网站的图标发现超时
+	MaxSize         int64         // 单个图标文件上限
+}
+
+// IconService 发现网站图标并缓存到本地
+type IconService struct {
+	repo   *repository.IconRepository
+	config IconConfig
+	client *http.Client
+
+	// sem 限制后台同时发现图标的网站数量
+	sem chan struct{}
+}
+
+func NewIconService(repo *repository.IconRepository, config IconConfig, client *http.Client) *IconService {
+	return &IconService{
+		repo:   repo,
+		config: config,
+		client: client,
+		sem:    make(chan struct{}, iconDiscoveryConcurrency),
+	}
+}
+
+// Start 启动定时刷新，ctx 取消时停止
+// 每次只处理超过刷新间隔的网站，从未获取过图标的网站也会在这里补上
+func (s *IconService) Start(ctx context.Context) {
+	if s.config.RefreshInterval <= 0 {
+		return
+	}
+
+	go func() {
+		timer := time.NewTimer(time.Minute)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if refreshed, err := s.RefreshStale(ctx); err != nil {
+					log.Println("Icon refresh failed:", err)
+				} else if refreshed > 0 {
+					log.Printf("Icon refresh finished, %d websites refreshed", refreshed)
+				}
+				timer.Reset(iconRefreshTick)
+			}
+		}
+	}()
+}
+
+// Get 根据哈希获取图标
+func (s *IconService) Get(hash string) (*model.Icon, error) {
+	icon, err := s.repo.Get(hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIconNotFound
+		}
+		return nil, err
+	}
+	return icon, nil
+}
+
+// Store 按内容保存图标，相同内容只保存一份
+func (s *IconService) Store(data []byte, contentType string) (*model.Icon, error) {
+	icon := model.NewIcon(data, contentType)
+	if err := s.repo.Save(icon); err != nil {
+		return nil, fmt.Errorf("failed to save icon: %w", err)
+	}
+	return icon, nil
+}
+
+// StoreUpload 标准化上传的图标后存入图标库
+// 按内容识别格式，位图缩放到标准尺寸并重新编码为 PNG，无法解码时返回 ErrInvalidIcon
+func (s *IconService) StoreUpload(data []byte) (*model.Icon, error) {
+	if s.config.MaxSize > 0 && int64(len(data)) > s.config.MaxSize {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrInvalidIcon, s.config.MaxSize)
+	}
+
+	icon, err := normalizeIcon(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Save(icon); err != nil {
+		return nil, fmt.Errorf("failed to save icon: %w", err)
+	}
+	return icon, nil
+}
+
+// StoreRef 把请求中以 DataURL 提交的图标按上传图标处理并替换为引用，其他值原样返回
+func (s *IconService) StoreRef(icon *string) (*string, error) {
+	if icon == nil || !model.IsDataURL(*icon) {
+		return icon, nil
+	}
+
+	_, data, err := model.ParseDataURL(*icon)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIcon, err)
+	}
+	stored, err := s.StoreUpload(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := stored.URL()
+	return &ref, nil
+}
+
+// GetVariant 获取图标不小于 size 的最小缩略图，没有时返回原图
+func (s *IconService) GetVariant(hash string, size int) (*model.Icon, error) {
+	icon, err := s.Get(hash)
+	if err != nil || size <= 0 {
+		return icon, err
+	}
+
+	variant, err := s.repo.GetVariant(hash, size)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return icon, nil
+		}
+		return nil, err
+	}
+
+	icon.ContentType = variant.ContentType
+	icon.Data = variant.Data
+	icon.Size = int64(len(variant.Data))
+	return icon, nil
+}
+
+// Inline 把图标库引用替换为 DataURL，找不到的引用和其他值原样返回
+func (s *IconService) Inline(icon *string) (*string, error) {
+	if icon == nil {
+		return nil, nil
+	}
+	hash, ok := model.IconHashFromRef(*icon)
+	if !ok {
+		return icon, nil
+	}
+
+	stored, err := s.repo.Get(hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return icon, nil
+		}
+		return nil, err
+	}
+
+	dataURL := stored.DataURL()
+	return &dataURL, nil
+}
+
+// UploadResponse 上传图标的返回结果
+func UploadResponse(icon *model.Icon) *model.UploadIconResponse {
+	sizes := make([]int, 0, len(icon.Variants))
+	for _, variant := range icon.Variants {
+		sizes = append(sizes, variant.Size)
+	}
+	return &model.UploadIconResponse{
+		Hash:        icon.Hash,
+		URL:         icon.URL(),
+		ContentType: icon.ContentType,
+		Size:        icon.Size,
+		Sizes:       sizes,
+	}
+}
+
+// Schedule 在后台为网站发现图标，不覆盖用户指定的图标
+func (s *IconService) Schedule(userID, websiteID string) {
+	if !s.config.Discovery {
+		return
+	}
+
+	go func() {
+		s.sem <- struct{}{}
+		defer func() { <-s.sem }()
+
+		target, err := s.repo.GetTarget(userID, websiteID)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("Failed to load website %s for icon discovery: %v", websiteID, err)
+			}
+			return
+		}
+		if !target.AutoIcon() {
+			return
+		}
+
+		if err := s.refresh(context.Background(), target, false); err != nil {
+			log.Printf("Failed to update icon for %s: %v", websiteID, err)
+		}
+	}()
+}
+
+// Refresh 立即为账号下的网站重新发现图标，会覆盖用户指定的图标
+func (s *IconService) Refresh(ctx context.Context, userID, websiteID string) error {
+	target, err := s.repo.GetTarget(userID, websiteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebsiteNotFound
+		}
+		return err
+	}
+
+	// 手动刷新时找不到图标也使用占位图标，而不是保留原来的图标
+	target.Icon = nil
+	target.IconSource = nil
+	return s.refresh(ctx, target, true)
+}
+
+// RefreshStale 刷新超过刷新间隔的网站图标，并清理不再被引用的图标，返回处理的网站数量
+func (s *IconService) RefreshStale(ctx context.Context) (int, error) {
+	now := time.Now()
+	targets, err := s.repo.StaleTargets(now.Add(-s.config.RefreshInterval), iconRefreshBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		target := target
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return 0, ctx.Err()
+		case s.sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-s.sem }()
+
+			if err := s.refresh(ctx, &target, false); err != nil {
+				log.Printf("Failed to update icon for %s: %v", target.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 只清理一小时前保存的图标，避免删掉刚下载、还未写入网站的图标
+	if _, err := s.repo.PruneUnused(now.Add(-time.Hour)); err != nil {
+		return len(targets), err
+	}
+
+	return len(targets), nil
+}
+
+// refresh 发现并保存网站图标
+// 发现失败时，已有自动发现的图标保持不变，否则使用按网站名称生成的占位图标
+func (s *IconService) refresh(ctx context.Context, target *model.IconTarget, force bool) error {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	now := time.Now()
+	icon, err := s.Discover(ctx, target.URL)
+	source := model.IconSourceAuto
+	if err != nil {
+		if target.IconSource != nil && *target.IconSource == model.IconSourceAuto {
+			return s.repo.TouchWebsiteIcon(target.ID, now)
+		}
+
+		if icon, err = s.Store(fallbackIcon(target.Name, target.URL), "image/svg+xml"); err != nil {
+			return err
+		}
+		source = model.IconSourceFallback
+	}
+
+	return s.repo.SetWebsiteIcon(target.ID, icon.URL(), source, now, !force)
+}
+
+// Discover 从网页发现图标并保存到本地
+// 依次尝试页面声明的 <link rel=icon>、apple-touch-icon、web manifest 中的图标和 /favicon.ico
+func (s *IconService) Discover(ctx context.Context, pageURL string) (*model.Icon, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid website url: %s", pageURL)
+	}
+
+	body, finalURL, err := s.fetch(ctx, base.String(), maxIconPageSize)
+	if err != nil {
+		// 页面无法访问时仍然尝试 /favicon.ico
+		return s.DiscoverFromPage(ctx, base, nil)
+	}
+	return s.DiscoverFromPage(ctx, finalURL, body)
+}
+
+// DiscoverFromPage 使用已经下载的页面发现图标，避免重复请求；body 为空时只尝试 /favicon.ico
+func (s *IconService) DiscoverFromPage(ctx context.Context, base *url.URL, body []byte) (*model.Icon, error) {
+	var candidates []iconCandidate
+	if len(body) > 0 {
+		var manifestURL string
+		candidates, manifestURL = parseIconLinks(body, base)
+		if manifestURL != "" {
+			candidates = append(candidates, s.manifestIcons(ctx, manifestURL)...)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	candidates = append(candidates, iconCandidate{url: base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()})
+
+	tried := make(map[string]bool)
+	var lastErr error
+	for _, candidate := range candidates {
+		if tried[candidate.url] {
+			continue
+		}
+		tried[candidate.url] = true
+
+		data, _, err := s.fetch(ctx, candidate.url, s.config.MaxSize)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		icon, err := normalizeIcon(data)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", candidate.url, err)
+			continue
+		}
+		if err := s.repo.Save(icon); err != nil {
+			return nil, fmt.Errorf("failed to save icon: %w", err)
+		}
+		return icon, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no icon found")
+	}
+	return nil, lastErr
+}
+
+// manifestIcons 读取 web manifest 中声明的图标
+func (s *IconService) manifestIcons(ctx context.Context, manifestURL string) []iconCandidate {
+	body, finalURL, err := s.fetch(ctx, manifestURL, maxManifestSize)
+	if err != nil {
+		return nil
+	}
+
+	var manifest struct {
+		Icons []struct {
+			Src     string `json:"src"`
+			Sizes   string `json:"sizes"`
+			Type    string `json:"type"`
+			Purpose string `json:"purpose"`
+		} `json:"icons"`
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil
+	}
+
+	var candidates []iconCandidate
+	for _, icon := range manifest.Icons {
+		// maskable 图标四周留有安全区，显示在卡片上偏小
+		if icon.Purpose != "" && !strings.Contains(icon.Purpose, "any") {
+			continue
+		}
+		ref, err := finalURL.Parse(strings.TrimSpace(icon.Src))
+		if err != nil || icon.Src == "" {
+			continue
+		}
+		candidates = append(candidates, iconCandidate{
+			url:   ref.String(),
+			score: iconScore(icon.Sizes, icon.Type, ref.Path, 0),
+		})
+	}
+	return candidates
+}
+
+// fetch 下载 URL 的内容，超过 limit 时报错
+func (s *IconService) fetch(ctx context.Context, rawURL string, limit int64) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", "nav-panel-icon-fetcher/"+version.Version)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s returned status %d", rawURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, nil, fmt.Errorf("%s exceeds %d bytes", rawURL, limit)
+	}
+
+	return data, resp.Request.URL, nil
+}
+
+// iconCandidate 页面声明的候选图标，score 越大越优先
+type iconCandidate struct {
+	url   string
+	score int
+}
+
+// parseIconLinks 解析页面 <head> 中的图标和 manifest 链接
+func parseIconLinks(body []byte, pageURL *url.URL) ([]iconCandidate, string) {
+	base := pageURL
+	var candidates []iconCandidate
+	var manifestURL string
+
+	z := xhtml.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			return candidates, manifestURL
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			token := z.Token()
+			attrs := make(map[string]string, len(token.Attr))
+			for _, attr := range token.Attr {
+				attrs[strings.ToLower(attr.Key)] = strings.TrimSpace(attr.Val)
+			}
+
+			switch token.Data {
+			case "base":
+				if ref, err := pageURL.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+					base = ref
+				}
+			case "link":
+				href := attrs["href"]
+				if href == "" {
+					continue
+				}
+				ref, err := base.Parse(href)
+				if err != nil {
+					continue
+				}
+
+				for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+					switch rel {
+					case "icon":
+						candidates = append(candidates, iconCandidate{
+							url:   ref.String(),
+							score: iconScore(attrs["sizes"], attrs["type"], ref.Path, 16),
+						})
+					case "apple-touch-icon", "apple-touch-icon-precomposed":
+						candidates = append(candidates, iconCandidate{
+							url:   ref.String(),
+							score: iconScore(attrs["sizes"], attrs["type"], ref.Path, 180),
+						})
+					case "manifest":
+						if manifestURL == "" {
+							manifestURL = ref.String()
+						}
+					}
+				}
+			case "body":
+				return candidates, manifestURL
+			}
+		}
+	}
+}
+
+// iconScore 根据声明的尺寸和格式为候选图标打分
+// 矢量图标最优先，其次是接近 192px 的图标，没有声明尺寸时使用 defaultSize
+func iconScore(sizes, contentType, path string, defaultSize int) int {
+	sizes = strings.ToLower(sizes)
+	if sizes == "any" || strings.Contains(contentType, "svg") || strings.HasSuffix(strings.ToLower(path), ".svg") {
+		return 1000
+	}
+
+	size := 0
+	for _, s := range strings.Fields(sizes) {
+		w, _, ok := strings.Cut(s, "x")
+		if n, err := strconv.Atoi(w); ok && err == nil && n > size {
+			size = n
+		}
+	}
+	if size == 0 {
+		size = defaultSize
+	}
+
+	// 过大的图标下载和缩放都浪费，按超出部分扣分
+	if size > 192 {
+		return 192 - (size-192)/8
+	}
+	return size
+}
+
+// detectIconType 根据内容判断图标格式，不是图片时返回 false
+func detectIconType(data []byte) (string, bool) {
+	contentType := http.DetectContentType(data)
+	if strings.HasPrefix(contentType, "image/") {
+		return contentType, true
+	}
+
+	// DetectContentType 会把 SVG 识别为 text/xml 或 text/plain
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); strings.HasPrefix(mediaType, "text/") &&
+		bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+		return "image/svg+xml", true
+	}
+	return "", false
+}
+
+// fallbackColors 占位图标的背景色
+var fallbackColors = []string{"#ef4444", "#f97316", "#eab308", "#22c55e", "#14b8a6", "#3b82f6", "#6366f1", "#a855f7", "#ec4899", "#64748b"}
+
+// fallbackIcon 生成显示网站名称首字母的占位图标，背景色由域名决定
+func fallbackIcon(name, rawURL string) []byte {
+	letter := "?"
+	for _, r := range strings.TrimSpace(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letter = string(unicode.ToUpper(r))
+			break
+		}
+	}
+
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	sum := sha256.Sum256([]byte(host))
+	color := fallbackColors[int(sum[0])%len(fallbackColors)]
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="64" height="64" viewBox="0 0 64 64">`+
+		`<rect width="64" height="64" rx="12" fill="%s"/>`+
+		`<text x="32" y="32" dy=".35em" text-anchor="middle" font-family="sans-serif" font-size="32" fill="#fff">%s</text>`+
+		`</svg>`, color, html.EscapeString(letter)))
+}
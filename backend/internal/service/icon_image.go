@@ -0,0 +1,229 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/svg"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// maxIconDimension 解码前检查的最大宽高，防止解压炸弹
+	maxIconDimension = 4096
+	// normalizedIconBudget 标准化后主图标的大小上限，超出时改用更小的尺寸
+	normalizedIconBudget = 128 << 10
+)
+
+// standardIconSizes 标准化时生成的尺寸，最大的一个作为主图标，其余作为缩略图
+var standardIconSizes = []int{32, 64, 128, 256}
+
+// normalizeIcon 按实际内容识别图标格式并解码，缩放到标准尺寸后重新编码为 PNG
+// SVG 为矢量图，只做安全清理；无法解码的内容返回 ErrInvalidIcon
+func normalizeIcon(data []byte) (*model.Icon, error) {
+	contentType, ok := detectIconType(data)
+	if !ok {
+		return nil, fmt.Errorf("%w: unrecognized image format", ErrInvalidIcon)
+	}
+	if contentType == "image/svg+xml" {
+		// SVG 可以内嵌脚本和外部引用，只保存清理后的文档
+		cleaned, err := svg.Sanitize(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIcon, err)
+		}
+		return model.NewIcon(cleaned, contentType), nil
+	}
+
+	img, err := decodeIconImage(data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIcon, err)
+	}
+
+	bounds := img.Bounds()
+	longest := max(bounds.Dx(), bounds.Dy())
+	if longest == 0 {
+		return nil, fmt.Errorf("%w: empty image", ErrInvalidIcon)
+	}
+
+	// 不放大图片，比最小标准尺寸还小的图片按原尺寸保存
+	var sizes []int
+	for _, size := range standardIconSizes {
+		if size <= longest {
+			sizes = append(sizes, size)
+		}
+	}
+	if len(sizes) == 0 {
+		sizes = []int{longest}
+	}
+
+	var variants []model.IconVariant
+	for _, size := range sizes {
+		encoded, err := encodeIconPNG(resizeIcon(img, size))
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, model.IconVariant{Size: size, ContentType: "image/png", Data: encoded})
+	}
+
+	// 从大到小选择第一个不超过预算的尺寸作为主图标，更大的尺寸丢弃
+	for i := len(variants) - 1; i >= 0; i-- {
+		if len(variants[i].Data) <= normalizedIconBudget {
+			icon := model.NewIcon(variants[i].Data, "image/png")
+			icon.Variants = variants[:i]
+			return icon, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: exceeds %d bytes after resizing", ErrInvalidIcon, normalizedIconBudget)
+}
+
+// decodeIconImage 解码位图图标，先检查尺寸再解码像素
+func decodeIconImage(data []byte, contentType string) (image.Image, error) {
+	if contentType == "image/x-icon" {
+		return decodeICO(data)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxIconDimension || config.Height > maxIconDimension {
+		return nil, fmt.Errorf("image is %dx%d, the maximum is %dx%d", config.Width, config.Height, maxIconDimension, maxIconDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// resizeIcon 等比缩放到最长边为 size，已经不超过 size 时原样返回
+func resizeIcon(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if max(w, h) <= size {
+		return img
+	}
+
+	if w >= h {
+		w, h = size, max(1, h*size/w)
+	} else {
+		w, h = max(1, w*size/h), size
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+func encodeIconPNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode icon: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeICO 解码 ICO 文件中最大的一张图片，图片可以是 PNG 或不带文件头的 BMP
+func decodeICO(data []byte) (image.Image, error) {
+	if len(data) < 6 {
+		return nil, errors.New("truncated ico header")
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if count == 0 || len(data) < 6+count*16 {
+		return nil, errors.New("truncated ico directory")
+	}
+
+	// 宽度为0表示256像素
+	best, bestSize := -1, -1
+	for i := 0; i < count; i++ {
+		entry := data[6+i*16:]
+		size := int(entry[0])
+		if size == 0 {
+			size = 256
+		}
+		if size > bestSize {
+			best, bestSize = i, size
+		}
+	}
+
+	entry := data[6+best*16:]
+	length := int(binary.LittleEndian.Uint32(entry[8:12]))
+	offset := int(binary.LittleEndian.Uint32(entry[12:16]))
+	if offset < 0 || length <= 0 || offset+length > len(data) || offset+length < offset {
+		return nil, errors.New("ico image out of range")
+	}
+	entryData := data[offset : offset+length]
+
+	if bytes.HasPrefix(entryData, []byte("\x89PNG\r\n\x1a\n")) {
+		return decodeIconImage(entryData, "image/png")
+	}
+	return decodeICODIB(entryData)
+}
+
+// decodeICODIB 解码 ICO 中的 BMP 图片
+// 其高度是实际高度的两倍（包含 AND 掩码），32 位图片的第四个字节是透明度
+func decodeICODIB(dib []byte) (image.Image, error) {
+	if len(dib) < 40 {
+		return nil, errors.New("truncated ico bitmap header")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(dib[0:4]))
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(dib[8:12]))) / 2
+	bpp := int(binary.LittleEndian.Uint16(dib[14:16]))
+	if width <= 0 || height <= 0 || width > 256 || height > 256 || headerSize < 40 || headerSize > len(dib) {
+		return nil, errors.New("invalid ico bitmap header")
+	}
+
+	if bpp == 32 {
+		pixels := dib[headerSize:]
+		if len(pixels) < width*height*4 {
+			return nil, errors.New("truncated ico bitmap")
+		}
+
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+		hasAlpha := false
+		for y := 0; y < height; y++ {
+			row := pixels[(height-1-y)*width*4:]
+			for x := 0; x < width; x++ {
+				p := row[x*4:]
+				img.SetNRGBA(x, y, color.NRGBA{R: p[2], G: p[1], B: p[0], A: p[3]})
+				hasAlpha = hasAlpha || p[3] != 0
+			}
+		}
+
+		// 部分旧图标的透明度通道全为0，实际是不透明图片
+		if !hasAlpha {
+			for i := 3; i < len(img.Pix); i += 4 {
+				img.Pix[i] = 0xff
+			}
+		}
+		return img, nil
+	}
+
+	// 其他位深交给 BMP 解码器，补上文件头并把高度改为实际高度，忽略透明掩码
+	header := make([]byte, 14)
+	copy(header, "BM")
+	binary.LittleEndian.PutUint32(header[2:6], uint32(14+len(dib)))
+	paletteSize := 0
+	if bpp <= 8 {
+		colors := int(binary.LittleEndian.Uint32(dib[32:36]))
+		if colors == 0 {
+			colors = 1 << bpp
+		}
+		paletteSize = colors * 4
+	}
+	binary.LittleEndian.PutUint32(header[10:14], uint32(14+headerSize+paletteSize))
+
+	patched := append([]byte(nil), dib...)
+	binary.LittleEndian.PutUint32(patched[8:12], uint32(height))
+	return bmp.Decode(bytes.NewReader(append(header, patched...)))
+}
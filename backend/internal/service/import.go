@@ -0,0 +1,949 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrImportRejected 替换模式下存在无效记录，整个导入已回滚
+var ErrImportRejected = errors.New("import aborted: replace mode requires every record to be valid, nothing was changed")
+
+// ParseImportOptions 解析导入模式；dry-run 默认按合并策略预览，可通过 strategy 指定预览替换
+func ParseImportOptions(mode, strategy string) (model.ImportOptions, error) {
+	switch model.ImportMode(mode) {
+	case "", model.ImportModeReplace:
+		return model.ImportOptions{Mode: model.ImportModeReplace}, nil
+	case model.ImportModeMerge:
+		return model.ImportOptions{Mode: model.ImportModeMerge}, nil
+	case model.ImportModeDryRun:
+		switch model.ImportMode(strategy) {
+		case "", model.ImportModeMerge:
+			return model.ImportOptions{Mode: model.ImportModeMerge, DryRun: true}, nil
+		case model.ImportModeReplace:
+			return model.ImportOptions{Mode: model.ImportModeReplace, DryRun: true}, nil
+		}
+		return model.ImportOptions{}, fmt.Errorf("invalid dry-run strategy '%s', expected replace or merge", strategy)
+	}
+	return model.ImportOptions{}, fmt.Errorf("invalid import mode '%s', expected replace, merge or dry-run", mode)
+}
+
+// Import 在单个事务中把数据导入到指定账号并返回逐条处理报告
+// ctx 取消（如客户端断开）时停止获取网页元数据并放弃导入
+func (s *SettingsService) Import(ctx context.Context, userID string, data *model.ImportData, opts model.ImportOptions) (*model.ImportReport, error) {
+	// 在开启事务前获取网页，避免网络请求期间长时间占用数据库
+	metadataFilled, metadataUnfilled := 0, []model.ImportItem{}
+	if opts.FetchMetadata && s.previewService != nil && len(data.Websites) > 0 {
+		metadataFilled, metadataUnfilled = s.previewService.FillMetadata(ctx, data.Websites)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.importRepo.Begin(userID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &model.ImportReport{
+		Mode:                   opts.Mode,
+		DryRun:                 opts.DryRun,
+		MetadataFilled:         metadataFilled,
+		MetadataUnfilled:       metadataUnfilled,
+		Groups:                 model.NewImportEntityReport(),
+		Tags:                   model.NewImportEntityReport(),
+		Websites:               model.NewImportEntityReport(),
+		SearchEngineCategories: model.NewImportEntityReport(),
+		SearchEngines:          model.NewImportEntityReport(),
+		Settings:               model.NewImportEntityReport(),
+	}
+
+	// 先导入分组，以便网站引用的 group_id 能够映射到实际的分组
+	groupIDMap := make(map[string]string)
+	if data.Groups != nil {
+		groupIDMap, err = importGroups(tx, data.Groups, opts.Mode, &report.Groups)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 替换网站时同时替换标签，避免留下没有网站的旧标签
+	if data.Tags != nil || data.Websites != nil {
+		if err := importTags(tx, data.Tags, opts.Mode, &report.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.Websites != nil {
+		if err := importWebsites(tx, data.Websites, groupIDMap, opts.Mode, &report.Websites); err != nil {
+			return nil, err
+		}
+	}
+
+	// 先导入搜索引擎分类，以便搜索引擎引用的 categoryId 能够映射到实际的分类
+	// 替换搜索引擎时同时替换分类，避免留下旧的分类
+	categoryIDMap := make(map[string]string)
+	if data.SearchEngineCategories != nil || data.SearchEngines != nil {
+		categoryIDMap, err = importSearchEngineCategories(tx, data.SearchEngineCategories, opts.Mode, &report.SearchEngineCategories)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if data.SearchEngines != nil {
+		if err := importSearchEngines(tx, data.SearchEngines, categoryIDMap, opts.Mode, &report.SearchEngines); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.Settings != nil {
+		if err := importSettings(tx, data.Settings, &report.Settings); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	// 替换模式会先清空数据，只要有一条记录无效就整体回滚，避免留下残缺的面板
+	if opts.Mode == model.ImportModeReplace && report.HasRejected() {
+		return report, ErrImportRejected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	report.Committed = true
+
+	return report, nil
+}
+
+func importGroups(tx *repository.ImportTx, groups []model.Group, mode model.ImportMode, report *model.ImportEntityReport) (map[string]string, error) {
+	var existing []model.Group
+	if mode == model.ImportModeReplace {
+		if err := tx.DeleteAllGroups(); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		existing, err = tx.Groups()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 同名分组只在同一父分组下合并，不同父分组下的同名子分组互不影响
+	byID := make(map[string]*model.Group)
+	byName := make(map[string]*model.Group)
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		byName[groupNameKey(existing[i].ParentId, existing[i].Name)] = &existing[i]
+	}
+	forest := newGroupForest(existing)
+
+	// idMap 记录导入数据中的分组ID到实际分组ID的映射
+	idMap := make(map[string]string)
+	seenIDs := make(map[string]bool)
+	now := time.Now()
+
+	// 先导入父分组，子分组的 parentId 才能映射到实际的分组
+	for _, g := range parentGroupsFirst(groups) {
+		g := g
+		item := model.ImportItem{ID: g.ID, Name: g.Name}
+
+		g.Name = strings.TrimSpace(g.Name)
+		if g.Name == "" {
+			item.Reason = "name is required"
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+
+		if g.ID != "" && seenIDs[g.ID] {
+			item.Reason = "duplicate entry in import data"
+			report.Skipped = append(report.Skipped, item)
+			continue
+		}
+		seenIDs[g.ID] = true
+
+		icon, reason, err := storeImportIcon(tx, g.Icon)
+		if err != nil {
+			return nil, err
+		}
+		g.Icon, item.Reason = icon, reason
+
+		// 父分组不在导入数据中且不是已有分组时导入为顶层分组
+		if g.ParentId != nil {
+			if mapped, ok := idMap[*g.ParentId]; ok {
+				g.ParentId = &mapped
+			} else if byID[*g.ParentId] == nil {
+				item.Reason = fmt.Sprintf("parent group '%s' not found, imported as top-level", *g.ParentId)
+				g.ParentId = nil
+			}
+		}
+
+		target := byID[g.ID]
+		if target == nil {
+			target = byName[groupNameKey(g.ParentId, g.Name)]
+		}
+
+		targetID := ""
+		if target != nil {
+			targetID = target.ID
+		}
+		if err := forest.checkPlacement(targetID, g.ParentId); err != nil {
+			item.Reason = err.Error() + ", imported as top-level"
+			g.ParentId = nil
+		}
+
+		if target != nil {
+			item.ID = target.ID
+			if g.ID != "" {
+				idMap[g.ID] = target.ID
+			}
+			if groupUnchanged(target, &g) {
+				item.Reason = "unchanged"
+				report.Skipped = append(report.Skipped, item)
+				continue
+			}
+			if err := tx.Savepoint(func() error { return tx.UpdateGroup(target.ID, &g) }); err != nil {
+				item.Reason = err.Error()
+				report.Rejected = append(report.Rejected, item)
+				continue
+			}
+			forest[target.ID] = g.ParentId
+			report.Updated = append(report.Updated, item)
+			continue
+		}
+
+		// 导入其他账号导出的数据时ID可能已被占用，此时分配新ID
+		originalID := g.ID
+		inUse, err := tx.GroupIDInUse(g.ID)
+		if err != nil {
+			return nil, err
+		}
+		if g.ID == "" || inUse {
+			g.ID = uuid.New().String()
+			item.ID = g.ID
+		}
+		if g.CreatedAt.IsZero() {
+			g.CreatedAt = now
+		}
+		g.UpdatedAt = now
+
+		if err := tx.Savepoint(func() error { return tx.CreateGroup(&g) }); err != nil {
+			item.Reason = err.Error()
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+		if originalID != "" {
+			idMap[originalID] = g.ID
+		}
+		byName[groupNameKey(g.ParentId, g.Name)] = &g
+		forest[g.ID] = g.ParentId
+		report.Created = append(report.Created, item)
+	}
+
+	return idMap, nil
+}
+
+func groupUnchanged(a, b *model.Group) bool {
+	return a.Name == b.Name &&
+		equalStringPtr(a.Color, b.Color) &&
+		equalStringPtr(a.Icon, b.Icon) &&
+		equalStringPtr(a.ParentId, b.ParentId) &&
+		a.SortOrder == b.SortOrder &&
+		a.IsCollapsed == b.IsCollapsed
+}
+
+// groupNameKey 按父分组和名称（忽略大小写）匹配已有分组的键
+func groupNameKey(parentID *string, name string) string {
+	key := strings.ToLower(name)
+	if parentID != nil {
+		key = *parentID + "/" + key
+	}
+	return key
+}
+
+// parentGroupsFirst 按分组在导入数据中的层级稳定排序，使父分组排在子分组之前
+func parentGroupsFirst(groups []model.Group) []model.Group {
+	forest := newGroupForest(groups)
+	sorted := append([]model.Group(nil), groups...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return forest.depth(sorted[i].ID) < forest.depth(sorted[j].ID)
+	})
+	return sorted
+}
+
+func importWebsites(tx *repository.ImportTx, websites []model.Website, groupIDMap map[string]string, mode model.ImportMode, report *model.ImportEntityReport) error {
+	groupIDs, err := tx.GroupIDs()
+	if err != nil {
+		return err
+	}
+
+	var existing []model.Website
+	if mode == model.ImportModeReplace {
+		if err := tx.DeleteAllWebsites(); err != nil {
+			return err
+		}
+	} else {
+		existing, err = tx.Websites()
+		if err != nil {
+			return err
+		}
+	}
+
+	byID := make(map[string]*model.Website)
+	byURL := make(map[string]*model.Website)
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		byURL[normalizeURL(existing[i].URL)] = &existing[i]
+	}
+
+	seenIDs := make(map[string]bool)
+	seenURLs := make(map[string]bool)
+	now := time.Now()
+
+	for _, w := range websites {
+		w := w
+		item := model.ImportItem{ID: w.ID, Name: w.Name}
+
+		if reason := validateImportWebsite(&w); reason != "" {
+			item.Reason = reason
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+
+		key := normalizeURL(w.URL)
+		if (w.ID != "" && seenIDs[w.ID]) || seenURLs[key] {
+			item.Reason = "duplicate entry in import data"
+			report.Skipped = append(report.Skipped, item)
+			continue
+		}
+		seenIDs[w.ID] = true
+		seenURLs[key] = true
+
+		icon, reason, err := storeImportIcon(tx, w.Icon)
+		if err != nil {
+			return err
+		}
+		w.Icon, item.Reason = icon, reason
+
+		if w.Tags != nil {
+			var dropped int
+			w.Tags, dropped = normalizeImportTags(w.Tags)
+			if dropped > 0 && item.Reason == "" {
+				item.Reason = fmt.Sprintf("%d invalid tag(s) dropped", dropped)
+			}
+		}
+
+		if w.GroupId != nil {
+			if mapped, ok := groupIDMap[*w.GroupId]; ok {
+				w.GroupId = &mapped
+			}
+		}
+
+		// 引用不存在的分组时导入为未分组，避免悬空的 group_id
+		if w.GroupId != nil && (*w.GroupId == "" || !groupIDs[*w.GroupId]) {
+			if *w.GroupId != "" {
+				item.Reason = fmt.Sprintf("group '%s' not found, imported as ungrouped", *w.GroupId)
+			}
+			w.GroupId = nil
+		}
+
+		target := byID[w.ID]
+		if target == nil {
+			target = byURL[key]
+		}
+
+		if target != nil {
+			item.ID = target.ID
+			if websiteUnchanged(target, &w) {
+				item.Reason = "unchanged"
+				report.Skipped = append(report.Skipped, item)
+				continue
+			}
+			err := tx.Savepoint(func() error {
+				if err := tx.UpdateWebsite(target.ID, &w); err != nil {
+					return err
+				}
+				return setImportWebsiteTags(tx, target.ID, w.Tags)
+			})
+			if err != nil {
+				item.Reason = err.Error()
+				report.Rejected = append(report.Rejected, item)
+				continue
+			}
+			report.Updated = append(report.Updated, item)
+			continue
+		}
+
+		inUse, err := tx.WebsiteIDInUse(w.ID)
+		if err != nil {
+			return err
+		}
+		if w.ID == "" || inUse {
+			w.ID = uuid.New().String()
+			item.ID = w.ID
+		}
+		if w.CreatedAt.IsZero() {
+			w.CreatedAt = now
+		}
+		w.UpdatedAt = now
+
+		err = tx.Savepoint(func() error {
+			if err := tx.CreateWebsite(&w); err != nil {
+				return err
+			}
+			return setImportWebsiteTags(tx, w.ID, w.Tags)
+		})
+		if err != nil {
+			item.Reason = err.Error()
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+		report.Created = append(report.Created, item)
+	}
+
+	return nil
+}
+
+func validateImportWebsite(w *model.Website) string {
+	w.Name = strings.TrimSpace(w.Name)
+	w.URL = strings.TrimSpace(w.URL)
+
+	if w.Name == "" {
+		return "name is required"
+	}
+	if w.URL == "" {
+		return "url is required"
+	}
+	if !isValidWebURL(w.URL) {
+		return fmt.Sprintf("invalid url '%s'", w.URL)
+	}
+	return ""
+}
+
+func websiteUnchanged(a, b *model.Website) bool {
+	return a.Name == b.Name &&
+		a.URL == b.URL &&
+		equalStringPtr(a.Icon, b.Icon) &&
+		equalStringPtr(a.Description, b.Description) &&
+		(b.Tags == nil || equalTagNames(a.Tags, b.Tags)) &&
+		equalStringPtr(a.GroupId, b.GroupId) &&
+		a.SortOrder == b.SortOrder
+}
+
+// importTags 导入标签，按名称（忽略大小写）与已有标签合并
+// 网站的标签按名称引用，标签ID只用于识别重复条目
+func importTags(tx *repository.ImportTx, tags []model.Tag, mode model.ImportMode, report *model.ImportEntityReport) error {
+	var existing []model.Tag
+	if mode == model.ImportModeReplace {
+		if err := tx.DeleteAllTags(); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		existing, err = tx.Tags()
+		if err != nil {
+			return err
+		}
+	}
+
+	byName := make(map[string]*model.Tag)
+	for i := range existing {
+		byName[strings.ToLower(existing[i].Name)] = &existing[i]
+	}
+
+	now := time.Now()
+	for _, t := range tags {
+		t := t
+		item := model.ImportItem{ID: t.ID, Name: t.Name}
+
+		name, err := normalizeTagName(t.Name)
+		if err != nil {
+			item.Reason = err.Error()
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+		t.Name = name
+
+		if target := byName[strings.ToLower(name)]; target != nil {
+			item.ID = target.ID
+			if target.Name == t.Name && equalStringPtr(target.Color, t.Color) {
+				item.Reason = "unchanged"
+				report.Skipped = append(report.Skipped, item)
+				continue
+			}
+			if err := tx.Savepoint(func() error { return tx.UpdateTag(target.ID, &t) }); err != nil {
+				item.Reason = err.Error()
+				report.Rejected = append(report.Rejected, item)
+				continue
+			}
+			target.Name, target.Color = t.Name, t.Color
+			report.Updated = append(report.Updated, item)
+			continue
+		}
+
+		inUse, err := tx.TagIDInUse(t.ID)
+		if err != nil {
+			return err
+		}
+		if t.ID == "" || inUse {
+			t.ID = uuid.New().String()
+			item.ID = t.ID
+		}
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		t.UpdatedAt = now
+
+		if err := tx.Savepoint(func() error { return tx.CreateTag(&t) }); err != nil {
+			item.Reason = err.Error()
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+		byName[strings.ToLower(t.Name)] = &t
+		report.Created = append(report.Created, item)
+	}
+
+	return nil
+}
+
+// setImportWebsiteTags 按名称设置导入网站的标签，tags 为 nil 时（导入数据未包含标签）保留已有标签
+func setImportWebsiteTags(tx *repository.ImportTx, websiteID string, tags []model.Tag) error {
+	if tags == nil {
+		return nil
+	}
+	return tx.SetWebsiteTags(websiteID, tags)
+}
+
+// normalizeImportTags 规范化网站引用的标签名称，去掉无效和重复的标签，返回去掉的无效标签数量
+func normalizeImportTags(tags []model.Tag) ([]model.Tag, int) {
+	seen := make(map[string]bool)
+	result := []model.Tag{}
+	dropped := 0
+	for _, t := range tags {
+		name, err := normalizeTagName(t.Name)
+		if err != nil {
+			dropped++
+			continue
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			t.Name = name
+			result = append(result, t)
+		}
+	}
+	return result, dropped
+}
+
+// equalTagNames 两组标签的名称（忽略大小写）是否相同
+func equalTagNames(a, b []model.Tag) bool {
+	names := make(map[string]bool)
+	for _, t := range a {
+		names[strings.ToLower(t.Name)] = true
+	}
+	other := make(map[string]bool)
+	for _, t := range b {
+		if !names[strings.ToLower(t.Name)] {
+			return false
+		}
+		other[strings.ToLower(t.Name)] = true
+	}
+	return len(names) == len(other)
+}
+
+// importSearchEngineCategories 导入搜索引擎分类，按ID或名称（忽略大小写）与已有分类合并
+// 返回导入数据中的分类ID到实际分类ID的映射
+func importSearchEngineCategories(tx *repository.ImportTx, categories []model.SearchEngineCategory, mode model.ImportMode, report *model.ImportEntityReport) (map[string]string, error) {
+	var existing []model.SearchEngineCategory
+	if mode == model.ImportModeReplace {
+		if err := tx.DeleteAllSearchEngineCategories(); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		existing, err = tx.SearchEngineCategories()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byID := make(map[string]*model.SearchEngineCategory)
+	byName := make(map[string]*model.SearchEngineCategory)
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		byName[strings.ToLower(existing[i].Name)] = &existing[i]
+	}
+
+	idMap := make(map[string]string)
+	seenIDs := make(map[string]bool)
+	seenNames := make(map[string]bool)
+	now := time.Now()
+
+	for _, c := range categories {
+		c := c
+		item := model.ImportItem{ID: c.ID, Name: c.Name}
+
+		name, err := normalizeSearchEngineCategoryName(c.Name)
+		if err != nil {
+			item.Reason = err.Error()
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+		c.Name = name
+
+		key := strings.ToLower(name)
+		if (c.ID != "" && seenIDs[c.ID]) || seenNames[key] {
+			item.Reason = "duplicate entry in import data"
+			report.Skipped = append(report.Skipped, item)
+			continue
+		}
+		seenIDs[c.ID] = true
+		seenNames[key] = true
+
+		target := byID[c.ID]
+		if target == nil {
+			target = byName[key]
+		}
+
+		if target != nil {
+			item.ID = target.ID
+			if c.ID != "" {
+				idMap[c.ID] = target.ID
+			}
+			if target.Name == c.Name && target.SortOrder == c.SortOrder {
+				item.Reason = "unchanged"
+				report.Skipped = append(report.Skipped, item)
+				continue
+			}
+			// 按ID匹配时新名称可能与另一个已有分类相同
+			if other := byName[key]; other != nil && other.ID != target.ID {
+				item.Reason = fmt.Sprintf("name conflicts with category '%s'", other.Name)
+				report.Rejected = append(report.Rejected, item)
+				continue
+			}
+			if err := tx.Savepoint(func() error { return tx.UpdateSearchEngineCategory(target.ID, &c) }); err != nil {
+				item.Reason = err.Error()
+				report.Rejected = append(report.Rejected, item)
+				continue
+			}
+			delete(byName, strings.ToLower(target.Name))
+			target.Name, target.SortOrder = c.Name, c.SortOrder
+			byName[key] = target
+			report.Updated = append(report.Updated, item)
+			continue
+		}
+
+		// 导入其他账号导出的数据时ID可能已被占用，此时分配新ID
+		originalID := c.ID
+		inUse, err := tx.SearchEngineCategoryIDInUse(c.ID)
+		if err != nil {
+			return nil, err
+		}
+		if c.ID == "" || inUse {
+			c.ID = uuid.New().String()
+			item.ID = c.ID
+		}
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt = now
+		}
+		c.UpdatedAt = now
+
+		if err := tx.Savepoint(func() error { return tx.CreateSearchEngineCategory(&c) }); err != nil {
+			item.Reason = err.Error()
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+		if originalID != "" {
+			idMap[originalID] = c.ID
+		}
+		byName[key] = &c
+		report.Created = append(report.Created, item)
+	}
+
+	return idMap, nil
+}
+
+func importSearchEngines(tx *repository.ImportTx, engines []model.SearchEngine, categoryIDMap map[string]string, mode model.ImportMode, report *model.ImportEntityReport) error {
+	categories, err := tx.SearchEngineCategories()
+	if err != nil {
+		return err
+	}
+	categoryIDs := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		categoryIDs[c.ID] = true
+	}
+
+	var existing []model.SearchEngine
+	if mode == model.ImportModeReplace {
+		if err := tx.DeleteAllSearchEngines(); err != nil {
+			return err
+		}
+	} else {
+		existing, err = tx.SearchEngines()
+		if err != nil {
+			return err
+		}
+	}
+
+	byID := make(map[string]*model.SearchEngine)
+	byURL := make(map[string]*model.SearchEngine)
+	// keywordOwners 关键词当前属于哪个搜索引擎，随导入结果更新
+	keywordOwners := make(map[string]string)
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+		byURL[normalizeURL(existing[i].URL)] = &existing[i]
+		if existing[i].Keyword != nil {
+			keywordOwners[*existing[i].Keyword] = existing[i].ID
+		}
+	}
+
+	seenIDs := make(map[string]bool)
+	seenURLs := make(map[string]bool)
+	hasDefault := false
+
+	for _, e := range engines {
+		e := e
+		item := model.ImportItem{ID: e.ID, Name: e.Name}
+
+		if reason := validateImportSearchEngine(&e); reason != "" {
+			item.Reason = reason
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+
+		key := normalizeURL(e.URL)
+		if seenIDs[e.ID] || seenURLs[key] {
+			item.Reason = "duplicate entry in import data"
+			report.Skipped = append(report.Skipped, item)
+			continue
+		}
+		seenIDs[e.ID] = true
+		seenURLs[key] = true
+
+		icon, reason, err := storeImportIcon(tx, e.Icon)
+		if err != nil {
+			return err
+		}
+		e.Icon, item.Reason = icon, reason
+
+		// 导入数据中只保留第一个默认搜索引擎
+		if e.IsDefault {
+			if hasDefault {
+				e.IsDefault = false
+				item.Reason = "another engine in import data is already default"
+			}
+			hasDefault = true
+		}
+
+		target := byID[e.ID]
+		if target == nil {
+			target = byURL[key]
+		}
+		targetID := e.ID
+		if target != nil {
+			targetID = target.ID
+		}
+
+		// 关键词无效或已被其他搜索引擎使用时只丢弃关键词，仍然导入搜索引擎
+		keyword, err := normalizeSearchKeyword(e.Keyword)
+		if err != nil {
+			keyword, item.Reason = nil, fmt.Sprintf("keyword dropped: %v", err)
+		} else if keyword != nil {
+			if owner, taken := keywordOwners[*keyword]; taken && owner != targetID {
+				keyword, item.Reason = nil, fmt.Sprintf("keyword '%s' is already used by another engine", *keyword)
+			}
+		}
+		e.Keyword = keyword
+
+		// 引用不存在的分类时导入为未分类，避免悬空的 category_id
+		if e.CategoryId != nil {
+			if mapped, ok := categoryIDMap[*e.CategoryId]; ok {
+				e.CategoryId = &mapped
+			}
+			if *e.CategoryId == "" || !categoryIDs[*e.CategoryId] {
+				if *e.CategoryId != "" {
+					item.Reason = fmt.Sprintf("category '%s' not found, imported as uncategorized", *e.CategoryId)
+				}
+				e.CategoryId = nil
+			}
+		}
+
+		if target != nil {
+			item.ID = target.ID
+			if searchEngineUnchanged(target, &e) {
+				item.Reason = "unchanged"
+				report.Skipped = append(report.Skipped, item)
+				continue
+			}
+			if err := tx.Savepoint(func() error { return tx.UpdateSearchEngine(target.ID, &e) }); err != nil {
+				item.Reason = err.Error()
+				report.Rejected = append(report.Rejected, item)
+				continue
+			}
+			claimSearchKeyword(keywordOwners, target.ID, e.Keyword)
+			report.Updated = append(report.Updated, item)
+			continue
+		}
+
+		if err := tx.Savepoint(func() error { return tx.CreateSearchEngine(&e) }); err != nil {
+			item.Reason = err.Error()
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+		claimSearchKeyword(keywordOwners, e.ID, e.Keyword)
+		report.Created = append(report.Created, item)
+	}
+
+	return nil
+}
+
+func validateImportSearchEngine(e *model.SearchEngine) string {
+	e.ID = strings.TrimSpace(e.ID)
+	e.Name = strings.TrimSpace(e.Name)
+	e.URL = strings.TrimSpace(e.URL)
+
+	if e.ID == "" {
+		return "id is required"
+	}
+	if e.Name == "" {
+		return "name is required"
+	}
+	// 旧版导出文件没有 method，按 GET 处理
+	tpl, err := parseSearchTemplate(e.URL, e.Method, e.Body)
+	if err != nil {
+		return err.Error()
+	}
+	e.Method = tpl.method
+	e.Body = normalizeSearchBody(e.Body)
+	return ""
+}
+
+func searchEngineUnchanged(a, b *model.SearchEngine) bool {
+	return a.Name == b.Name &&
+		a.URL == b.URL &&
+		equalStringPtr(a.Icon, b.Icon) &&
+		equalStringPtr(a.Placeholder, b.Placeholder) &&
+		a.IsDefault == b.IsDefault &&
+		equalStringPtr(a.Keyword, b.Keyword) &&
+		a.Method == b.Method &&
+		equalStringPtr(a.Body, b.Body) &&
+		a.SortOrder == b.SortOrder &&
+		equalStringPtr(a.CategoryId, b.CategoryId)
+}
+
+// claimSearchKeyword 记录搜索引擎导入后的关键词，释放它原来的关键词
+func claimSearchKeyword(owners map[string]string, id string, keyword *string) {
+	for k, owner := range owners {
+		if owner == id {
+			delete(owners, k)
+		}
+	}
+	if keyword != nil {
+		owners[*keyword] = id
+	}
+}
+
+func importSettings(tx *repository.ImportTx, settings *model.ExportSettings, report *model.ImportEntityReport) error {
+	item := model.ImportItem{ID: "app_settings", Name: "settings"}
+
+	current, err := tx.Settings()
+	if err != nil {
+		return err
+	}
+
+	// 未提供的字段保留当前值
+	merged := *current
+	if settings.Theme != "" {
+		merged.Theme = settings.Theme
+	}
+
+	if settings.Layout != nil {
+		if merged.LayoutConfig, err = toJSONString(settings.Layout); err != nil {
+			return err
+		}
+	}
+	if settings.Background != nil {
+		if merged.BackgroundConfig, err = toJSONString(settings.Background); err != nil {
+			return err
+		}
+	}
+	if settings.CardStyle != nil {
+		if merged.CardStyleConfig, err = toJSONString(settings.CardStyle); err != nil {
+			return err
+		}
+	}
+	if settings.Search != nil {
+		if merged.SearchConfig, err = toJSONString(settings.Search); err != nil {
+			return err
+		}
+	}
+
+	if merged.Theme == current.Theme &&
+		merged.LayoutConfig == current.LayoutConfig &&
+		merged.BackgroundConfig == current.BackgroundConfig &&
+		merged.CardStyleConfig == current.CardStyleConfig &&
+		merged.SearchConfig == current.SearchConfig {
+		item.Reason = "unchanged"
+		report.Skipped = append(report.Skipped, item)
+		return nil
+	}
+
+	if err := tx.Savepoint(func() error { return tx.UpdateSettings(&merged) }); err != nil {
+		item.Reason = err.Error()
+		report.Rejected = append(report.Rejected, item)
+		return nil
+	}
+	report.Updated = append(report.Updated, item)
+
+	return nil
+}
+
+// storeImportIcon 把导入数据中内联的 DataURL 图标标准化后存入图标库并替换为引用
+// 图标无法识别时丢弃图标继续导入，并返回原因
+func storeImportIcon(tx *repository.ImportTx, icon *string) (*string, string, error) {
+	if icon == nil || !model.IsDataURL(*icon) {
+		return icon, "", nil
+	}
+
+	_, data, err := model.ParseDataURL(*icon)
+	if err != nil {
+		return nil, "invalid icon: " + err.Error() + ", imported without icon", nil
+	}
+	decoded, err := normalizeIcon(data)
+	if err != nil {
+		return nil, err.Error() + ", imported without icon", nil
+	}
+	if err := tx.SaveIcon(decoded); err != nil {
+		return nil, "", err
+	}
+
+	ref := decoded.URL()
+	return &ref, "", nil
+}
+
+func toJSONString(v interface{}) (string, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
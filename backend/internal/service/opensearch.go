@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+const (
+	// openSearchShortName 描述文档中的名称，OpenSearch 规范要求不超过 16 个字符
+	openSearchShortName = "Nav Panel"
+	// openSearchIconSize 描述文档中图标的显示尺寸
+	openSearchIconSize = 16
+)
+
+type OpenSearchService struct {
+	engineRepo  *repository.SearchEngineRepository
+	websiteRepo *repository.WebsiteRepository
+	groupRepo   *repository.GroupRepository
+	history     *SearchHistoryService
+}
+
+func NewOpenSearchService(engineRepo *repository.SearchEngineRepository, websiteRepo *repository.WebsiteRepository, groupRepo *repository.GroupRepository, history *SearchHistoryService) *OpenSearchService {
+	return &OpenSearchService{engineRepo: engineRepo, websiteRepo: websiteRepo, groupRepo: groupRepo, history: history}
+}
+
+// Description 生成 OpenSearch 描述文档，baseURL 为面板对外的地址（不以 / 结尾）
+// 搜索统一经过 /search 按关键词分流，描述和图标取自默认搜索引擎
+func (s *OpenSearchService) Description(userID, baseURL string) (*model.OpenSearchDescription, error) {
+	doc := &model.OpenSearchDescription{
+		Xmlns:         "http://a9.com/-/spec/opensearch/1.1/",
+		XmlnsMoz:      "http://www.mozilla.org/2006/browser/search/",
+		ShortName:     openSearchShortName,
+		Description:   "通过导航面板搜索，输入 !关键词 切换搜索引擎",
+		InputEncoding: "UTF-8",
+		URLs: []model.OpenSearchURL{
+			{Type: "text/html", Method: "get", Template: baseURL + "/search?q={searchTerms}"},
+			{Type: "application/x-suggestions+json", Method: "get", Template: baseURL + "/search/suggest?q={searchTerms}"},
+			{Type: "application/opensearchdescription+xml", Rel: "self", Template: baseURL + "/opensearch.xml"},
+		},
+		SearchForm: baseURL + "/",
+	}
+
+	engines, err := s.engineRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	// 与 /search 选择相同的默认搜索引擎
+	if engine := defaultSearchEngine(engines); engine != nil {
+		doc.Description = fmt.Sprintf("通过导航面板使用 %s 搜索，输入 !关键词 切换搜索引擎", engine.Name)
+		if engine.Icon != nil && *engine.Icon != "" {
+			icon := *engine.Icon
+			// 图标库中的图标以 /api/icons/ 开头，需要转为绝对地址
+			if strings.HasPrefix(icon, "/") {
+				icon = baseURL + icon
+			}
+			doc.Image = &model.OpenSearchImage{Width: openSearchIconSize, Height: openSearchIconSize, URL: icon}
+		}
+	}
+
+	return doc, nil
+}
+
+// SuggestSources 地址栏建议可以使用哪些数据，由调用方按登录状态和 API 令牌的权限范围决定
+type SuggestSources struct {
+	Engines  bool // 搜索引擎关键词
+	History  bool // 搜索历史
+	Websites bool // 网站和分组
+}
+
+// Suggest 地址栏搜索建议，limit 不大于0时使用默认值
+// 输入以 ! 开头且没有空格时建议搜索引擎关键词；否则依次为搜索历史、匹配的网站和分组，相同内容只保留第一条
+// sources 中未启用的数据不会出现在建议中
+func (s *OpenSearchService) Suggest(userID, q, baseURL string, sources SuggestSources, limit int) ([]model.SearchCompletion, error) {
+	limit = searchHistoryLimit(limit)
+	q = strings.TrimSpace(q)
+
+	completions := []model.SearchCompletion{}
+	seen := make(map[string]bool)
+	add := func(c model.SearchCompletion) {
+		key := normalizeSearchQuery(c.Text)
+		if key == "" || seen[key] || len(completions) >= limit {
+			return
+		}
+		seen[key] = true
+		completions = append(completions, c)
+	}
+
+	if keyword, ok := strings.CutPrefix(q, "!"); ok && !strings.ContainsFunc(keyword, unicode.IsSpace) {
+		if !sources.Engines {
+			return completions, nil
+		}
+		engines, err := s.engineRepo.GetAll(userID)
+		if err != nil {
+			return nil, err
+		}
+		keyword = strings.ToLower(keyword)
+		for _, e := range engines {
+			if e.Keyword == nil || !strings.HasPrefix(*e.Keyword, keyword) {
+				continue
+			}
+			homepage := ""
+			if tpl, err := parseSearchTemplate(e.URL, e.Method, e.Body); err == nil {
+				homepage = tpl.homepage()
+			}
+			add(model.SearchCompletion{Text: "!" + *e.Keyword, Description: e.Name, URL: homepage})
+		}
+		return completions, nil
+	}
+
+	if sources.History {
+		history, err := s.history.Suggest(userID, q, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range history {
+			add(model.SearchCompletion{Text: h.Query, Description: "搜索历史", URL: baseURL + "/search?q=" + url.QueryEscape(h.Query)})
+		}
+	}
+
+	if !sources.Websites {
+		return completions, nil
+	}
+
+	if match := buildFTSQuery(q); match != "" {
+		websites, err := s.websiteRepo.Search(userID, model.WebsiteSearchQuery{Match: match, Limit: limit})
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range websites {
+			add(model.SearchCompletion{Text: w.Name, Description: w.URL, URL: baseURL + "/go/" + url.PathEscape(w.ID)})
+		}
+	}
+
+	if q != "" {
+		groups, err := s.groupRepo.GetAll(userID)
+		if err != nil {
+			return nil, err
+		}
+		term := strings.ToLower(q)
+		for _, g := range groups {
+			if strings.Contains(strings.ToLower(g.Name), term) {
+				add(model.SearchCompletion{Text: g.Name, Description: "分组", URL: baseURL + "/"})
+			}
+		}
+	}
+
+	return completions, nil
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"nav-panel-backend/internal/version"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	// ErrInvalidPreviewURL 预览的地址不是 http/https 网址
+	ErrInvalidPreviewURL = errors.New("invalid preview url")
+	// ErrPreviewFetch 无法获取或解析预览的页面
+	ErrPreviewFetch = errors.New("failed to fetch page")
+)
+
+const (
+	// maxPreviewPageSize 预览时最多读取的页面大小，超出部分直接截断，元数据通常都在 <head> 中
+	maxPreviewPageSize = 512 << 10
+	// previewTimeout 单次预览（获取页面和图标）的总超时
+	previewTimeout = 15 * time.Second
+	// previewPageTimeout 批量补全元数据时单个页面的超时
+	previewPageTimeout = 10 * time.Second
+	// previewConcurrency 批量补全元数据时同时请求的页面数量
+	previewConcurrency = 4
+	// previewFillTimeout 批量补全元数据的总时间上限，超时后剩余的网站保持原样
+	previewFillTimeout = 2 * time.Minute
+	// maxPreviewFillPages 批量补全元数据时最多请求的页面数量
+	maxPreviewFillPages = 200
+	// maxPreviewNameLength 建议名称的长度上限（字符数）
+	maxPreviewNameLength = 100
+	// maxPreviewDescriptionLength 建议描述的长度上限（字符数）
+	maxPreviewDescriptionLength = 300
+)
+
+// PreviewService 获取网页元数据，用于创建网站前的预览和导入时批量补全
+type PreviewService struct {
+	websiteRepo *repository.WebsiteRepository
+	groupRepo   *repository.GroupRepository
+	iconService *IconService
+	client      *http.Client
+}
+
+func NewPreviewService(websiteRepo *repository.WebsiteRepository, groupRepo *repository.GroupRepository, iconService *IconService, client *http.Client) *PreviewService {
+	return &PreviewService{
+		websiteRepo: websiteRepo,
+		groupRepo:   groupRepo,
+		iconService: iconService,
+		client:      client,
+	}
+}
+
+// Preview 获取网页元数据并生成创建网站的建议内容
+// 图标发现失败不影响预览结果，此时 icon 为空，创建后会在后台重新发现
+func (s *PreviewService) Preview(ctx context.Context, userID, rawURL string) (*model.WebsitePreview, error) {
+	pageURL, err := parsePreviewURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, previewTimeout)
+	defer cancel()
+
+	body, finalURL, err := s.fetchPage(ctx, pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPreviewFetch, err)
+	}
+	meta := parsePageMetadata(body, finalURL)
+
+	preview := &model.WebsitePreview{
+		Website: model.CreateWebsiteRequest{
+			Name:        suggestWebsiteName(meta, finalURL),
+			URL:         suggestWebsiteURL(meta, finalURL),
+			Description: suggestWebsiteDescription(meta),
+		},
+		Metadata: *meta,
+	}
+
+	if icon, err := s.iconService.DiscoverFromPage(ctx, finalURL, body); err == nil {
+		ref := icon.URL()
+		preview.Website.Icon = &ref
+	}
+
+	group, err := s.suggestGroup(userID, finalURL, meta)
+	if err != nil {
+		return nil, err
+	}
+	if group != nil {
+		preview.SuggestedGroup = group
+		preview.Website.GroupId = &group.ID
+	}
+
+	return preview, nil
+}
+
+// FillMetadata 为缺少名称或描述的网站并发获取页面元数据并补全，返回补全的网站数量和未能补全的网站
+// 只补全空字段，已有内容保持不变；最多请求 maxPreviewFillPages 个页面，总耗时不超过 previewFillTimeout，
+// ctx 取消（如客户端断开）时立即停止
+func (s *PreviewService) FillMetadata(ctx context.Context, websites []model.Website) (int, []model.ImportItem) {
+	ctx, cancel := context.WithTimeout(ctx, previewFillTimeout)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		filled   int
+		unfilled = []model.ImportItem{}
+	)
+	fail := func(w *model.Website, reason string) {
+		mu.Lock()
+		unfilled = append(unfilled, model.ImportItem{ID: w.ID, Name: w.Name, Reason: reason})
+		mu.Unlock()
+	}
+	sem := make(chan struct{}, previewConcurrency)
+
+	fetched := 0
+	for i := range websites {
+		w := &websites[i]
+		needsName := strings.TrimSpace(w.Name) == "" || w.Name == w.URL
+		needsDescription := w.Description == nil || strings.TrimSpace(*w.Description) == ""
+		if (!needsName && !needsDescription) || !isValidWebURL(w.URL) {
+			continue
+		}
+
+		if fetched >= maxPreviewFillPages {
+			fail(w, fmt.Sprintf("skipped %s: at most %d pages are fetched per import", w.URL, maxPreviewFillPages))
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(w, fmt.Sprintf("skipped %s: metadata fetching stopped: %v", w.URL, ctx.Err()))
+			continue
+		}
+		fetched++
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			pageCtx, cancel := context.WithTimeout(ctx, previewPageTimeout)
+			defer cancel()
+
+			pageURL, err := url.Parse(w.URL)
+			if err != nil {
+				fail(w, err.Error())
+				return
+			}
+			body, finalURL, err := s.fetchPage(pageCtx, pageURL)
+			if err != nil {
+				fail(w, err.Error())
+				return
+			}
+			meta := parsePageMetadata(body, finalURL)
+
+			changed := false
+			if needsName {
+				if name := suggestWebsiteName(meta, finalURL); name != "" {
+					w.Name = name
+					changed = true
+				}
+			}
+			if needsDescription {
+				if description := suggestWebsiteDescription(meta); description != nil {
+					w.Description = description
+					changed = true
+				}
+			}
+
+			if changed {
+				mu.Lock()
+				filled++
+				mu.Unlock()
+			} else {
+				fail(w, fmt.Sprintf("%s has no usable title or description", w.URL))
+			}
+		}()
+	}
+
+	wg.Wait()
+	return filled, unfilled
+}
+
+// fetchPage 下载 HTML 页面并转换为 UTF-8，超过 maxPreviewPageSize 的部分被截断
+func (s *PreviewService) fetchPage(ctx context.Context, pageURL *url.URL) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", "nav-panel-preview/"+version.Version)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, fmt.Errorf("%s returned status %d", pageURL, resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, nil, fmt.Errorf("%s is not an html page (%s)", pageURL, mediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPreviewPageSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 按 Content-Type、BOM 和 <meta charset> 识别编码，兼容 GBK 等非 UTF-8 页面
+	encoding, _, _ := charset.DetermineEncoding(data, contentType)
+	if decoded, err := encoding.NewDecoder().Bytes(data); err == nil {
+		data = decoded
+	}
+
+	return data, resp.Request.URL, nil
+}
+
+// parsePreviewURL 解析预览地址，省略协议时按 https 处理
+func parsePreviewURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, fmt.Errorf("%w: url is required", ErrInvalidPreviewURL)
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPreviewURL, rawURL)
+	}
+	u.Fragment = ""
+	return u, nil
+}
+
+// parsePageMetadata 解析页面 <head> 中的标题、描述、canonical 以及 OpenGraph 和 Twitter 卡片字段
+func parsePageMetadata(body []byte, pageURL *url.URL) *model.PageMetadata {
+	meta := &model.PageMetadata{
+		URL:       pageURL.String(),
+		Keywords:  []string{},
+		OpenGraph: map[string]string{},
+		Twitter:   map[string]string{},
+	}
+
+	var applicationName string
+	inTitle := false
+	z := xhtml.NewTokenizer(bytes.NewReader(body))
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			return finishPageMetadata(meta, applicationName)
+
+		case xhtml.TextToken:
+			if inTitle && meta.Title == "" {
+				meta.Title = collapseSpace(string(z.Text()))
+			}
+
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return finishPageMetadata(meta, applicationName)
+			}
+
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if tag == "body" {
+				return finishPageMetadata(meta, applicationName)
+			}
+			if tag == "title" {
+				inTitle = tt == xhtml.StartTagToken
+				continue
+			}
+			if (tag != "meta" && tag != "link") || !hasAttr {
+				continue
+			}
+
+			attrs := make(map[string]string)
+			for {
+				key, value, more := z.TagAttr()
+				attrs[strings.ToLower(string(key))] = string(value)
+				if !more {
+					break
+				}
+			}
+
+			if tag == "link" {
+				if hasToken(attrs["rel"], "canonical") && meta.CanonicalURL == "" {
+					if ref, err := pageURL.Parse(strings.TrimSpace(attrs["href"])); err == nil && attrs["href"] != "" {
+						meta.CanonicalURL = ref.String()
+					}
+				}
+				continue
+			}
+
+			// OpenGraph 规范使用 property，但很多网站写成 name，两者都接受
+			key := strings.ToLower(strings.TrimSpace(attrs["property"]))
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(attrs["name"]))
+			}
+			content := collapseSpace(attrs["content"])
+			if key == "" || content == "" {
+				continue
+			}
+
+			switch {
+			case key == "description":
+				if meta.Description == "" {
+					meta.Description = content
+				}
+			case key == "keywords":
+				for _, keyword := range strings.FieldsFunc(content, func(r rune) bool { return r == ',' || r == '，' }) {
+					if keyword = strings.TrimSpace(keyword); keyword != "" {
+						meta.Keywords = append(meta.Keywords, keyword)
+					}
+				}
+			case key == "application-name":
+				applicationName = content
+			case strings.HasPrefix(key, "og:"):
+				if _, ok := meta.OpenGraph[key[3:]]; !ok {
+					meta.OpenGraph[key[3:]] = content
+				}
+			case strings.HasPrefix(key, "twitter:"):
+				if _, ok := meta.Twitter[key[8:]]; !ok {
+					meta.Twitter[key[8:]] = content
+				}
+			}
+		}
+	}
+}
+
+// finishPageMetadata 补全由多个来源决定的字段
+func finishPageMetadata(meta *model.PageMetadata, applicationName string) *model.PageMetadata {
+	meta.SiteName = meta.OpenGraph["site_name"]
+	if meta.SiteName == "" {
+		meta.SiteName = applicationName
+	}
+	return meta
+}
+
+// suggestWebsiteName 首页优先使用站点名称，其他页面优先使用页面标题，都没有时使用主机名
+func suggestWebsiteName(meta *model.PageMetadata, pageURL *url.URL) string {
+	candidates := []string{meta.OpenGraph["title"], meta.Twitter["title"], meta.Title, meta.SiteName}
+	if pageURL.Path == "" || pageURL.Path == "/" {
+		candidates = append([]string{meta.SiteName}, candidates...)
+	}
+	candidates = append(candidates, strings.TrimPrefix(pageURL.Hostname(), "www."))
+
+	for _, name := range candidates {
+		if name != "" {
+			return truncateRunes(name, maxPreviewNameLength)
+		}
+	}
+	return ""
+}
+
+// suggestWebsiteURL canonical 地址与页面属于同一网站时使用 canonical，否则使用跟随重定向后的地址
+func suggestWebsiteURL(meta *model.PageMetadata, pageURL *url.URL) string {
+	if meta.CanonicalURL != "" {
+		if canonical, err := url.Parse(meta.CanonicalURL); err == nil && isValidWebURL(meta.CanonicalURL) && hostKey(canonical) == hostKey(pageURL) {
+			return canonical.String()
+		}
+	}
+	return pageURL.String()
+}
+
+func suggestWebsiteDescription(meta *model.PageMetadata) *string {
+	for _, description := range []string{meta.Description, meta.OpenGraph["description"], meta.Twitter["description"]} {
+		if description != "" {
+			description = truncateRunes(description, maxPreviewDescriptionLength)
+			return &description
+		}
+	}
+	return nil
+}
+
+// suggestGroup 建议网站所属的分组
+// 优先选择同一主机名的已有网站最多的分组，其次选择名称出现在页面标题、描述或关键词中的分组（名称越长越优先）
+func (s *PreviewService) suggestGroup(userID string, pageURL *url.URL, meta *model.PageMetadata) (*model.Group, error) {
+	groups, err := s.groupRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	websites, err := s.websiteRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	host := hostKey(pageURL)
+	counts := make(map[string]int)
+	for _, w := range websites {
+		if w.GroupId == nil {
+			continue
+		}
+		if u, err := url.Parse(w.URL); err == nil && hostKey(u) == host {
+			counts[*w.GroupId]++
+		}
+	}
+
+	var best *model.Group
+	bestCount := 0
+	for i := range groups {
+		if counts[groups[i].ID] > bestCount {
+			best, bestCount = &groups[i], counts[groups[i].ID]
+		}
+	}
+	if best != nil {
+		return best, nil
+	}
+
+	text := strings.ToLower(strings.Join(append([]string{
+		meta.Title, meta.Description, meta.SiteName, meta.OpenGraph["title"], meta.OpenGraph["description"],
+	}, meta.Keywords...), " "))
+	for i := range groups {
+		name := strings.ToLower(strings.TrimSpace(groups[i].Name))
+		if utf8.RuneCountInString(name) < 2 || !strings.Contains(text, name) {
+			continue
+		}
+		if best == nil || utf8.RuneCountInString(name) > utf8.RuneCountInString(best.Name) {
+			best = &groups[i]
+		}
+	}
+	return best, nil
+}
+
+// hostKey 用于比较是否为同一网站的主机名，忽略大小写和 www. 前缀
+func hostKey(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// hasToken 判断以空白分隔的属性值（如 rel）中是否包含指定值
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(strings.ToLower(value)) {
+		if field == token {
+			return true
+		}
+	}
+	return false
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:limit-1])) + "…"
+}
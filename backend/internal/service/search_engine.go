@@ -1,46 +1,101 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/repository"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+var (
+	// ErrInvalidSearchEngine 搜索引擎的关键词、地址模板、请求方法或表单模板无效
+	ErrInvalidSearchEngine = errors.New("invalid search engine")
+	// ErrSearchEngineKeywordExists 关键词已被其他搜索引擎使用（忽略大小写）
+	ErrSearchEngineKeywordExists = errors.New("the keyword is already used by another search engine")
+	// ErrNoSearchEngine 没有可用的搜索引擎
+	ErrNoSearchEngine = errors.New("no search engine configured")
+	// ErrSearchEngineInTrash 回收站中的搜索引擎仍占用该ID
+	ErrSearchEngineInTrash = errors.New("a search engine with this id is in the trash, restore or purge it first")
+)
+
+// maxSearchKeywordLength 搜索引擎关键词的长度上限（字符数）
+const maxSearchKeywordLength = 32
+
 type SearchEngineService struct {
-	repo *repository.SearchEngineRepository
+	repo         *repository.SearchEngineRepository
+	categoryRepo *repository.SearchEngineCategoryRepository
+	iconService  *IconService
 }
 
-func NewSearchEngineService(repo *repository.SearchEngineRepository) *SearchEngineService {
-	return &SearchEngineService{repo: repo}
+func NewSearchEngineService(repo *repository.SearchEngineRepository, categoryRepo *repository.SearchEngineCategoryRepository, iconService *IconService) *SearchEngineService {
+	return &SearchEngineService{repo: repo, categoryRepo: categoryRepo, iconService: iconService}
 }
 
-func (s *SearchEngineService) GetAll() ([]model.SearchEngine, error) {
-	return s.repo.GetAll()
+func (s *SearchEngineService) GetAll(userID string) ([]model.SearchEngine, error) {
+	return s.repo.GetAll(userID)
 }
 
-func (s *SearchEngineService) GetByID(id string) (*model.SearchEngine, error) {
-	return s.repo.GetByID(id)
+func (s *SearchEngineService) GetByID(userID, id string) (*model.SearchEngine, error) {
+	return s.repo.GetByID(userID, id)
 }
 
 
 
-func (s *SearchEngineService) Create(req *model.CreateSearchEngineRequest) (*model.SearchEngine, error) {
+func (s *SearchEngineService) Create(userID string, req *model.CreateSearchEngineRequest) (*model.SearchEngine, error) {
 	// 检查是否已存在相同ID的搜索引擎
-	existing, _ := s.repo.GetByID(req.ID)
+	existing, _ := s.repo.GetByID(userID, req.ID)
 	if existing != nil {
 		return nil, fmt.Errorf("search engine with id '%s' already exists", req.ID)
 	}
+	if trashed, err := s.repo.InTrash(userID, req.ID); err != nil {
+		return nil, err
+	} else if trashed {
+		return nil, fmt.Errorf("%w: '%s'", ErrSearchEngineInTrash, req.ID)
+	}
+
+	method := ""
+	if req.Method != nil {
+		method = *req.Method
+	}
+	tpl, err := parseSearchTemplate(req.URL, method, req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	keyword, err := s.checkKeyword(userID, "", req.Keyword)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID, err := s.checkCategory(userID, req.CategoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	icon, err := s.iconService.StoreRef(req.Icon)
+	if err != nil {
+		return nil, err
+	}
 
 	engine := &model.SearchEngine{
 		ID:          req.ID,
 		Name:        req.Name,
-		URL:         req.URL,
-		Icon:        req.Icon,
+		URL:         strings.TrimSpace(req.URL),
+		Icon:        icon,
 		Placeholder: req.Placeholder,
 		IsDefault:   req.IsDefault != nil && *req.IsDefault,
+		Keyword:     keyword,
+		Method:      tpl.method,
+		Body:        normalizeSearchBody(req.Body),
+		CategoryId:  categoryID,
 	}
 
-	err := s.repo.Create(engine)
+	err = s.repo.Create(userID, engine)
 	if err != nil {
 		return nil, err
 	}
@@ -48,9 +103,9 @@ func (s *SearchEngineService) Create(req *model.CreateSearchEngineRequest) (*mod
 	return engine, nil
 }
 
-func (s *SearchEngineService) Update(id string, req *model.UpdateSearchEngineRequest) (*model.SearchEngine, error) {
+func (s *SearchEngineService) Update(userID, id string, req *model.UpdateSearchEngineRequest) (*model.SearchEngine, error) {
 	// 检查搜索引擎是否存在
-	_, err := s.repo.GetByID(id)
+	existing, err := s.repo.GetByID(userID, id)
 	if err != nil {
 		return nil, fmt.Errorf("search engine not found: %w", err)
 	}
@@ -60,15 +115,36 @@ func (s *SearchEngineService) Update(id string, req *model.UpdateSearchEngineReq
 	if req.Name != nil {
 		updates["name"] = *req.Name
 	}
-	if req.URL != nil {
-		updates["url"] = *req.URL
+	// 地址模板、请求方法和表单模板合并已有的值后一起校验
+	if req.URL != nil || req.Method != nil || req.Body != nil {
+		rawURL, method, body := existing.URL, existing.Method, existing.Body
+		if req.URL != nil {
+			rawURL = strings.TrimSpace(*req.URL)
+			updates["url"] = rawURL
+		}
+		if req.Method != nil {
+			method = *req.Method
+		}
+		if req.Body != nil {
+			body = normalizeSearchBody(req.Body)
+			updates["body"] = body
+		}
+		tpl, err := parseSearchTemplate(rawURL, method, body)
+		if err != nil {
+			return nil, err
+		}
+		updates["method"] = tpl.method
 	}
 	if req.Icon != nil {
 		if *req.Icon == "" {
 			// 空字符串表示清除图标，设置为 NULL
 			updates["icon"] = nil
 		} else {
-			updates["icon"] = *req.Icon
+			icon, err := s.iconService.StoreRef(req.Icon)
+			if err != nil {
+				return nil, err
+			}
+			updates["icon"] = *icon
 		}
 	}
 	if req.Placeholder != nil {
@@ -77,22 +153,36 @@ func (s *SearchEngineService) Update(id string, req *model.UpdateSearchEngineReq
 	if req.IsDefault != nil {
 		updates["is_default"] = *req.IsDefault
 	}
+	if req.Keyword != nil {
+		keyword, err := s.checkKeyword(userID, id, req.Keyword)
+		if err != nil {
+			return nil, err
+		}
+		updates["keyword"] = keyword
+	}
+	if req.CategoryId != nil {
+		categoryID, err := s.checkCategory(userID, req.CategoryId)
+		if err != nil {
+			return nil, err
+		}
+		updates["category_id"] = categoryID
+	}
 
 	// 执行更新
-	err = s.repo.Update(id, updates)
+	err = s.repo.Update(userID, id, updates)
 	if err != nil {
 		return nil, err
 	}
 
 	// 返回更新后的数据
-	return s.GetByID(id)
+	return s.GetByID(userID, id)
 }
 
 
 
-func (s *SearchEngineService) Delete(id string) error {
+func (s *SearchEngineService) Delete(userID, id string) error {
 	// 检查搜索引擎是否存在
-	existing, err := s.repo.GetByID(id)
+	existing, err := s.repo.GetByID(userID, id)
 	if err != nil {
 		return fmt.Errorf("search engine not found: %w", err)
 	}
@@ -100,17 +190,248 @@ func (s *SearchEngineService) Delete(id string) error {
 	// 如果删除的是默认搜索引擎，需要警告或设置新的默认引擎
 	if existing.IsDefault {
 		// 这里可以选择自动设置第一个为默认，或者返回错误要求用户先设置其他为默认
-		engines, err := s.repo.GetAll()
+		engines, err := s.repo.GetAll(userID)
 		if err == nil && len(engines) > 1 {
 			// 找到第一个不是当前删除的引擎，设为默认
 			for _, engine := range engines {
 				if engine.ID != id {
-					s.repo.Update(engine.ID, map[string]interface{}{"is_default": true})
+					s.repo.Update(userID, engine.ID, map[string]interface{}{"is_default": true})
 					break
 				}
 			}
 		}
 	}
 
-	return s.repo.Delete(id)
-} 
\ No newline at end of file
+	return s.repo.Trash(userID, id)
+}
+
+// GetTrash 获取回收站中的搜索引擎
+func (s *SearchEngineService) GetTrash(userID string) ([]model.TrashedSearchEngine, error) {
+	return s.repo.GetTrash(userID)
+}
+
+// Restore 从回收站恢复搜索引擎到原来的位置，关键词已被占用时清空关键词，分类已被删除时变为未分类
+func (s *SearchEngineService) Restore(userID, id string) (*model.SearchEngine, error) {
+	if err := s.repo.Restore(userID, id); err == sql.ErrNoRows {
+		return nil, ErrNotInTrash
+	} else if err != nil {
+		return nil, err
+	}
+
+	// 没有默认引擎时（如删除了唯一的搜索引擎）恢复的搜索引擎成为默认引擎
+	engines, err := s.repo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	hasDefault := false
+	for _, e := range engines {
+		hasDefault = hasDefault || e.IsDefault
+	}
+	if !hasDefault {
+		if err := s.repo.Update(userID, id, map[string]interface{}{"is_default": true}); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetByID(userID, id)
+}
+
+// Purge 永久删除回收站中的搜索引擎
+func (s *SearchEngineService) Purge(userID, id string) error {
+	if err := s.repo.Purge(userID, id); err == sql.ErrNoRows {
+		return ErrNotInTrash
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// EmptyTrash 清空回收站中的搜索引擎，返回删除的数量
+func (s *SearchEngineService) EmptyTrash(userID string) (int64, error) {
+	return s.repo.EmptyTrash(userID)
+}
+
+// Reorder 重新排序搜索引擎，列出的搜索引擎按给定顺序占据它们原来的位置，返回排序后的列表
+func (s *SearchEngineService) Reorder(userID string, req *model.ReorderSearchEnginesRequest) ([]model.SearchEngine, error) {
+	if len(req.EngineIds) == 0 {
+		return nil, fmt.Errorf("%w: engineIds must not be empty", ErrInvalidSearchEngine)
+	}
+
+	engines, err := s.repo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(engines))
+	for _, e := range engines {
+		existing[e.ID] = true
+	}
+	seen := make(map[string]bool, len(req.EngineIds))
+	for _, id := range req.EngineIds {
+		if !existing[id] {
+			return nil, fmt.Errorf("search engine '%s' not found: %w", id, sql.ErrNoRows)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: '%s' is listed more than once", ErrInvalidSearchEngine, id)
+		}
+		seen[id] = true
+	}
+
+	if err := s.repo.Reorder(userID, req.EngineIds); err != nil {
+		return nil, fmt.Errorf("failed to reorder search engines: %w", err)
+	}
+	return s.repo.GetAll(userID)
+}
+
+// Route 解析搜索内容并选择搜索引擎，返回最终的搜索请求，lang 替换模板中的 {lang}
+// 支持 "!gh foo" 和 "gh foo" 两种写法：第一个词是某个引擎的关键词时使用该引擎搜索其余内容，
+// 否则使用默认搜索引擎搜索全部内容。"!gh" 后没有内容时跳转到该引擎的首页
+func (s *SearchEngineService) Route(userID, q, lang string) (*model.SearchRoute, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, fmt.Errorf("%w: q is required", ErrInvalidSearchQuery)
+	}
+
+	first, rest := splitFirstWord(q)
+	keyword := strings.TrimPrefix(first, "!")
+	bang := keyword != first
+	if keyword != "" && (bang || rest != "") {
+		engine, err := s.repo.GetByKeyword(userID, strings.ToLower(keyword))
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if engine != nil {
+			return routeTo(engine, rest, lang)
+		}
+	}
+
+	engines, err := s.repo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	engine := defaultSearchEngine(engines)
+	if engine == nil {
+		return nil, ErrNoSearchEngine
+	}
+	return routeTo(engine, q, lang)
+}
+
+// Resolve 按搜索引擎的模板生成最终的搜索请求
+func (s *SearchEngineService) Resolve(userID, id string, req model.ResolveSearchRequest) (*model.ResolvedSearch, error) {
+	engine, err := s.repo.GetByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query is required", ErrInvalidSearchQuery)
+	}
+
+	tpl, err := parseSearchTemplate(engine.URL, engine.Method, engine.Body)
+	if err != nil {
+		return nil, err
+	}
+	resolved := tpl.resolve(query, strings.TrimSpace(req.Lang))
+	return &resolved, nil
+}
+
+// checkCategory 检查分类是否存在，空字符串返回 nil 表示未分类
+func (s *SearchEngineService) checkCategory(userID string, raw *string) (*string, error) {
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return nil, nil
+	}
+	id := strings.TrimSpace(*raw)
+	if _, err := s.categoryRepo.GetByID(userID, id); err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: category '%s' not found", ErrInvalidSearchEngine, id)
+	} else if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// checkKeyword 规范化关键词并检查是否被其他搜索引擎占用，空字符串返回 nil 表示没有关键词
+func (s *SearchEngineService) checkKeyword(userID, id string, raw *string) (*string, error) {
+	keyword, err := normalizeSearchKeyword(raw)
+	if err != nil || keyword == nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetByKeyword(userID, *keyword)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if existing != nil && existing.ID != id {
+		return nil, fmt.Errorf("%w: '%s' is used by '%s'", ErrSearchEngineKeywordExists, *keyword, existing.Name)
+	}
+	return keyword, nil
+}
+
+// normalizeSearchKeyword 去掉前导的 ! 并转为小写，关键词只能包含字母、数字和 - _ .
+func normalizeSearchKeyword(raw *string) (*string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	keyword := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(*raw), "!"))
+	if keyword == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(keyword) > maxSearchKeywordLength {
+		return nil, fmt.Errorf("%w: keyword exceeds %d characters", ErrInvalidSearchEngine, maxSearchKeywordLength)
+	}
+	for _, r := range keyword {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.", r) {
+			return nil, fmt.Errorf("%w: keyword may only contain letters, digits, '-', '_' and '.'", ErrInvalidSearchEngine)
+		}
+	}
+	return &keyword, nil
+}
+
+// splitFirstWord 拆分出第一个词和其余内容，其余内容去掉首尾空白
+func splitFirstWord(q string) (string, string) {
+	i := strings.IndexFunc(q, unicode.IsSpace)
+	if i < 0 {
+		return q, ""
+	}
+	return q[:i], strings.TrimSpace(q[i:])
+}
+
+// defaultSearchEngine 选出默认搜索引擎，没有设置默认时使用排在最前的，列表为空时返回 nil
+func defaultSearchEngine(engines []model.SearchEngine) *model.SearchEngine {
+	for i := range engines {
+		if engines[i].IsDefault {
+			return &engines[i]
+		}
+	}
+	if len(engines) == 0 {
+		return nil
+	}
+	return &engines[0]
+}
+
+// routeTo 生成使用指定搜索引擎的搜索请求，query 为空时跳转到该引擎的首页
+// 旧版本保存的地址可能不是有效的模板，此时返回包含引擎名称的 ErrInvalidSearchEngine
+func routeTo(engine *model.SearchEngine, query, lang string) (*model.SearchRoute, error) {
+	tpl, err := parseSearchTemplate(engine.URL, engine.Method, engine.Body)
+	if err != nil {
+		return nil, fmt.Errorf("search engine '%s' cannot be used: %w", engine.Name, err)
+	}
+
+	route := &model.SearchRoute{Engine: engine, Query: query}
+	if query == "" {
+		route.Request = model.ResolvedSearch{Method: http.MethodGet, URL: tpl.homepage(), Encoding: tpl.encoding}
+	} else {
+		route.Request = tpl.resolve(query, lang)
+	}
+	return route, nil
+}
+
+// normalizeSearchBody 去掉表单模板首尾的空白，为空时返回 nil
+func normalizeSearchBody(body *string) *string {
+	if body == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*body)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrSearchEngineCategoryNotFound 搜索引擎分类不存在
+	ErrSearchEngineCategoryNotFound = errors.New("search engine category not found")
+	// ErrSearchEngineCategoryExists 同名分类已存在（名称忽略大小写）
+	ErrSearchEngineCategoryExists = errors.New("a search engine category with this name already exists")
+	// ErrInvalidSearchEngineCategory 分类名称为空或过长、重新排序的列表不完整
+	ErrInvalidSearchEngineCategory = errors.New("invalid search engine category")
+)
+
+// maxSearchEngineCategoryNameLength 分类名称的长度上限（字符数）
+const maxSearchEngineCategoryNameLength = 50
+
+type SearchEngineCategoryService struct {
+	repo *repository.SearchEngineCategoryRepository
+}
+
+func NewSearchEngineCategoryService(repo *repository.SearchEngineCategoryRepository) *SearchEngineCategoryService {
+	return &SearchEngineCategoryService{repo: repo}
+}
+
+// GetAll 按排序获取所有分类
+func (s *SearchEngineCategoryService) GetAll(userID string) ([]model.SearchEngineCategory, error) {
+	return s.repo.GetAll(userID)
+}
+
+// Create 创建分类，同名（忽略大小写）分类已存在时返回 ErrSearchEngineCategoryExists
+func (s *SearchEngineCategoryService) Create(userID string, req model.CreateSearchEngineCategoryRequest) (*model.SearchEngineCategory, error) {
+	name, err := normalizeSearchEngineCategoryName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.FindByName(userID, name); err == nil {
+		return nil, ErrSearchEngineCategoryExists
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return s.repo.Create(userID, name)
+}
+
+// Update 重命名分类，只改变大小写的重命名是允许的
+func (s *SearchEngineCategoryService) Update(userID, id string, req model.UpdateSearchEngineCategoryRequest) (*model.SearchEngineCategory, error) {
+	if req.Name == nil {
+		category, err := s.repo.GetByID(userID, id)
+		if err == sql.ErrNoRows {
+			return nil, ErrSearchEngineCategoryNotFound
+		}
+		return category, err
+	}
+
+	name, err := normalizeSearchEngineCategoryName(*req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if other, err := s.repo.FindByName(userID, name); err == nil && other.ID != id {
+		return nil, ErrSearchEngineCategoryExists
+	} else if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	category, err := s.repo.Rename(userID, id, name)
+	if err == sql.ErrNoRows {
+		return nil, ErrSearchEngineCategoryNotFound
+	}
+	return category, err
+}
+
+// Delete 删除分类，分类中的搜索引擎变为未分类
+func (s *SearchEngineCategoryService) Delete(userID, id string) error {
+	if err := s.repo.Delete(userID, id); err == sql.ErrNoRows {
+		return ErrSearchEngineCategoryNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reorder 重新排序分类，列表必须包含每个分类且只出现一次
+func (s *SearchEngineCategoryService) Reorder(userID string, categoryIds []string) ([]model.SearchEngineCategory, error) {
+	categories, err := s.repo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		existing[c.ID] = true
+	}
+	seen := make(map[string]bool, len(categoryIds))
+	for _, id := range categoryIds {
+		if !existing[id] {
+			return nil, fmt.Errorf("%w: '%s'", ErrSearchEngineCategoryNotFound, id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: '%s' is listed more than once", ErrInvalidSearchEngineCategory, id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(existing) {
+		return nil, fmt.Errorf("%w: categoryIds must list every category", ErrInvalidSearchEngineCategory)
+	}
+
+	if err := s.repo.Reorder(userID, categoryIds); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAll(userID)
+}
+
+// normalizeSearchEngineCategoryName 去掉首尾空白并合并连续空白
+func normalizeSearchEngineCategoryName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidSearchEngineCategory)
+	}
+	if utf8.RuneCountInString(name) > maxSearchEngineCategoryNameLength {
+		return "", fmt.Errorf("%w: name exceeds %d characters", ErrInvalidSearchEngineCategory, maxSearchEngineCategoryNameLength)
+	}
+	return name, nil
+}
+
+// categorizeSearchEngines 按分类的顺序归类搜索引擎，分类内保持搜索引擎的顺序
+// 不属于任何分类（或分类已不存在）的搜索引擎放在 uncategorized 中
+func categorizeSearchEngines(categories []model.SearchEngineCategory, engines []model.SearchEngine) ([]model.SearchEngineCategoryWithEngines, []model.SearchEngine) {
+	grouped := make([]model.SearchEngineCategoryWithEngines, len(categories))
+	index := make(map[string]int, len(categories))
+	for i, c := range categories {
+		grouped[i] = model.SearchEngineCategoryWithEngines{SearchEngineCategory: c, Engines: []model.SearchEngine{}}
+		index[c.ID] = i
+	}
+
+	uncategorized := []model.SearchEngine{}
+	for _, e := range engines {
+		if e.CategoryId != nil {
+			if i, ok := index[*e.CategoryId]; ok {
+				grouped[i].Engines = append(grouped[i].Engines, e)
+				continue
+			}
+		}
+		uncategorized = append(uncategorized, e)
+	}
+	return grouped, uncategorized
+}
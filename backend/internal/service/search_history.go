@@ -0,0 +1,229 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSearchHistoryNotFound 搜索记录不存在
+	ErrSearchHistoryNotFound = errors.New("search history entry not found")
+	// ErrInvalidSearchHistory 查询为空或过长、引用了不存在的搜索引擎
+	ErrInvalidSearchHistory = errors.New("invalid search history entry")
+	// ErrInvalidSearchHistorySettings 保留天数或排除规则无效
+	ErrInvalidSearchHistorySettings = errors.New("invalid search history settings")
+)
+
+const (
+	// maxSearchQueryLength 记录的查询长度上限（字符数）
+	maxSearchQueryLength = 200
+	// maxSearchHistoryRetentionDays 搜索历史保留天数的上限
+	maxSearchHistoryRetentionDays = 3650
+	// maxSearchHistoryExcludePatterns 排除规则的数量上限
+	maxSearchHistoryExcludePatterns = 50
+	// defaultSearchHistoryLimit 历史列表和建议默认返回的数量
+	defaultSearchHistoryLimit = 10
+	// maxSearchHistoryLimit 历史列表和建议最多返回的数量
+	maxSearchHistoryLimit = 100
+)
+
+type SearchHistoryService struct {
+	repo             *repository.SearchHistoryRepository
+	searchEngineRepo *repository.SearchEngineRepository
+}
+
+func NewSearchHistoryService(repo *repository.SearchHistoryRepository, searchEngineRepo *repository.SearchEngineRepository) *SearchHistoryService {
+	return &SearchHistoryService{repo: repo, searchEngineRepo: searchEngineRepo}
+}
+
+// Record 记录一次搜索，关闭搜索历史或查询匹配排除规则时不记录并返回 nil
+func (s *SearchHistoryService) Record(userID string, req model.RecordSearchRequest) (*model.SearchHistoryEntry, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query is required", ErrInvalidSearchHistory)
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return nil, fmt.Errorf("%w: query exceeds %d characters", ErrInvalidSearchHistory, maxSearchQueryLength)
+	}
+	if req.EngineId != nil {
+		if _, err := s.searchEngineRepo.GetByID(userID, *req.EngineId); err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: search engine '%s' not found", ErrInvalidSearchHistory, *req.EngineId)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	settings, err := s.settings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled || matchesExcludePatterns(settings.ExcludePatterns, query) {
+		return nil, nil
+	}
+
+	entry := &model.SearchHistoryEntry{
+		ID:         uuid.New().String(),
+		Query:      query,
+		EngineId:   req.EngineId,
+		SearchedAt: time.Now(),
+	}
+	if err := s.repo.Create(userID, entry, normalizeSearchQuery(query)); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Track 记录经 /search 跳转的搜索，失败只写日志，不影响跳转
+func (s *SearchHistoryService) Track(userID, query, engineID string) {
+	if _, err := s.Record(userID, model.RecordSearchRequest{Query: query, EngineId: &engineID}); err != nil && !errors.Is(err, ErrInvalidSearchHistory) {
+		log.Printf("Failed to record search history: %v", err)
+	}
+}
+
+// GetRecent 获取最近的搜索记录，limit 不大于0时使用默认值，超过上限时按上限处理
+func (s *SearchHistoryService) GetRecent(userID string, limit int) ([]model.SearchHistoryEntry, error) {
+	if _, err := s.settings(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRecent(userID, searchHistoryLimit(limit))
+}
+
+// Suggest 根据搜索历史返回建议，按频率和新近程度排序，q 为空时返回最常用的查询
+func (s *SearchHistoryService) Suggest(userID, q string, limit int) ([]model.SearchSuggestion, error) {
+	if _, err := s.settings(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.Suggest(userID, normalizeSearchQuery(q), time.Now(), searchHistoryLimit(limit))
+}
+
+// Delete 删除一条搜索记录
+func (s *SearchHistoryService) Delete(userID, id string) error {
+	if err := s.repo.Delete(userID, id); err == sql.ErrNoRows {
+		return ErrSearchHistoryNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Clear 清空搜索历史，返回删除的数量
+func (s *SearchHistoryService) Clear(userID string) (int64, error) {
+	return s.repo.Clear(userID)
+}
+
+// GetSettings 获取搜索历史设置
+func (s *SearchHistoryService) GetSettings(userID string) (*model.SearchHistorySettings, error) {
+	return s.repo.GetSettings(userID)
+}
+
+// UpdateSettings 更新搜索历史设置，已有记录中超出保留期限或匹配新排除规则的会立即删除
+func (s *SearchHistoryService) UpdateSettings(userID string, req model.UpdateSearchHistorySettingsRequest) (*model.SearchHistorySettings, error) {
+	settings, err := s.repo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.RetentionDays != nil {
+		if *req.RetentionDays < 0 || *req.RetentionDays > maxSearchHistoryRetentionDays {
+			return nil, fmt.Errorf("%w: retentionDays must be between 0 and %d", ErrInvalidSearchHistorySettings, maxSearchHistoryRetentionDays)
+		}
+		settings.RetentionDays = *req.RetentionDays
+	}
+	if req.ExcludePatterns != nil {
+		patterns, err := normalizeExcludePatterns(*req.ExcludePatterns)
+		if err != nil {
+			return nil, err
+		}
+		settings.ExcludePatterns = patterns
+	}
+
+	if err := s.repo.SaveSettings(userID, settings); err != nil {
+		return nil, err
+	}
+
+	if err := s.purgeExpired(userID, settings); err != nil {
+		return nil, err
+	}
+	if len(settings.ExcludePatterns) > 0 {
+		match := func(query string) bool { return matchesExcludePatterns(settings.ExcludePatterns, query) }
+		if err := s.repo.DeleteMatching(userID, match); err != nil {
+			return nil, err
+		}
+	}
+
+	return settings, nil
+}
+
+// settings 获取设置并删除超出保留期限的记录，读取历史之前调用，保证过期记录不会再出现
+func (s *SearchHistoryService) settings(userID string) (*model.SearchHistorySettings, error) {
+	settings, err := s.repo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.purgeExpired(userID, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *SearchHistoryService) purgeExpired(userID string, settings *model.SearchHistorySettings) error {
+	if settings.RetentionDays == 0 {
+		return nil
+	}
+	return s.repo.DeleteBefore(userID, time.Now().AddDate(0, 0, -settings.RetentionDays))
+}
+
+// normalizeSearchQuery 合并连续空白并转为小写，用于合并相同的查询
+func normalizeSearchQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// normalizeExcludePatterns 校验排除规则，规则为正则表达式，匹配时忽略大小写
+func normalizeExcludePatterns(patterns []string) ([]string, error) {
+	if len(patterns) > maxSearchHistoryExcludePatterns {
+		return nil, fmt.Errorf("%w: at most %d exclude patterns are allowed", ErrInvalidSearchHistorySettings, maxSearchHistoryExcludePatterns)
+	}
+
+	result := []string{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			return nil, fmt.Errorf("%w: invalid exclude pattern '%s'", ErrInvalidSearchHistorySettings, pattern)
+		}
+		result = append(result, pattern)
+	}
+	return result, nil
+}
+
+// matchesExcludePatterns 查询是否匹配任一排除规则，无法编译的规则忽略
+func matchesExcludePatterns(patterns []string, query string) bool {
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile("(?i)" + pattern); err == nil && re.MatchString(query) {
+			return true
+		}
+	}
+	return false
+}
+
+func searchHistoryLimit(limit int) int {
+	if limit <= 0 {
+		return defaultSearchHistoryLimit
+	}
+	return min(limit, maxSearchHistoryLimit)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// 搜索地址模板
+//
+// 占位符：{query} 按模板的字符编码做百分号编码后的搜索内容，{query:raw} 原样插入的搜索内容，
+// {lang} 语言代码，旧版的 {} 等同于 {query}。
+// 指令：{encoding=gbk} 指定搜索内容使用的字符编码（默认 UTF-8，名称同 HTML 的 charset），
+// 可以写在地址或表单模板中，生成请求时去掉。
+// 模板中的其他内容视为已经编码，原样保留。
+
+// searchPlaceholderPattern 匹配模板中的占位符和指令
+var searchPlaceholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+const (
+	// defaultSearchLang 没有指定语言时替换 {lang} 的值
+	defaultSearchLang = "en"
+	// searchFormContentType POST 搜索请求的表单类型
+	searchFormContentType = "application/x-www-form-urlencoded"
+)
+
+// searchTemplate 解析后的搜索地址模板
+type searchTemplate struct {
+	url      string
+	method   string
+	fields   []searchTemplateField // POST 表单字段，值为模板
+	encoding string                // 字符编码的规范名称
+	encoder  encoding.Encoding     // 为 nil 时使用 UTF-8
+	explicit bool                  // 模板中是否写了 {encoding=...}
+}
+
+type searchTemplateField struct {
+	name  string
+	value string
+}
+
+// parseSearchTemplate 解析并校验搜索引擎的地址模板、请求方法和表单模板
+func parseSearchTemplate(rawURL, method string, body *string) (*searchTemplate, error) {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet && method != http.MethodPost {
+		return nil, fmt.Errorf("%w: method must be GET or POST", ErrInvalidSearchEngine)
+	}
+
+	t := &searchTemplate{method: method, encoding: "utf-8"}
+
+	var err error
+	if t.url, err = t.stripDirectives(strings.TrimSpace(rawURL)); err != nil {
+		return nil, err
+	}
+	hasQuery, err := checkSearchPlaceholders(t.url)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyTemplate := ""
+	if body != nil {
+		bodyTemplate = strings.TrimSpace(*body)
+	}
+	if bodyTemplate != "" {
+		if method != http.MethodPost {
+			return nil, fmt.Errorf("%w: body is only allowed for POST engines", ErrInvalidSearchEngine)
+		}
+		if bodyTemplate, err = t.stripDirectives(bodyTemplate); err != nil {
+			return nil, err
+		}
+		for _, pair := range strings.Split(bodyTemplate, "&") {
+			if pair == "" {
+				continue
+			}
+			name, value, _ := strings.Cut(pair, "=")
+			if name == "" || searchPlaceholderPattern.MatchString(name) {
+				return nil, fmt.Errorf("%w: body field '%s' must have a literal name", ErrInvalidSearchEngine, pair)
+			}
+			found, err := checkSearchPlaceholders(value)
+			if err != nil {
+				return nil, err
+			}
+			hasQuery = hasQuery || found
+			t.fields = append(t.fields, searchTemplateField{name: name, value: value})
+		}
+	}
+
+	if !hasQuery {
+		return nil, fmt.Errorf("%w: url or body must contain the {query} placeholder", ErrInvalidSearchEngine)
+	}
+
+	// 用示例内容展开后必须是 http(s) 地址，占位符可以出现在主机名中，如 https://{lang}.wikipedia.org/wiki/{query}
+	if !isValidWebURL(t.expand(t.url, "test", defaultSearchLang, true)) {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidSearchEngine)
+	}
+
+	return t, nil
+}
+
+// stripDirectives 去掉模板中的 {encoding=...} 指令并记录字符编码，地址和表单模板中的指令必须一致
+func (t *searchTemplate) stripDirectives(tpl string) (string, error) {
+	var err error
+	stripped := searchPlaceholderPattern.ReplaceAllStringFunc(tpl, func(m string) string {
+		name, ok := strings.CutPrefix(m[1:len(m)-1], "encoding=")
+		if !ok || err != nil {
+			return m
+		}
+
+		enc, e := htmlindex.Get(strings.TrimSpace(name))
+		if e != nil {
+			err = fmt.Errorf("%w: unsupported encoding '%s'", ErrInvalidSearchEngine, name)
+			return ""
+		}
+		canonical, _ := htmlindex.Name(enc)
+		if t.explicit && canonical != t.encoding {
+			err = fmt.Errorf("%w: conflicting encodings '%s' and '%s'", ErrInvalidSearchEngine, t.encoding, canonical)
+			return ""
+		}
+		t.encoding, t.explicit = canonical, true
+		if canonical != "utf-8" {
+			t.encoder = enc
+		}
+		return ""
+	})
+	return stripped, err
+}
+
+// checkSearchPlaceholders 检查模板中的占位符是否都受支持，返回是否包含搜索内容
+func checkSearchPlaceholders(tpl string) (bool, error) {
+	hasQuery := false
+	for _, m := range searchPlaceholderPattern.FindAllStringSubmatch(tpl, -1) {
+		switch m[1] {
+		case "", "query", "query:raw":
+			hasQuery = true
+		case "lang":
+		default:
+			return false, fmt.Errorf("%w: unknown placeholder '%s'", ErrInvalidSearchEngine, m[0])
+		}
+	}
+	return hasQuery, nil
+}
+
+// resolve 生成最终的搜索请求，lang 为空时使用 en
+func (t *searchTemplate) resolve(query, lang string) model.ResolvedSearch {
+	if lang == "" {
+		lang = defaultSearchLang
+	}
+
+	r := model.ResolvedSearch{
+		Method:   t.method,
+		URL:      t.expand(t.url, query, lang, true),
+		Encoding: t.encoding,
+	}
+	if t.method == http.MethodPost {
+		r.ContentType = searchFormContentType
+		r.Fields = []model.SearchFormField{}
+		pairs := make([]string, 0, len(t.fields))
+		for _, f := range t.fields {
+			r.Fields = append(r.Fields, model.SearchFormField{Name: decodeSearchLiteral(f.name), Value: t.expand(f.value, query, lang, false)})
+			pairs = append(pairs, f.name+"="+t.expand(f.value, query, lang, true))
+		}
+		r.Body = strings.Join(pairs, "&")
+	}
+	return r
+}
+
+// homepage 搜索引擎的首页，用于没有搜索内容的 "!关键词"
+func (t *searchTemplate) homepage() string {
+	u, err := url.Parse(t.expand(t.url, "", defaultSearchLang, true))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/"
+}
+
+// expand 展开模板中的占位符
+// encoded 为 true 时对替换内容做百分号编码（空格编码为 %20，占位符位于路径中时同样有效），模板中的其他内容原样保留；
+// 为 false 时生成未编码的文本，用于表单字段：模板中的其他内容解码，替换内容原样插入
+func (t *searchTemplate) expand(tpl, query, lang string, encoded bool) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range searchPlaceholderPattern.FindAllStringSubmatchIndex(tpl, -1) {
+		b.WriteString(t.literal(tpl[last:m[0]], encoded))
+		switch tpl[m[2]:m[3]] {
+		case "", "query":
+			b.WriteString(t.value(query, encoded))
+		case "query:raw":
+			b.WriteString(query)
+		case "lang":
+			b.WriteString(t.value(lang, encoded))
+		}
+		last = m[1]
+	}
+	b.WriteString(t.literal(tpl[last:], encoded))
+	return b.String()
+}
+
+func (t *searchTemplate) literal(s string, encoded bool) string {
+	if encoded {
+		return s
+	}
+	return decodeSearchLiteral(s)
+}
+
+// value 按模板的字符编码对替换内容做百分号编码，无法用该编码表示的字符替换为该编码的替代字符
+func (t *searchTemplate) value(s string, encoded bool) string {
+	if !encoded {
+		return s
+	}
+	if t.encoder != nil {
+		if converted, err := encoding.ReplaceUnsupported(t.encoder.NewEncoder()).String(s); err == nil {
+			s = converted
+		}
+	}
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func decodeSearchLiteral(s string) string {
+	if decoded, err := url.QueryUnescape(s); err == nil {
+		return decoded
+	}
+	return s
+}
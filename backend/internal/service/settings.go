@@ -4,42 +4,71 @@ import (
 	"encoding/json"
 	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/repository"
+	"nav-panel-backend/internal/version"
+	"time"
 )
 
 type SettingsService struct {
 	settingsRepo     *repository.SettingsRepository
 	websiteRepo      *repository.WebsiteRepository
 	searchEngineRepo *repository.SearchEngineRepository
+	categoryRepo     *repository.SearchEngineCategoryRepository
+	groupRepo        *repository.GroupRepository
+	tagRepo          *repository.TagRepository
+	importRepo       *repository.ImportRepository
+	iconService      *IconService
+	previewService   *PreviewService
 }
 
-func NewSettingsService(settingsRepo *repository.SettingsRepository) *SettingsService {
+func NewSettingsService(settingsRepo *repository.SettingsRepository, iconService *IconService, previewService *PreviewService) *SettingsService {
 	return &SettingsService{
-		settingsRepo: settingsRepo,
+		settingsRepo:   settingsRepo,
+		iconService:    iconService,
+		previewService: previewService,
 	}
 }
 
 // SetRepositories 设置其他仓库依赖（用于导入导出功能）
-func (s *SettingsService) SetRepositories(websiteRepo *repository.WebsiteRepository, searchEngineRepo *repository.SearchEngineRepository) {
+func (s *SettingsService) SetRepositories(websiteRepo *repository.WebsiteRepository, searchEngineRepo *repository.SearchEngineRepository, categoryRepo *repository.SearchEngineCategoryRepository, groupRepo *repository.GroupRepository, tagRepo *repository.TagRepository, importRepo *repository.ImportRepository) {
 	s.websiteRepo = websiteRepo
 	s.searchEngineRepo = searchEngineRepo
+	s.categoryRepo = categoryRepo
+	s.groupRepo = groupRepo
+	s.tagRepo = tagRepo
+	s.importRepo = importRepo
 }
 
 
 
-func (s *SettingsService) Get() (*model.AppSettingsResponse, error) {
+func (s *SettingsService) Get(userID string) (*model.AppSettingsResponse, error) {
 	// 获取设置数据
-	settings, err := s.settingsRepo.Get()
+	settings, err := s.settingsRepo.Get(userID)
 	if err != nil {
 		return nil, err
 	}
 
 	// 获取搜索引擎数据
-	searchEngines, err := s.searchEngineRepo.GetAll()
+	searchEngines, err := s.searchEngineRepo.GetAll(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 解析JSON配置
+	categories, err := s.categoryRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := parseSettings(settings)
+
+	// 将搜索引擎数据添加到搜索配置中，同时按分类归类
+	response.Search.Engines = emptyIfNil(searchEngines)
+	response.Search.Categories, response.Search.Uncategorized = categorizeSearchEngines(categories, searchEngines)
+
+	return response, nil
+}
+
+// parseSettings 解析数据库中的JSON配置，无法解析的部分使用默认值
+func parseSettings(settings *model.AppSettings) *model.AppSettingsResponse {
 	var layout model.LayoutConfig
 	if err := json.Unmarshal([]byte(settings.LayoutConfig), &layout); err != nil {
 		// 使用默认值
@@ -77,9 +106,6 @@ func (s *SettingsService) Get() (*model.AppSettingsResponse, error) {
 		}
 	}
 
-	// 将搜索引擎数据添加到搜索配置中
-	searchConfig.Engines = searchEngines
-
 	return &model.AppSettingsResponse{
 		Theme:      settings.Theme,
 		Layout:     layout,
@@ -87,10 +113,10 @@ func (s *SettingsService) Get() (*model.AppSettingsResponse, error) {
 		CardStyle:  cardStyle,
 		Search:     searchConfig,
 		UpdatedAt:  settings.UpdatedAt,
-	}, nil
+	}
 }
 
-func (s *SettingsService) Update(req *model.UpdateSettingsRequest) (*model.AppSettingsResponse, error) {
+func (s *SettingsService) Update(userID string, req *model.UpdateSettingsRequest) (*model.AppSettingsResponse, error) {
 	// 构建更新字段
 	updates := make(map[string]interface{})
 
@@ -126,10 +152,10 @@ func (s *SettingsService) Update(req *model.UpdateSettingsRequest) (*model.AppSe
 		// 处理搜索配置，但不包含engines数组（engines单独管理）
 		searchMap, ok := (*req.Search).(map[string]interface{})
 		if ok {
-			// 移除engines字段，只保存其他搜索配置
+			// 移除engines及分类字段，只保存其他搜索配置
 			searchConfigOnly := make(map[string]interface{})
 			for k, v := range searchMap {
-				if k != "engines" {
+				if k != "engines" && k != "categories" && k != "uncategorized" {
 					searchConfigOnly[k] = v
 				}
 			}
@@ -144,86 +170,112 @@ func (s *SettingsService) Update(req *model.UpdateSettingsRequest) (*model.AppSe
 
 	// 执行更新
 	if len(updates) > 0 {
-		err := s.settingsRepo.Update(updates)
+		err := s.settingsRepo.Update(userID, updates)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	// 返回更新后的设置
-	return s.Get()
+	return s.Get(userID)
 }
 
-func (s *SettingsService) Export() (*model.ExportData, error) {
+// Export 导出指定账号的所有数据
+func (s *SettingsService) Export(userID string) (*model.ExportData, error) {
 	// 获取所有数据
-	websites, err := s.websiteRepo.GetAll()
+	groups, err := s.groupRepo.GetAll(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	searchEngines, err := s.searchEngineRepo.GetAll()
+	tagsWithCount, err := s.tagRepo.GetAll(userID)
 	if err != nil {
 		return nil, err
 	}
+	tags := make([]model.Tag, len(tagsWithCount))
+	for i := range tagsWithCount {
+		tags[i] = tagsWithCount[i].Tag
+	}
 
-	settings, err := s.settingsRepo.Get()
+	websites, err := s.websiteRepo.GetAll(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.ExportData{
-		Websites:      websites,
-		SearchEngines: searchEngines,
-		Settings:      settings,
-	}, nil
-}
+	searchEngines, err := s.searchEngineRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *SettingsService) Import(data *model.ImportData) error {
-	// 导入网站数据
-	if data.Websites != nil {
-		// 删除现有网站（可选：也可以选择合并）
-		existing, _ := s.websiteRepo.GetAll()
-		for _, site := range existing {
-			s.websiteRepo.Delete(site.ID)
-		}
+	categories, err := s.categoryRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
 
-		// 导入新网站
-		for _, website := range data.Websites {
-			s.websiteRepo.Create(&website)
-		}
+	settings, err := s.settingsRepo.Get(userID)
+	if err != nil {
+		return nil, err
 	}
+	parsed := parseSettings(settings)
 
-	// 导入搜索引擎数据
-	if data.SearchEngines != nil {
-		// 删除现有搜索引擎
-		existing, _ := s.searchEngineRepo.GetAll()
-		for _, engine := range existing {
-			s.searchEngineRepo.Delete(engine.ID)
+	// 导出文件需要能在其他服务器上导入，图标库中的图标以 DataURL 内联
+	for i := range groups {
+		if groups[i].Icon, err = s.iconService.Inline(groups[i].Icon); err != nil {
+			return nil, err
 		}
-
-		// 导入新搜索引擎
-		for _, engine := range data.SearchEngines {
-			s.searchEngineRepo.Create(&engine)
+	}
+	if err := s.inlineWebsiteIcons(websites); err != nil {
+		return nil, err
+	}
+	for i := range searchEngines {
+		if searchEngines[i].Icon, err = s.iconService.Inline(searchEngines[i].Icon); err != nil {
+			return nil, err
 		}
 	}
 
-	// 导入设置
-	if data.Settings != nil {
-		updates := map[string]interface{}{
-			"theme":               data.Settings.Theme,
-			"layout_config":       data.Settings.LayoutConfig,
-			"background_config":   data.Settings.BackgroundConfig,
-			"card_style_config":   data.Settings.CardStyleConfig,
-			"search_config":       data.Settings.SearchConfig,
+	return &model.ExportData{
+		FormatVersion:          model.ExportFormatVersion,
+		ExportedAt:             time.Now(),
+		AppVersion:             version.Version,
+		Groups:                 emptyIfNil(groups),
+		Tags:                   tags,
+		Websites:               emptyIfNil(websites),
+		SearchEngineCategories: categories,
+		SearchEngines:          emptyIfNil(searchEngines),
+		Settings: &model.ExportSettings{
+			Theme:      parsed.Theme,
+			Layout:     &parsed.Layout,
+			Background: &parsed.Background,
+			CardStyle:  &parsed.CardStyle,
+			Search: &model.SearchPreferences{
+				Enabled:         parsed.Search.Enabled,
+				DefaultEngineId: parsed.Search.DefaultEngineId,
+				OpenInNewTab:    parsed.Search.OpenInNewTab,
+			},
+		},
+	}, nil
+}
+
+// inlineWebsiteIcons 把网站引用的图标库图标替换为 DataURL
+func (s *SettingsService) inlineWebsiteIcons(websites []model.Website) error {
+	for i := range websites {
+		icon, err := s.iconService.Inline(websites[i].Icon)
+		if err != nil {
+			return err
 		}
-		s.settingsRepo.Update(updates)
+		websites[i].Icon = icon
 	}
-
 	return nil
 }
 
+// emptyIfNil 保证导出的列表字段为空数组而不是null
+func emptyIfNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
 
-
-func (s *SettingsService) Reset() error {
-	return s.settingsRepo.Reset()
+func (s *SettingsService) Reset(userID string) error {
+	return s.settingsRepo.Reset(userID)
 } 
\ No newline at end of file
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrTagNotFound 标签不存在
+	ErrTagNotFound = errors.New("tag not found")
+	// ErrTagExists 同名标签已存在（名称忽略大小写）
+	ErrTagExists = errors.New("a tag with this name already exists, merge the tags instead")
+	// ErrInvalidTag 标签名称为空或过长
+	ErrInvalidTag = errors.New("invalid tag")
+)
+
+// maxTagNameLength 标签名称的长度上限（字符数）
+const maxTagNameLength = 50
+
+type TagService struct {
+	repo *repository.TagRepository
+}
+
+func NewTagService(repo *repository.TagRepository) *TagService {
+	return &TagService{repo: repo}
+}
+
+// GetAll 获取所有标签及其网站数量
+func (s *TagService) GetAll(userID string) ([]model.TagWithCount, error) {
+	return s.repo.GetAll(userID)
+}
+
+// Create 创建标签，同名（忽略大小写）标签已存在时返回 ErrTagExists
+func (s *TagService) Create(userID string, req model.CreateTagRequest) (*model.TagWithCount, error) {
+	name, err := normalizeTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	req.Name = name
+
+	if _, err := s.repo.FindByName(userID, name); err == nil {
+		return nil, ErrTagExists
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return s.repo.Create(userID, req)
+}
+
+// Update 重命名标签或修改颜色，新名称与其他标签相同时返回 ErrTagExists
+// 只改变大小写的重命名是允许的
+func (s *TagService) Update(userID, id string, req model.UpdateTagRequest) (*model.TagWithCount, error) {
+	if req.Name != nil {
+		name, err := normalizeTagName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		req.Name = &name
+
+		if other, err := s.repo.FindByName(userID, name); err == nil && other.ID != id {
+			return nil, ErrTagExists
+		} else if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	tag, err := s.repo.Update(userID, id, req)
+	if err == sql.ErrNoRows {
+		return nil, ErrTagNotFound
+	}
+	return tag, err
+}
+
+// Delete 删除标签，网站本身不受影响
+func (s *TagService) Delete(userID, id string) error {
+	if err := s.repo.Delete(userID, id); err == sql.ErrNoRows {
+		return ErrTagNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Merge 把源标签合并到目标标签，返回合并后的目标标签
+func (s *TagService) Merge(userID string, req model.MergeTagsRequest) (*model.TagWithCount, error) {
+	if err := s.repo.Merge(userID, req.SourceIds, req.TargetId); err == sql.ErrNoRows {
+		return nil, ErrTagNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(userID, req.TargetId)
+}
+
+// normalizeTagName 去掉首尾空白并合并连续空白
+func normalizeTagName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidTag)
+	}
+	if utf8.RuneCountInString(name) > maxTagNameLength {
+		return "", fmt.Errorf("%w: name exceeds %d characters", ErrInvalidTag, maxTagNameLength)
+	}
+	return name, nil
+}
+
+// normalizeTagNames 规范化网站的标签名称列表，忽略空名称并按名称（忽略大小写）去重
+func normalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
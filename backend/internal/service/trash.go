@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"nav-panel-backend/internal/repository"
+	"time"
+)
+
+// ErrNotInTrash 回收站中没有该条目，随分组一起删除的网站和子分组只能随该分组一起恢复或永久删除
+var ErrNotInTrash = errors.New("item not found in trash")
+
+// trashPurgeTick 回收站定时清理的检查间隔
+const trashPurgeTick = time.Hour
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	Retention time.Duration // 移入回收站多久后自动永久删除，为0时不自动清理
+}
+
+// TrashService 定期永久删除超过保留期限的回收站条目，各类条目的查看、恢复和永久删除由对应的服务提供
+type TrashService struct {
+	repo   *repository.TrashRepository
+	config TrashConfig
+}
+
+func NewTrashService(repo *repository.TrashRepository, config TrashConfig) *TrashService {
+	return &TrashService{repo: repo, config: config}
+}
+
+// Start 启动定时清理，启动时立即清理一次，ctx 取消时停止
+func (s *TrashService) Start(ctx context.Context) {
+	if s.config.Retention <= 0 {
+		return
+	}
+
+	go func() {
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if purged, err := s.PurgeExpired(); err != nil {
+					log.Println("Trash purge failed:", err)
+				} else if purged > 0 {
+					log.Printf("Trash purge finished, %d expired items deleted", purged)
+				}
+				timer.Reset(trashPurgeTick)
+			}
+		}
+	}()
+}
+
+// PurgeExpired 永久删除超过保留期限的回收站条目，返回删除的数量
+func (s *TrashService) PurgeExpired() (int64, error) {
+	if s.config.Retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.PurgeBefore(time.Now().Add(-s.config.Retention))
+}
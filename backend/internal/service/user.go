@@ -0,0 +1,152 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrUserNotFound 账号不存在
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUsernameTaken 用户名已被使用
+	ErrUsernameTaken = errors.New("username already exists")
+	// ErrLastAdmin 不能删除或降级最后一个管理员
+	ErrLastAdmin = errors.New("at least one admin account is required")
+	// ErrInvalidUser 用户名为空或角色无效
+	ErrInvalidUser = errors.New("invalid user")
+)
+
+// UserService 账号管理，仅管理员可用
+type UserService struct {
+	repo *repository.UserRepository
+}
+
+func NewUserService(repo *repository.UserRepository) *UserService {
+	return &UserService{repo: repo}
+}
+
+// GetAll 获取所有账号
+func (s *UserService) GetAll() ([]model.User, error) {
+	users, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return emptyIfNil(users), nil
+}
+
+// Create 创建账号，新账号会写入默认设置和默认搜索引擎
+func (s *UserService) Create(req model.CreateUserRequest) (*model.User, error) {
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return nil, fmt.Errorf("%w: username is required", ErrInvalidUser)
+	}
+
+	role := req.Role
+	if role == "" {
+		role = model.UserRoleUser
+	}
+	if err := validateRole(role); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetByUsername(username); err == nil {
+		return nil, ErrUsernameTaken
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(username, string(hash), role)
+}
+
+// Update 修改账号的密码或角色
+func (s *UserService) Update(id string, req model.UpdateUserRequest) (*model.User, error) {
+	user, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Role != nil && *req.Role != user.Role {
+		if err := validateRole(*req.Role); err != nil {
+			return nil, err
+		}
+		if user.IsAdmin() {
+			if err := s.ensureOtherAdmin(); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.repo.UpdateRole(id, *req.Role); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdatePassword(id, string(hash)); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.GetByID(id)
+}
+
+// Delete 删除账号及其所有数据
+func (s *UserService) Delete(id string) error {
+	user, err := s.get(id)
+	if err != nil {
+		return err
+	}
+
+	if user.IsAdmin() {
+		if err := s.ensureOtherAdmin(); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Delete(id)
+}
+
+func (s *UserService) get(id string) (*model.User, error) {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// ensureOtherAdmin 删除或降级管理员前确认还有其他管理员
+func (s *UserService) ensureOtherAdmin() error {
+	count, err := s.repo.CountAdmins()
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func validateRole(role model.UserRole) error {
+	switch role {
+	case model.UserRoleAdmin, model.UserRoleUser:
+		return nil
+	default:
+		return fmt.Errorf("%w: role %q must be admin or user", ErrInvalidUser, role)
+	}
+}
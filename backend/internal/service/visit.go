@@ -0,0 +1,156 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"nav-panel-backend/internal/model"
+	"nav-panel-backend/internal/repository"
+	"net/url"
+	"time"
+)
+
+// ErrInvalidVisitStats 访问统计的时间范围或数量超出允许范围
+var ErrInvalidVisitStats = errors.New("invalid visit stats query")
+
+const (
+	// defaultVisitStatsDays 访问统计默认统计最近的天数（含今天）
+	defaultVisitStatsDays = 30
+	// maxVisitStatsDays 访问统计最多统计的天数
+	maxVisitStatsDays = 365
+	// defaultVisitStatsLimit 访问排行默认返回的网站数量
+	defaultVisitStatsLimit = 10
+	// maxVisitStatsLimit 访问排行最多返回的网站数量
+	maxVisitStatsLimit = 100
+	// maxVisitReferrerLength 来源页面地址的长度上限，超出时不记录来源
+	maxVisitReferrerLength = 500
+)
+
+type VisitService struct {
+	repo        *repository.VisitRepository
+	websiteRepo *repository.WebsiteRepository
+}
+
+func NewVisitService(repo *repository.VisitRepository, websiteRepo *repository.WebsiteRepository) *VisitService {
+	return &VisitService{repo: repo, websiteRepo: websiteRepo}
+}
+
+// Visit 记录一次对网站的访问并返回跳转地址，visitorID 为空表示未登录访问
+// 记录失败只写日志，不影响跳转
+func (s *VisitService) Visit(userID, websiteID, visitorID, referrer string) (string, error) {
+	website, err := s.websiteRepo.GetByID(userID, websiteID)
+	if err == sql.ErrNoRows {
+		return "", ErrWebsiteNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.Record(userID, website.ID, visitorID, normalizeReferrer(referrer), time.Now()); err != nil {
+		log.Printf("Failed to record visit of website %s: %v", website.ID, err)
+	}
+
+	return website.URL, nil
+}
+
+// MostVisited 最近 days 天内访问最多的网站，days 或 limit 不大于0时使用默认值
+func (s *VisitService) MostVisited(userID string, days, limit int) ([]model.WebsiteVisitStat, error) {
+	since, limit, err := visitRankingRange(days, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Ranking(userID, since, limit, false)
+}
+
+// LeastVisited 最近 days 天内有访问但访问最少的网站，没有访问的网站见 NeverVisited
+func (s *VisitService) LeastVisited(userID string, days, limit int) ([]model.WebsiteVisitStat, error) {
+	since, limit, err := visitRankingRange(days, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Ranking(userID, since, limit, true)
+}
+
+// NeverVisited 最近 days 天内没有访问的网站，days 不大于0时为从未访问过的网站
+func (s *VisitService) NeverVisited(userID string, days int) ([]model.Website, error) {
+	if days > maxVisitStatsDays {
+		return nil, fmt.Errorf("%w: days must not exceed %d", ErrInvalidVisitStats, maxVisitStatsDays)
+	}
+
+	since := ""
+	if days > 0 {
+		since = visitSince(days)
+	}
+	return s.repo.NeverVisited(userID, since)
+}
+
+// DailyVisits 最近 days 天每天的访问次数，没有访问的日期为 0，websiteID 不为空时只统计该网站
+func (s *VisitService) DailyVisits(userID string, websiteID *string, days int) ([]model.DailyVisits, error) {
+	if days <= 0 {
+		days = defaultVisitStatsDays
+	}
+	if days > maxVisitStatsDays {
+		return nil, fmt.Errorf("%w: days must not exceed %d", ErrInvalidVisitStats, maxVisitStatsDays)
+	}
+
+	if websiteID != nil {
+		if _, err := s.websiteRepo.GetByID(userID, *websiteID); err == sql.ErrNoRows {
+			return nil, ErrWebsiteNotFound
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := s.repo.Daily(userID, websiteID, visitSince(days))
+	if err != nil {
+		return nil, err
+	}
+	visits := make(map[string]int, len(rows))
+	for _, row := range rows {
+		visits[row.Date] = row.Visits
+	}
+
+	result := make([]model.DailyVisits, 0, days)
+	start := time.Now().AddDate(0, 0, -(days - 1))
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i).Format(repository.VisitDayFormat)
+		result = append(result, model.DailyVisits{Date: day, Visits: visits[day]})
+	}
+	return result, nil
+}
+
+// visitRankingRange 校验访问排行的参数，返回统计起始日期和返回数量
+func visitRankingRange(days, limit int) (string, int, error) {
+	if days <= 0 {
+		days = defaultVisitStatsDays
+	}
+	if days > maxVisitStatsDays {
+		return "", 0, fmt.Errorf("%w: days must not exceed %d", ErrInvalidVisitStats, maxVisitStatsDays)
+	}
+	if limit <= 0 {
+		limit = defaultVisitStatsLimit
+	}
+	if limit > maxVisitStatsLimit {
+		return "", 0, fmt.Errorf("%w: limit must not exceed %d", ErrInvalidVisitStats, maxVisitStatsLimit)
+	}
+	return visitSince(days), limit, nil
+}
+
+// visitSince 最近 days 天（含今天）的起始日期，按服务器时区计算
+func visitSince(days int) string {
+	return time.Now().AddDate(0, 0, -(days - 1)).Format(repository.VisitDayFormat)
+}
+
+// normalizeReferrer 只保留来源页面的协议、主机和路径，去掉查询参数和片段以免记录敏感信息，也使同一页面的访问聚合到一行
+func normalizeReferrer(referrer string) string {
+	u, err := url.Parse(referrer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+
+	normalized := u.Scheme + "://" + u.Host + u.EscapedPath()
+	if len(normalized) > maxVisitReferrerLength {
+		return ""
+	}
+	return normalized
+}
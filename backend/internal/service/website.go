@@ -1,29 +1,66 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"html"
 	"nav-panel-backend/internal/model"
 	"nav-panel-backend/internal/repository"
+	"strings"
 	"time"
+	"unicode"
+)
+
+// ErrInvalidSearchQuery 搜索关键词为空或不包含可搜索的字符
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+const (
+	// defaultSearchLimit 搜索结果的默认数量
+	defaultSearchLimit = 20
+	// maxSearchLimit 搜索结果的数量上限
+	maxSearchLimit = 100
 )
 
 type WebsiteService struct {
-	repo *repository.WebsiteRepository
+	repo        *repository.WebsiteRepository
+	groupRepo   *repository.GroupRepository
+	tagRepo     *repository.TagRepository
+	iconService *IconService
 }
 
-func NewWebsiteService(repo *repository.WebsiteRepository) *WebsiteService {
-	return &WebsiteService{repo: repo}
+func NewWebsiteService(repo *repository.WebsiteRepository, groupRepo *repository.GroupRepository, tagRepo *repository.TagRepository, iconService *IconService) *WebsiteService {
+	return &WebsiteService{repo: repo, groupRepo: groupRepo, tagRepo: tagRepo, iconService: iconService}
 }
 
-func (s *WebsiteService) GetAll() ([]model.Website, error) {
-	return s.repo.GetAll()
+// GetAll 获取网站，指定标签时只返回同时带有所有这些标签的网站
+func (s *WebsiteService) GetAll(userID string, tags []string) ([]model.Website, error) {
+	return s.repo.GetByTags(userID, tags)
 }
 
-func (s *WebsiteService) GetByID(id string) (*model.Website, error) {
-	return s.repo.GetByID(id)
+func (s *WebsiteService) GetByID(userID, id string) (*model.Website, error) {
+	return s.repo.GetByID(userID, id)
 }
 
-func (s *WebsiteService) Create(req *model.CreateWebsiteRequest) (*model.Website, error) {
+func (s *WebsiteService) Create(userID string, req *model.CreateWebsiteRequest) (*model.Website, error) {
+	// 只能放入自己的分组
+	if req.GroupId != nil && *req.GroupId != "" {
+		if err := s.checkGroup(userID, *req.GroupId); err != nil {
+			return nil, err
+		}
+	}
+
+	tags, err := normalizeTagNames(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	icon, err := s.iconService.StoreRef(req.Icon)
+	if err != nil {
+		return nil, err
+	}
+
 	// 生成ID
 	id := generateID()
 
@@ -31,23 +68,33 @@ func (s *WebsiteService) Create(req *model.CreateWebsiteRequest) (*model.Website
 		ID:          id,
 		Name:        req.Name,
 		URL:         req.URL,
-		Icon:        req.Icon,
+		Icon:        icon,
 		Description: req.Description,
-		Category:    req.Category,
 		GroupId:     req.GroupId,
 	}
 
-	err := s.repo.Create(website)
+	err = s.repo.Create(userID, website)
 	if err != nil {
 		return nil, err
 	}
 
-	return website, nil
+	if len(tags) > 0 {
+		if err := s.tagRepo.SetWebsiteTags(userID, website.ID, tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// 没有指定图标时在后台自动发现
+	if website.Icon == nil || *website.Icon == "" {
+		s.iconService.Schedule(userID, website.ID)
+	}
+
+	return s.repo.GetByID(userID, website.ID)
 }
 
-func (s *WebsiteService) Update(id string, req *model.UpdateWebsiteRequest) (*model.Website, error) {
+func (s *WebsiteService) Update(userID, id string, req *model.UpdateWebsiteRequest) (*model.Website, error) {
 	// 检查网站是否存在
-	_, err := s.repo.GetByID(id)
+	existing, err := s.repo.GetByID(userID, id)
 	if err != nil {
 		return nil, fmt.Errorf("website not found: %w", err)
 	}
@@ -61,60 +108,184 @@ func (s *WebsiteService) Update(id string, req *model.UpdateWebsiteRequest) (*mo
 		updates["url"] = *req.URL
 	}
 	if req.Icon != nil {
-		updates["icon"] = *req.Icon
+		// 用户指定的图标不再由服务端自动更新
+		icon, err := s.iconService.StoreRef(req.Icon)
+		if err != nil {
+			return nil, err
+		}
+		updates["icon"] = *icon
+		updates["icon_source"] = nil
 	}
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
-	if req.Category != nil {
-		updates["category"] = *req.Category
-	}
 	if req.GroupId != nil {
 		// 处理 groupId：如果是空字符串，设置为 NULL
 		if *req.GroupId == "" {
 			updates["group_id"] = nil
 		} else {
+			if err := s.checkGroup(userID, *req.GroupId); err != nil {
+				return nil, err
+			}
 			updates["group_id"] = *req.GroupId
 		}
 	}
 
+	var tags []string
+	if req.Tags != nil {
+		if tags, err = normalizeTagNames(*req.Tags); err != nil {
+			return nil, err
+		}
+	}
+
 	// 执行更新
-	err = s.repo.Update(id, updates)
+	err = s.repo.Update(userID, id, updates)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.Tags != nil {
+		if err := s.tagRepo.SetWebsiteTags(userID, id, tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// URL 变化或清空图标时重新发现，用户指定的图标不受影响
+	urlChanged := req.URL != nil && *req.URL != existing.URL
+	if (urlChanged && req.Icon == nil) || (req.Icon != nil && *req.Icon == "") {
+		s.iconService.Schedule(userID, id)
+	}
+
 	// 返回更新后的数据
-	return s.repo.GetByID(id)
+	return s.repo.GetByID(userID, id)
 }
 
-func (s *WebsiteService) Delete(id string) error {
+// Delete 把网站移入回收站，可以恢复到原分组的原位置
+func (s *WebsiteService) Delete(userID, id string) error {
 	// 检查网站是否存在
-	_, err := s.repo.GetByID(id)
+	_, err := s.repo.GetByID(userID, id)
 	if err != nil {
 		return fmt.Errorf("website not found: %w", err)
 	}
 
-	return s.repo.Delete(id)
+	return s.repo.Trash(userID, id)
+}
+
+// GetTrash 获取回收站中的网站
+func (s *WebsiteService) GetTrash(userID string) ([]model.TrashedWebsite, error) {
+	return s.repo.GetTrash(userID)
 }
 
-func (s *WebsiteService) Reorder(req *model.ReorderWebsitesRequest) ([]model.Website, error) {
+// Restore 从回收站恢复网站，原分组已不存在时放到未分组中
+func (s *WebsiteService) Restore(userID, id string) (*model.Website, error) {
+	if err := s.repo.Restore(userID, id); err == sql.ErrNoRows {
+		return nil, ErrNotInTrash
+	} else if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(userID, id)
+}
+
+// Purge 永久删除回收站中的网站
+func (s *WebsiteService) Purge(userID, id string) error {
+	if err := s.repo.Purge(userID, id); err == sql.ErrNoRows {
+		return ErrNotInTrash
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// EmptyTrash 清空回收站中的网站，返回删除的数量
+func (s *WebsiteService) EmptyTrash(userID string) (int64, error) {
+	return s.repo.EmptyTrash(userID)
+}
+
+func (s *WebsiteService) Reorder(userID string, req *model.ReorderWebsitesRequest) ([]model.Website, error) {
 	// 验证所有网站ID是否存在
 	for _, id := range req.WebsiteIds {
-		_, err := s.repo.GetByID(id)
+		_, err := s.repo.GetByID(userID, id)
 		if err != nil {
 			return nil, fmt.Errorf("website not found: %s", id)
 		}
 	}
 
 	// 执行重新排序
-	err := s.repo.Reorder(req.WebsiteIds)
+	err := s.repo.Reorder(userID, req.WebsiteIds)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reorder websites: %w", err)
 	}
 
 	// 返回重新排序后的列表
-	return s.repo.GetAll()
+	return s.repo.GetAll(userID)
+}
+
+// RefreshIcon 立即重新发现网站图标并返回更新后的网站
+func (s *WebsiteService) RefreshIcon(ctx context.Context, userID, id string) (*model.Website, error) {
+	if err := s.iconService.Refresh(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(userID, id)
+}
+
+// Search 全文搜索网站，每个词按前缀匹配，多个词须同时出现
+// limit 不大于0时使用默认值，超过上限时按上限处理
+func (s *WebsiteService) Search(userID, q string, groupID *string, tags []string, limit int) ([]model.WebsiteSearchResult, error) {
+	match := buildFTSQuery(q)
+	if match == "" {
+		return nil, fmt.Errorf("%w: q must contain at least one letter or digit", ErrInvalidSearchQuery)
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	results, err := s.repo.Search(userID, model.WebsiteSearchQuery{
+		Match:    match,
+		GroupId: groupID,
+		Tags:    tags,
+		Limit:   min(limit, maxSearchLimit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		h := &results[i].Highlights
+		h.Name = renderHighlight(h.Name)
+		h.URL = renderHighlight(h.URL)
+		h.Description = renderHighlight(h.Description)
+		h.Tags = renderHighlight(h.Tags)
+	}
+	return results, nil
+}
+
+// buildFTSQuery 把用户输入转换为 FTS5 查询：每个词作为带前缀匹配的短语，避免输入被解析为 FTS5 语法
+func buildFTSQuery(q string) string {
+	var terms []string
+	for _, field := range strings.Fields(q) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		terms = append(terms, `"`+strings.ReplaceAll(field, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+var highlightReplacer = strings.NewReplacer(repository.HighlightStart, "<mark>", repository.HighlightEnd, "</mark>")
+
+// renderHighlight 转义 HTML 后把匹配标记替换为 <mark>，结果可以直接插入页面
+func renderHighlight(s string) string {
+	return highlightReplacer.Replace(html.EscapeString(s))
+}
+
+// checkGroup 检查分组属于该账号且未被删除，否则返回 ErrGroupNotFound
+func (s *WebsiteService) checkGroup(userID, groupID string) error {
+	if _, err := s.groupRepo.GetByID(userID, groupID); err == sql.ErrNoRows {
+		return fmt.Errorf("%w: '%s'", ErrGroupNotFound, groupID)
+	} else if err != nil {
+		return err
+	}
+	return nil
 }
 
 // generateID 生成唯一ID
@@ -0,0 +1,235 @@
+// Package svg 清理用户提供的 SVG 图标，防止通过图标注入脚本
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	svgNamespace   = "http://www.w3.org/2000/svg"
+	xlinkNamespace = "http://www.w3.org/1999/xlink"
+
+	// maxDepth 元素嵌套深度上限
+	maxDepth = 64
+)
+
+// ErrInvalidSVG SVG 无法解析或根元素不是 <svg>
+var ErrInvalidSVG = errors.New("invalid svg")
+
+// allowedElements 允许保留的元素，其他元素连同其内容一起删除
+// 不包含 script、foreignObject、image、a、动画元素（可以把 href 改成 javascript:）等
+var allowedElements = toSet(
+	"svg", "g", "defs", "title", "desc", "symbol", "use", "style",
+	"path", "rect", "circle", "ellipse", "line", "polyline", "polygon",
+	"text", "tspan", "textPath",
+	"linearGradient", "radialGradient", "stop", "pattern", "clipPath", "mask", "marker",
+	"filter", "feBlend", "feColorMatrix", "feComponentTransfer", "feComposite", "feDropShadow",
+	"feFlood", "feFuncA", "feFuncB", "feFuncG", "feFuncR", "feGaussianBlur", "feMerge",
+	"feMergeNode", "feMorphology", "feOffset",
+)
+
+// allowedAttributes 允许保留的属性，事件属性（on*）等其他属性一律删除
+var allowedAttributes = toSet(
+	"id", "class", "style", "transform", "viewBox", "preserveAspectRatio", "version",
+	"width", "height", "x", "y", "x1", "y1", "x2", "y2", "cx", "cy", "r", "rx", "ry", "fx", "fy", "fr",
+	"d", "points", "pathLength", "offset",
+	"fill", "fill-opacity", "fill-rule", "stroke", "stroke-width", "stroke-linecap", "stroke-linejoin",
+	"stroke-miterlimit", "stroke-dasharray", "stroke-dashoffset", "stroke-opacity",
+	"opacity", "color", "visibility", "display", "overflow", "vector-effect", "shape-rendering",
+	"clip-path", "clip-rule", "mask", "filter", "paint-order", "mix-blend-mode", "isolation",
+	"stop-color", "stop-opacity", "gradientUnits", "gradientTransform", "spreadMethod",
+	"patternUnits", "patternContentUnits", "patternTransform", "clipPathUnits", "maskUnits", "maskContentUnits",
+	"marker-start", "marker-mid", "marker-end", "markerWidth", "markerHeight", "markerUnits", "refX", "refY", "orient",
+	"font-family", "font-size", "font-weight", "font-style", "text-anchor", "dominant-baseline",
+	"alignment-baseline", "letter-spacing", "word-spacing", "text-decoration", "dx", "dy", "rotate",
+	"textLength", "lengthAdjust", "startOffset",
+	"filterUnits", "primitiveUnits", "in", "in2", "result", "mode", "type", "values", "operator",
+	"k1", "k2", "k3", "k4", "stdDeviation", "radius", "flood-color", "flood-opacity",
+	"tableValues", "slope", "intercept", "amplitude", "exponent",
+	"media",
+)
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+
+	// cssURLPattern CSS 中的 url() 引用，只保留指向文档内元素的 url(#id)
+	cssURLPattern = regexp.MustCompile(`(?i)url\s*\(\s*(['"]?)([^)'"]*)(['"]?)\s*\)`)
+	// cssDangerPattern 无法安全清理的 CSS，整段删除
+	cssDangerPattern = regexp.MustCompile(`(?i)@import|expression\s*\(|image-set\s*\(|javascript:|behavior\s*:|-moz-binding|\\`)
+	// cssURLStart 替换后仍残留的 url(，说明存在无法识别的写法
+	cssURLStart = regexp.MustCompile(`(?i)url\s*\(`)
+)
+
+// Sanitize 按白名单清理 SVG，返回重新序列化的文档
+// 删除脚本、foreignObject、事件属性、外部 href 和 CSS 中的外部 url()，不允许 DOCTYPE 和实体
+func Sanitize(data []byte) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = true
+
+	var out bytes.Buffer
+	var stack []string
+	skipDepth := 0
+	rootSeen := false
+	usesXlink := false
+
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSVG, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 {
+				skipDepth++
+				continue
+			}
+
+			if !rootSeen {
+				if t.Name.Local != "svg" || (t.Name.Space != "" && t.Name.Space != svgNamespace) {
+					return nil, fmt.Errorf("%w: root element must be <svg>", ErrInvalidSVG)
+				}
+				rootSeen = true
+			} else if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: multiple root elements", ErrInvalidSVG)
+			}
+
+			if !allowedElements[t.Name.Local] || (t.Name.Space != "" && t.Name.Space != svgNamespace) || len(stack) >= maxDepth {
+				skipDepth = 1
+				continue
+			}
+
+			out.WriteString("<" + t.Name.Local)
+			if len(stack) == 0 {
+				// 根元素的命名空间声明在结尾统一补上
+				out.WriteString(` xmlns="` + svgNamespace + `"`)
+			}
+			for _, attr := range t.Attr {
+				name, value, ok := sanitizeAttr(attr)
+				if !ok {
+					continue
+				}
+				if strings.HasPrefix(name, "xlink:") {
+					usesXlink = true
+				}
+				out.WriteString(" " + name + `="` + attrEscaper.Replace(value) + `"`)
+			}
+			out.WriteString(">")
+			stack = append(stack, t.Name.Local)
+
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			if len(stack) == 0 {
+				continue
+			}
+			out.WriteString("</" + stack[len(stack)-1] + ">")
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if skipDepth > 0 || len(stack) == 0 {
+				continue
+			}
+			text := string(t)
+			if stack[len(stack)-1] == "style" {
+				css, ok := sanitizeCSS(text)
+				if !ok {
+					continue
+				}
+				text = css
+			}
+			out.WriteString(textEscaper.Replace(text))
+
+		case xml.Directive:
+			// DOCTYPE 可以声明实体，直接拒绝
+			return nil, fmt.Errorf("%w: DOCTYPE is not allowed", ErrInvalidSVG)
+		}
+		// 注释和处理指令（包括 XML 声明）直接丢弃
+	}
+
+	if !rootSeen {
+		return nil, fmt.Errorf("%w: no <svg> element", ErrInvalidSVG)
+	}
+
+	result := out.Bytes()
+	if usesXlink {
+		result = bytes.Replace(result, []byte(`xmlns="`+svgNamespace+`"`), []byte(`xmlns="`+svgNamespace+`" xmlns:xlink="`+xlinkNamespace+`"`), 1)
+	}
+	return result, nil
+}
+
+// sanitizeAttr 检查单个属性，返回输出时使用的名称和清理后的值
+func sanitizeAttr(attr xml.Attr) (string, string, bool) {
+	value := attr.Value
+
+	switch {
+	case attr.Name.Local == "href" && (attr.Name.Space == "" || attr.Name.Space == xlinkNamespace):
+		// 只允许引用文档内的元素，如 <use href="#icon">
+		if !isLocalRef(value) {
+			return "", "", false
+		}
+		if attr.Name.Space == xlinkNamespace {
+			return "xlink:href", strings.TrimSpace(value), true
+		}
+		return "href", strings.TrimSpace(value), true
+	case attr.Name.Space != "":
+		// xmlns 声明、xml:space 和其他命名空间的属性
+		return "", "", false
+	case !allowedAttributes[attr.Name.Local]:
+		return "", "", false
+	case attr.Name.Local == "style":
+		css, ok := sanitizeCSS(value)
+		return "style", css, ok
+	}
+
+	// 表现属性（如 fill="url(#gradient)"）同样只能引用文档内的元素
+	if cleaned, ok := sanitizeCSS(value); !ok || cleaned != value {
+		return "", "", false
+	}
+	return attr.Name.Local, value, true
+}
+
+// sanitizeCSS 删除外部 url() 引用，包含无法安全处理的内容时返回 false
+func sanitizeCSS(css string) (string, bool) {
+	if cssDangerPattern.MatchString(css) {
+		return "", false
+	}
+
+	cleaned := cssURLPattern.ReplaceAllStringFunc(css, func(match string) string {
+		ref := cssURLPattern.FindStringSubmatch(match)[2]
+		if isLocalRef(ref) {
+			return match
+		}
+		return "none"
+	})
+	if cssURLStart.MatchString(cssURLPattern.ReplaceAllString(cleaned, "")) {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// isLocalRef 是否为指向文档内元素的引用
+func isLocalRef(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	return len(ref) > 1 && ref[0] == '#' && !strings.ContainsAny(ref, "\"'()<>\\ ")
+}
+
+func toSet(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
@@ -0,0 +1,4 @@
+package version
+
+// Version 应用版本号，构建时可通过 -ldflags "-X nav-panel-backend/internal/version.Version=x.y.z" 覆盖
+var Version = "dev"